@@ -6,7 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
-	"strconv"
+	"strings"
 	"time"
 
 	"github.com/akmmp241/topupstore-microservice/shared"
@@ -15,17 +15,29 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
-	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 const AuthTopic = "auth-mail-service"
 
+const AccessTokenExpiry = shared.UserTokenMaxLifetime
+
+const (
+	UserRegistration  = "user-registration"
+	UserLogin         = "user-login"
+	ForgotPassword    = "forgot-password"
+	EmailVerification = "email-verification"
+	EmailChange       = "email-change-requested"
+	ContactChange     = "contact-change-notice"
+	MagicLink         = "magic-link"
+)
+
 const (
-	UserRegistration = "user-registration"
-	UserLogin        = "user-login"
-	ForgotPassword   = "forgot-password"
+	resendVerificationPerEmail = 3
+	resendVerificationPerIp    = 10
+	resendVerificationWindow   = time.Hour
 )
 
 type AuthService struct {
@@ -33,23 +45,35 @@ type AuthService struct {
 	Validator         *validator.Validate
 	RedisClient       *redis.Client
 	UserServiceClient *upb.UserServiceClient
+	KeyManager        *KeyManager
+	SmsSender         SmsSender
 }
 
-func NewAuthService(p *KafkaProducer, v *validator.Validate, r *redis.Client, u *upb.UserServiceClient) *AuthService {
+func NewAuthService(p *KafkaProducer, v *validator.Validate, r *redis.Client, u *upb.UserServiceClient, k *KeyManager, sms SmsSender) *AuthService {
 	return &AuthService{
 		Producer:          p,
 		Validator:         v,
 		RedisClient:       r,
 		UserServiceClient: u,
+		KeyManager:        k,
+		SmsSender:         sms,
 	}
 }
 
 func (s *AuthService) RegisterRoutes(router fiber.Router) {
 	router.Post("/register", s.handleRegister)
 	router.Post("/login", s.Login)
+	router.Post("/magic-link", s.handleRequestMagicLink)
+	router.Post("/magic-link/verify", s.handleMagicCodeLogin)
+	router.Get("/magic-link/:token", s.handleMagicLinkLogin)
 	router.Get("/verify/:token", s.handleVerifyEmail)
+	router.Post("/verify/resend", s.handleResendVerification)
 	router.Post("/password", s.handleForgotPassword)
 	router.Patch("/password/:reset_token", s.handleResetPassword)
+	router.Post("/email/change", shared.JWTUserMiddleware, s.handleRequestEmailChange)
+	router.Get("/email/change/:token", s.handleConfirmEmailChange)
+	router.Post("/phone/change", shared.JWTUserMiddleware, s.handleRequestPhoneChange)
+	router.Post("/phone/change/confirm", shared.JWTUserMiddleware, s.handleConfirmPhoneChange)
 	router.Get("/me", s.handleGetUser).Use(shared.JWTUserMiddleware)
 }
 
@@ -66,14 +90,22 @@ func (s *AuthService) handleRegister(c *fiber.Ctx) error {
 		return shared.NewFailedValidationError(*registerRequest, err.(validator.ValidationErrors))
 	}
 
-	registerRequest.EmailVerificationToken = uuid.NewString()
+	verification, err := newEmailVerification(registerRequest.Email)
+	if err != nil {
+		slog.Error("Error occurred while creating email verification token", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
 
 	createUserReq := upb.CreateUserReq{
-		Name:                   registerRequest.Name,
-		Email:                  registerRequest.Email,
-		Password:               registerRequest.Password,
-		PhoneNumber:            registerRequest.PhoneNumber,
-		EmailVerificationToken: registerRequest.EmailVerificationToken,
+		Name:                       registerRequest.Name,
+		Email:                      registerRequest.Email,
+		Password:                   registerRequest.Password,
+		PhoneNumber:                registerRequest.PhoneNumber,
+		EmailVerificationToken:     verification.TokenHash,
+		EmailVerificationExpiresAt: timestamppb.New(verification.ExpiresAt),
+		ReferralCode:               strings.ToUpper(registerRequest.ReferralCode),
+		SignupIp:                   c.IP(),
+		SignupDevice:               c.Get("X-Device-Id"),
 	}
 
 	_, err = (*s.UserServiceClient).CreateUser(c.Context(), &createUserReq)
@@ -83,6 +115,9 @@ func (s *AuthService) handleRegister(c *fiber.Ctx) error {
 			slog.Error("User already exists", "err", err)
 			return fiber.NewError(fiber.StatusConflict, st.Message())
 		}
+		if ok && st.Code() == codes.InvalidArgument {
+			return fiber.NewError(fiber.StatusUnprocessableEntity, st.Message())
+		}
 
 		slog.Error("Error occurred while calling user service create user", "err", err)
 		return err
@@ -91,13 +126,10 @@ func (s *AuthService) handleRegister(c *fiber.Ctx) error {
 	baseEvent := AuthEvent[NewRegistrationMessage]{
 		EventTye: UserRegistration,
 		Data: &NewRegistrationMessage{
-			Email: registerRequest.Email,
-			Name:  registerRequest.Name,
-			VerificationUrl: fmt.Sprintf(
-				"%s/api/auth/verify/%s",
-				os.Getenv("APP_URL"),
-				registerRequest.EmailVerificationToken,
-			),
+			Email:           registerRequest.Email,
+			Name:            registerRequest.Name,
+			VerificationUrl: verification.Url,
+			ExpiresAt:       verification.ExpiresAt,
 		},
 	}
 
@@ -129,40 +161,55 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 		return shared.NewFailedValidationError(*loginRequest, err.(validator.ValidationErrors))
 	}
 
-	getUserByEmailReq := upb.GetUserByEmailReq{
-		Email: loginRequest.Email,
+	verifyCredentialsReq := upb.VerifyCredentialsReq{
+		Email:    loginRequest.Email,
+		Password: loginRequest.Password,
 	}
 
-	getUserRes, err := (*s.UserServiceClient).GetUserByEmail(c.Context(), &getUserByEmailReq)
+	// the password is checked by user service, the hash never leaves it
+	getUserRes, err := (*s.UserServiceClient).VerifyCredentials(c.Context(), &verifyCredentialsReq)
 	if err != nil {
 		st, ok := status.FromError(err)
-		if ok && st.Code() == codes.NotFound {
-			return fiber.NewError(fiber.StatusNotFound, "User not found")
+		if ok && st.Code() == codes.Unauthenticated {
+			return fiber.NewError(fiber.StatusUnauthorized, "Invalid credentials")
 		}
 
-		slog.Error("Error occurred while calling user service get user", "err", err)
+		slog.Error("Error occurred while calling user service verify credentials", "err", err)
 		return err
 	}
 	user := getUserRes.GetUser()
 
-	err = bcrypt.CompareHashAndPassword(
-		[]byte(user.GetPassword()),
-		[]byte(loginRequest.Password),
-	)
-	if err != nil {
-		slog.Error("Error occurred while comparing passwords", "err", err)
-		return fiber.NewError(fiber.StatusUnauthorized, "Invalid credentials")
+	return s.completeLogin(c, user)
+}
+
+// completeLogin issues the access token for an authenticated user and sends
+// the new login notification, whatever the login method was.
+func (s *AuthService) completeLogin(c *fiber.Ctx, user *upb.User) error {
+	if user.GetStatus() != "" && user.GetStatus() != shared.UserStatusActive {
+		slog.Warn("Login rejected for inactive user", "user-id", user.GetId(), "status", user.GetStatus())
+		return fiber.NewError(fiber.StatusForbidden, "Your account is "+user.GetStatus()+", please contact support")
 	}
 
 	// Generate JWT token
-	expiry := time.Now().Add(time.Hour * 24)
-	userId := strconv.Itoa(int(user.GetId()))
-	accessToken, err := shared.GenerateJWTForUser(userId, expiry)
+	expiry := time.Now().Add(AccessTokenExpiry)
+	sessionId := uuid.NewString()
+	accessToken, err := s.KeyManager.GenerateJWTForUser(user, sessionId, expiry)
 	if err != nil {
 		slog.Error("Error occurred while generating JWT token", "err", err)
 		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
 	}
 
+	err = shared.RecordSession(c.Context(), s.RedisClient, int(user.GetId()), &shared.Session{
+		Id:        sessionId,
+		IpAddress: c.IP(),
+		Device:    c.Get("User-Agent"),
+		CreatedAt: time.Now(),
+		ExpiresAt: expiry,
+	})
+	if err != nil {
+		slog.Warn("Error occurred while recording session", "err", err)
+	}
+
 	baseEvent := AuthEvent[NewLoginMessage]{
 		EventTye: UserLogin,
 		Data: &NewLoginMessage{
@@ -196,11 +243,17 @@ func (s *AuthService) handleVerifyEmail(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid token")
 	}
 
+	tokenHash, err := parseEmailVerification(token)
+	if err != nil {
+		slog.Error("Error occurred while parsing email verification token", "err", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid or expired token")
+	}
+
 	verifyEmailReq := &upb.VerifyEmailReq{
-		EmailVerificationToken: token,
+		EmailVerificationToken: tokenHash,
 	}
 
-	_, err := (*s.UserServiceClient).VerifyEmail(c.Context(), verifyEmailReq)
+	_, err = (*s.UserServiceClient).VerifyEmail(c.Context(), verifyEmailReq)
 	if err != nil {
 		st, ok := status.FromError(err)
 		if ok && st.Code() == codes.NotFound {
@@ -219,6 +272,106 @@ func (s *AuthService) handleVerifyEmail(c *fiber.Ctx) error {
 	})
 }
 
+func (s *AuthService) handleResendVerification(c *fiber.Ctx) error {
+	resendRequest := &ResendVerificationRequest{}
+	err := c.BodyParser(resendRequest)
+	if err != nil {
+		slog.Error("Error occurred while parsing request body", "err", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err = s.Validator.Struct(resendRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*resendRequest, err.(validator.ValidationErrors))
+	}
+
+	email := strings.ToLower(resendRequest.Email)
+
+	limits := map[string]int64{
+		"verify-resend:ip:" + c.IP():   resendVerificationPerIp,
+		"verify-resend:email:" + email: resendVerificationPerEmail,
+	}
+	for key, limit := range limits {
+		allowed, err := shared.AllowRequest(c.Context(), s.RedisClient, key, limit, resendVerificationWindow)
+		if err != nil {
+			slog.Error("Error occurred while checking rate limit", "err", err)
+			return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+		}
+		if !allowed {
+			return fiber.NewError(fiber.StatusTooManyRequests, "Too many requests, please try again later")
+		}
+	}
+
+	// the response never tells whether the email exists or is already verified
+	response := fiber.Map{
+		"message": "If the email is registered and not yet verified, a new verification link has been sent",
+		"data":    nil,
+		"errors":  nil,
+	}
+
+	getUserRes, err := (*s.UserServiceClient).GetUserByEmail(c.Context(), &upb.GetUserByEmailReq{
+		Email: resendRequest.Email,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && st.Code() == codes.NotFound {
+			return c.JSON(response)
+		}
+
+		slog.Error("Error occurred while calling user service get user", "err", err)
+		return err
+	}
+	user := getUserRes.GetUser()
+
+	if user.GetEmailVerifiedAt() != nil {
+		return c.JSON(response)
+	}
+
+	verification, err := newEmailVerification(user.GetEmail())
+	if err != nil {
+		slog.Error("Error occurred while creating email verification token", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	_, err = (*s.UserServiceClient).SetEmailVerificationToken(c.Context(), &upb.SetEmailVerificationTokenReq{
+		Email:                  user.GetEmail(),
+		EmailVerificationToken: verification.TokenHash,
+		ExpiresAt:              timestamppb.New(verification.ExpiresAt),
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && (st.Code() == codes.NotFound || st.Code() == codes.FailedPrecondition) {
+			return c.JSON(response)
+		}
+
+		slog.Error("Error occurred while calling user service set email verification token", "err", err)
+		return err
+	}
+
+	baseEvent := AuthEvent[EmailVerificationMessage]{
+		EventTye: EmailVerification,
+		Data: &EmailVerificationMessage{
+			Email:           user.GetEmail(),
+			Name:            user.GetName(),
+			VerificationUrl: verification.Url,
+			ExpiresAt:       verification.ExpiresAt,
+		},
+	}
+
+	emailVerificationMsgBytes, err := json.Marshal(baseEvent)
+	if err != nil {
+		slog.Error("Error occurred while marshalling message", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	msg := [2]string{"", string(emailVerificationMsgBytes)}
+	if err := s.Producer.Write(c.Context(), AuthTopic, msg); err != nil {
+		slog.Error("Error occurred while sending message to Kafka", "err", err)
+	}
+
+	return c.JSON(response)
+}
+
 func (s *AuthService) handleForgotPassword(c *fiber.Ctx) error {
 	forgotPasswordRequest := &ForgotPasswordRequest{}
 	err := c.BodyParser(forgotPasswordRequest)
@@ -357,6 +510,17 @@ func (s *AuthService) handleResetPassword(c *fiber.Ctx) error {
 	})
 }
 
+func (s *AuthService) handleGetJWKS(c *fiber.Ctx) error {
+	jwks, err := s.KeyManager.JWKS(c.Context())
+	if err != nil {
+		slog.Error("Error occurred while building JWKS", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(jwks)
+}
+
 func (s *AuthService) handleGetUser(c *fiber.Ctx) error {
 	userId, err := shared.GetUserIdFromToken(c)
 	if err != nil {
@@ -386,6 +550,7 @@ func (s *AuthService) handleGetUser(c *fiber.Ctx) error {
 		EmailVerifiedAt: getUserRes.GetUser().EmailVerifiedAt.AsTime(),
 		CreatedAt:       getUserRes.GetUser().CreatedAt.AsTime(),
 		UpdatedAt:       getUserRes.GetUser().UpdatedAt.AsTime(),
+		Roles:           getUserRes.GetUser().Roles,
 	}
 
 	return c.JSON(fiber.Map{
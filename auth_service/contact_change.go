@@ -0,0 +1,354 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	upb "github.com/akmmp241/topupstore-microservice/user-proto/v1"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	contactChangeEmail       = "email"
+	contactChangePhoneNumber = "phone_number"
+)
+
+const (
+	emailChangeExpiry = time.Hour
+	phoneOtpExpiry    = 10 * time.Minute
+
+	contactChangeRequestsPerUser = 5
+	contactChangeRequestWindow   = time.Hour
+	phoneOtpAttemptsPerUser      = 5
+)
+
+func (s *AuthService) handleRequestEmailChange(c *fiber.Ctx) error {
+	emailChangeRequest := &EmailChangeRequest{}
+	err := c.BodyParser(emailChangeRequest)
+	if err != nil {
+		slog.Error("Error occurred while parsing request body", "err", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err = s.Validator.Struct(emailChangeRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*emailChangeRequest, err.(validator.ValidationErrors))
+	}
+
+	user, err := s.getCurrentUser(c)
+	if err != nil {
+		return err
+	}
+
+	if err := s.limitContactChangeRequests(c, user.GetId()); err != nil {
+		return err
+	}
+
+	link, err := newVerificationLink(emailChangeAudience, emailChangeRequest.Email, "/api/auth/email/change/", emailChangeExpiry)
+	if err != nil {
+		slog.Error("Error occurred while creating email change token", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	err = s.requestContactChange(c, user, contactChangeEmail, emailChangeRequest.Email, link.TokenHash, link.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	emailChangeEvent := AuthEvent[EmailChangeMessage]{
+		EventTye: EmailChange,
+		Data: &EmailChangeMessage{
+			Name:            user.GetName(),
+			Email:           emailChangeRequest.Email,
+			ConfirmationUrl: link.Url,
+			ExpiresAt:       link.ExpiresAt,
+		},
+	}
+
+	emailChangeMsgBytes, err := json.Marshal(emailChangeEvent)
+	if err != nil {
+		slog.Error("Error occurred while marshalling message", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	msg := [2]string{"", string(emailChangeMsgBytes)}
+	if err := s.Producer.Write(c.Context(), AuthTopic, msg); err != nil {
+		slog.Error("Error occurred while sending message to Kafka", "err", err)
+	}
+
+	noticeEvent := AuthEvent[ContactChangeMessage]{
+		EventTye: ContactChange,
+		Data: &ContactChangeMessage{
+			Name:        user.GetName(),
+			Email:       user.GetEmail(),
+			Field:       "email address",
+			NewValue:    maskEmail(emailChangeRequest.Email),
+			RequestedAt: time.Now(),
+			IpAddress:   c.IP(),
+		},
+	}
+
+	noticeMsgBytes, err := json.Marshal(noticeEvent)
+	if err != nil {
+		slog.Error("Error occurred while marshalling message", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	msg = [2]string{"", string(noticeMsgBytes)}
+	if err := s.Producer.Write(c.Context(), AuthTopic, msg); err != nil {
+		slog.Error("Error occurred while sending message to Kafka", "err", err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "A confirmation link has been sent to the new email address",
+		"data":    fiber.Map{"expired_at": link.ExpiresAt},
+		"errors":  nil,
+	})
+}
+
+func (s *AuthService) handleConfirmEmailChange(c *fiber.Ctx) error {
+	token := c.Params("token")
+	if token == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid token")
+	}
+
+	tokenHash, err := parseVerificationLink(emailChangeAudience, token)
+	if err != nil {
+		slog.Error("Error occurred while parsing email change token", "err", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid or expired token")
+	}
+
+	_, err = s.confirmContactChange(c, &upb.ConfirmContactChangeReq{
+		Type:      contactChangeEmail,
+		TokenHash: tokenHash,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Email changed successfully",
+		"data":    nil,
+		"errors":  nil,
+	})
+}
+
+func (s *AuthService) handleRequestPhoneChange(c *fiber.Ctx) error {
+	phoneChangeRequest := &PhoneChangeRequest{}
+	err := c.BodyParser(phoneChangeRequest)
+	if err != nil {
+		slog.Error("Error occurred while parsing request body", "err", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err = s.Validator.Struct(phoneChangeRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*phoneChangeRequest, err.(validator.ValidationErrors))
+	}
+
+	user, err := s.getCurrentUser(c)
+	if err != nil {
+		return err
+	}
+
+	if err := s.limitContactChangeRequests(c, user.GetId()); err != nil {
+		return err
+	}
+
+	otp, err := newOtp()
+	if err != nil {
+		slog.Error("Error occurred while generating OTP", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+	expiresAt := time.Now().Add(phoneOtpExpiry)
+
+	err = s.requestContactChange(c, user, contactChangePhoneNumber, phoneChangeRequest.PhoneNumber, hashOtp(user.GetId(), otp), expiresAt)
+	if err != nil {
+		return err
+	}
+
+	// a fresh code gets a fresh set of attempts
+	if err := shared.ResetRateLimit(c.Context(), s.RedisClient, fmt.Sprintf("phone-change-confirm:%d", user.GetId())); err != nil {
+		slog.Warn("Error occurred while resetting rate limit", "err", err)
+	}
+
+	otpMessage := fmt.Sprintf("Your AkmalStore verification code is %s. It expires in %d minutes. Never share this code.", otp, int(phoneOtpExpiry.Minutes()))
+	if err := s.SmsSender.Send(c.Context(), phoneChangeRequest.PhoneNumber, otpMessage); err != nil {
+		slog.Error("Error occurred while sending OTP", "err", err)
+		return fiber.NewError(fiber.StatusBadGateway, "Failed to send verification code")
+	}
+
+	noticeMessage := fmt.Sprintf("AkmalStore: a request was made to change your account phone number to %s. If this was not you, please secure your account.", maskPhoneNumber(phoneChangeRequest.PhoneNumber))
+	if err := s.SmsSender.Send(c.Context(), user.GetPhoneNumber(), noticeMessage); err != nil {
+		slog.Error("Error occurred while sending phone change notice", "err", err)
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "A verification code has been sent to the new phone number",
+		"data":    fiber.Map{"expired_at": expiresAt},
+		"errors":  nil,
+	})
+}
+
+func (s *AuthService) handleConfirmPhoneChange(c *fiber.Ctx) error {
+	confirmRequest := &ConfirmPhoneChangeRequest{}
+	err := c.BodyParser(confirmRequest)
+	if err != nil {
+		slog.Error("Error occurred while parsing request body", "err", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err = s.Validator.Struct(confirmRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*confirmRequest, err.(validator.ValidationErrors))
+	}
+
+	userId := shared.GetUserClaims(c).Subject
+
+	// OTPs are short, so the number of guesses per code is capped
+	allowed, err := shared.AllowRequest(c.Context(), s.RedisClient, "phone-change-confirm:"+userId, phoneOtpAttemptsPerUser, phoneOtpExpiry)
+	if err != nil {
+		slog.Error("Error occurred while checking rate limit", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+	if !allowed {
+		return fiber.NewError(fiber.StatusTooManyRequests, "Too many attempts, please request a new code")
+	}
+
+	id, err := strconv.ParseInt(userId, 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	_, err = s.confirmContactChange(c, &upb.ConfirmContactChangeReq{
+		Type:      contactChangePhoneNumber,
+		TokenHash: hashOtp(int32(id), confirmRequest.Otp),
+		UserId:    userId,
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Phone number changed successfully",
+		"data":    nil,
+		"errors":  nil,
+	})
+}
+
+func (s *AuthService) getCurrentUser(c *fiber.Ctx) (*upb.User, error) {
+	getUserRes, err := (*s.UserServiceClient).GetUserById(c.Context(), &upb.GetUserByIdReq{
+		Id: shared.GetUserClaims(c).Subject,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && st.Code() == codes.NotFound {
+			slog.Error("User not found", "err", err)
+			return nil, fiber.NewError(fiber.StatusNotFound, st.Message())
+		}
+
+		slog.Error("Error occurred while calling user service get user", "err", err)
+		return nil, err
+	}
+
+	return getUserRes.GetUser(), nil
+}
+
+func (s *AuthService) limitContactChangeRequests(c *fiber.Ctx, userId int32) error {
+	key := fmt.Sprintf("contact-change:%d", userId)
+	allowed, err := shared.AllowRequest(c.Context(), s.RedisClient, key, contactChangeRequestsPerUser, contactChangeRequestWindow)
+	if err != nil {
+		slog.Error("Error occurred while checking rate limit", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+	if !allowed {
+		return fiber.NewError(fiber.StatusTooManyRequests, "Too many requests, please try again later")
+	}
+
+	return nil
+}
+
+func (s *AuthService) requestContactChange(c *fiber.Ctx, user *upb.User, changeType string, newValue string, tokenHash string, expiresAt time.Time) error {
+	_, err := (*s.UserServiceClient).RequestContactChange(c.Context(), &upb.RequestContactChangeReq{
+		UserId:    strconv.Itoa(int(user.GetId())),
+		Type:      changeType,
+		NewValue:  newValue,
+		TokenHash: tokenHash,
+		ExpiresAt: timestamppb.New(expiresAt),
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && st.Code() == codes.AlreadyExists {
+			return fiber.NewError(fiber.StatusConflict, st.Message())
+		}
+		if ok && st.Code() == codes.InvalidArgument {
+			return fiber.NewError(fiber.StatusBadRequest, st.Message())
+		}
+
+		slog.Error("Error occurred while calling user service request contact change", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *AuthService) confirmContactChange(c *fiber.Ctx, req *upb.ConfirmContactChangeReq) (*upb.ConfirmContactChangeRes, error) {
+	res, err := (*s.UserServiceClient).ConfirmContactChange(c.Context(), req)
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && st.Code() == codes.NotFound {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid or expired token")
+		}
+		if ok && st.Code() == codes.AlreadyExists {
+			return nil, fiber.NewError(fiber.StatusConflict, st.Message())
+		}
+
+		slog.Error("Error occurred while calling user service confirm contact change", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func newOtp() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashOtp binds the code to the user, six digits alone are not unique.
+func hashOtp(userId int32, otp string) string {
+	return hashToken(fmt.Sprintf("%d:%s", userId, otp))
+}
+
+func maskEmail(email string) string {
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || len(local) == 0 {
+		return email
+	}
+
+	return local[:1] + strings.Repeat("*", len(local)-1) + "@" + domain
+}
+
+func maskPhoneNumber(phoneNumber string) string {
+	if len(phoneNumber) <= 4 {
+		return phoneNumber
+	}
+
+	return strings.Repeat("*", len(phoneNumber)-4) + phoneNumber[len(phoneNumber)-4:]
+}
@@ -3,12 +3,12 @@ package main
 import "time"
 
 type RegisterRequest struct {
-	Email                  string `json:"email" validate:"required,email"`
-	Password               string `json:"password" validate:"required,min=8,max=255"`
-	Name                   string `json:"name" validate:"required,min=2,max=255"`
-	PhoneNumber            string `json:"phone_number" validate:"required,min=10,max=15"`
-	PasswordConfirmation   string `json:"password_confirmation" validate:"required,eqfield=Password"`
-	EmailVerificationToken string `json:"email_verification_token"`
+	Email                string `json:"email" validate:"required,email"`
+	Password             string `json:"password" validate:"required,min=8,max=255"`
+	Name                 string `json:"name" validate:"required,min=2,max=255"`
+	PhoneNumber          string `json:"phone_number" validate:"required,min=10,max=15"`
+	PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password"`
+	ReferralCode         string `json:"referral_code" validate:"omitempty,alphanum,max=20"`
 }
 
 type LoginRequest struct {
@@ -16,6 +16,31 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required,min=8,max=255"`
 }
 
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type MagicCodeRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Code  string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type EmailChangeRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type PhoneChangeRequest struct {
+	PhoneNumber string `json:"phone_number" validate:"required,min=10,max=15"`
+}
+
+type ConfirmPhoneChangeRequest struct {
+	Otp string `json:"otp" validate:"required,len=6,numeric"`
+}
+
 type ForgotPasswordRequest struct {
 	Email string `json:"email" validate:"required,email"`
 }
@@ -38,7 +63,7 @@ type GetUserResponse struct {
 	Errors  any          `json:"errors"`
 }
 
-type AuthEvent[T NewLoginMessage | NewRegistrationMessage | ForgotPasswordMessage] struct {
+type AuthEvent[T NewLoginMessage | NewRegistrationMessage | ForgotPasswordMessage | EmailVerificationMessage | EmailChangeMessage | ContactChangeMessage | MagicLinkMessage] struct {
 	EventTye string `json:"event_type"`
 	Data     *T     `json:"data"`
 }
@@ -52,9 +77,42 @@ type NewLoginMessage struct {
 }
 
 type NewRegistrationMessage struct {
-	Name            string `json:"name"`
-	VerificationUrl string `json:"verification_url"`
-	Email           string `json:"email"`
+	Name            string    `json:"name"`
+	VerificationUrl string    `json:"verification_url"`
+	Email           string    `json:"email"`
+	ExpiresAt       time.Time `json:"expired_at"`
+}
+
+type EmailVerificationMessage struct {
+	Name            string    `json:"name"`
+	VerificationUrl string    `json:"verification_url"`
+	Email           string    `json:"email"`
+	ExpiresAt       time.Time `json:"expired_at"`
+}
+
+type EmailChangeMessage struct {
+	Name            string    `json:"name"`
+	Email           string    `json:"email"`
+	ConfirmationUrl string    `json:"confirmation_url"`
+	ExpiresAt       time.Time `json:"expired_at"`
+}
+
+// ContactChangeMessage warns the current address that a change was requested.
+type ContactChangeMessage struct {
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	Field       string    `json:"field"`
+	NewValue    string    `json:"new_value"`
+	RequestedAt time.Time `json:"requested_at"`
+	IpAddress   string    `json:"ip_address"`
+}
+
+type MagicLinkMessage struct {
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	LoginUrl  string    `json:"login_url"`
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expired_at"`
 }
 
 type ForgotPasswordMessage struct {
@@ -85,4 +143,5 @@ type GetResponse struct {
 	EmailVerifiedAt time.Time `json:"email_verified_at"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
+	Roles           []string  `json:"roles"`
 }
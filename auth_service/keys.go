@@ -0,0 +1,446 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	upb "github.com/akmmp241/topupstore-microservice/user-proto/v1"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	signingKeysKey     = "jwt:signing-keys"
+	rotationLockKey    = "jwt:signing-keys:rotation-lock"
+	rotationLockExpiry = 30 * time.Second
+	keyReloadInterval  = time.Minute
+	keyWaitInterval    = 500 * time.Millisecond
+
+	defaultRotationInterval = 30 * 24 * time.Hour
+)
+
+// StoredKey is the representation of a signing key in Redis. Keys are shared
+// between auth service replicas so that any of them can sign and publish
+// the same JWKS. Other services reach the same Redis, so the private key is
+// sealed with a key encryption key only the auth service is given.
+type StoredKey struct {
+	Kid                 string    `json:"kid"`
+	Alg                 string    `json:"alg"`
+	EncryptedPrivateKey string    `json:"encrypted_private_key"`
+	CreatedAt           time.Time `json:"created_at"`
+
+	// PrivateKey is the plain PEM written before keys were encrypted, such
+	// keys are sealed on the next reload
+	PrivateKey string `json:"private_key,omitempty"`
+}
+
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	createdAt time.Time
+}
+
+type KeyManager struct {
+	RedisClient      *redis.Client
+	Alg              string
+	RotationInterval time.Duration
+	Kek              cipher.AEAD
+
+	mu   sync.RWMutex
+	keys []*signingKey // newest first
+}
+
+func NewKeyManager(r *redis.Client) *KeyManager {
+	alg := os.Getenv("JWT_SIGNING_ALG")
+	if alg == "" {
+		alg = jwt.SigningMethodEdDSA.Alg()
+	}
+
+	if alg != jwt.SigningMethodEdDSA.Alg() && alg != jwt.SigningMethodRS256.Alg() {
+		slog.Error("Unsupported JWT signing algorithm", "alg", alg)
+		panic("unsupported JWT_SIGNING_ALG " + alg)
+	}
+
+	rotationInterval := defaultRotationInterval
+	if v := os.Getenv("JWT_KEY_ROTATION_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			slog.Error("Invalid JWT key rotation interval", "value", v, "err", err)
+			panic("invalid JWT_KEY_ROTATION_INTERVAL " + v)
+		}
+		rotationInterval = d
+	}
+
+	kek, err := loadSigningKeyKek()
+	if err != nil {
+		slog.Error("Error occurred while loading signing key encryption key", "err", err)
+		panic(err)
+	}
+
+	m := &KeyManager{
+		RedisClient:      r,
+		Alg:              alg,
+		RotationInterval: rotationInterval,
+		Kek:              kek,
+	}
+
+	if err := m.rotateIfDue(context.Background()); err != nil {
+		slog.Error("Error occurred while preparing signing keys", "err", err)
+		panic(err)
+	}
+
+	if err := m.waitForSigningKey(context.Background()); err != nil {
+		slog.Error("Error occurred while waiting for a signing key", "err", err)
+		panic(err)
+	}
+
+	return m
+}
+
+// waitForSigningKey keeps a replica that lost the rotation lock at startup
+// from serving without a key until the next tick. It reloads until the
+// winner publishes the key, or takes the lock over if the winner died.
+func (m *KeyManager) waitForSigningKey(ctx context.Context) error {
+	deadline := time.Now().Add(2 * rotationLockExpiry)
+
+	for {
+		m.mu.RLock()
+		ready := len(m.keys) > 0
+		m.mu.RUnlock()
+
+		if ready {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.New("no signing key was published")
+		}
+
+		time.Sleep(keyWaitInterval)
+
+		if err := m.rotateIfDue(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// Run reloads the key set from Redis and rotates the active key once it is
+// older than the rotation interval. It blocks until ctx is done.
+func (m *KeyManager) Run(ctx context.Context) {
+	ticker := time.NewTicker(keyReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.rotateIfDue(ctx); err != nil {
+				slog.Error("Error occurred while rotating signing keys", "err", err)
+			}
+		}
+	}
+}
+
+func (m *KeyManager) GenerateJWTForUser(user *upb.User, sessionId string, expiry time.Time) (string, error) {
+	claims := shared.UserClaims{
+		Roles:       user.GetRoles(),
+		Permissions: user.GetPermissions(),
+		Tier:        user.GetTier(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        sessionId,
+			Subject:   strconv.Itoa(int(user.GetId())),
+			Issuer:    shared.UserTokenIssuer,
+			ExpiresAt: jwt.NewNumericDate(expiry),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	return m.Sign(claims)
+}
+
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.keys) == 0 {
+		return "", errors.New("no signing key available")
+	}
+
+	active := m.keys[0]
+
+	token := jwt.NewWithClaims(active.method, claims)
+	token.Header["kid"] = active.kid
+
+	return token.SignedString(active.private)
+}
+
+func (m *KeyManager) JWKS(_ context.Context) (*shared.JWKS, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	jwks := &shared.JWKS{Keys: []shared.JWK{}}
+	for _, key := range m.keys {
+		jwk, err := shared.NewJWK(key.kid, key.method.Alg(), key.private.Public())
+		if err != nil {
+			return nil, err
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks, nil
+}
+
+func (m *KeyManager) rotateIfDue(ctx context.Context) error {
+	if err := m.reload(ctx); err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	due := len(m.keys) == 0 || time.Since(m.keys[0].createdAt) >= m.RotationInterval
+	m.mu.RUnlock()
+
+	if !due {
+		return nil
+	}
+
+	// only one replica generates the next key, the others pick it up on reload
+	locked, err := m.RedisClient.SetNX(ctx, rotationLockKey, "1", rotationLockExpiry).Result()
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return nil
+	}
+	defer m.RedisClient.Del(ctx, rotationLockKey)
+
+	stored, err := m.generate()
+	if err != nil {
+		return err
+	}
+
+	value, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	if err := m.RedisClient.HSet(ctx, signingKeysKey, stored.Kid, value).Err(); err != nil {
+		return err
+	}
+
+	slog.Info("Rotated JWT signing key", "kid", stored.Kid, "alg", stored.Alg)
+
+	return m.reload(ctx)
+}
+
+// reload reads every key from Redis and drops the ones that cannot have
+// signed a still valid token anymore.
+func (m *KeyManager) reload(ctx context.Context) error {
+	values, err := m.RedisClient.HGetAll(ctx, signingKeysKey).Result()
+	if err != nil {
+		return err
+	}
+
+	var keys []*signingKey
+	for kid, value := range values {
+		var stored StoredKey
+		if err := json.Unmarshal([]byte(value), &stored); err != nil {
+			slog.Warn("Skipping malformed signing key", "kid", kid, "err", err)
+			continue
+		}
+
+		if stored.PrivateKey != "" {
+			if err := m.sealLegacyKey(ctx, &stored); err != nil {
+				slog.Warn("Error occurred while encrypting plain signing key", "kid", kid, "err", err)
+			}
+		}
+
+		key, err := m.parseStoredKey(&stored)
+		if err != nil {
+			slog.Warn("Skipping malformed signing key", "kid", kid, "err", err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].createdAt.After(keys[j].createdAt)
+	})
+
+	// a key stops signing when its successor is created and its last token
+	// expires AccessTokenExpiry later, so it must stay published until then
+	var retired []string
+	for i := 1; i < len(keys); i++ {
+		if time.Since(keys[i-1].createdAt) > AccessTokenExpiry {
+			for _, key := range keys[i:] {
+				retired = append(retired, key.kid)
+			}
+			keys = keys[:i]
+			break
+		}
+	}
+
+	if len(retired) > 0 {
+		if err := m.RedisClient.HDel(ctx, signingKeysKey, retired...).Err(); err != nil {
+			slog.Warn("Error occurred while deleting retired signing keys", "err", err)
+		}
+		slog.Info("Retired JWT signing keys", "kids", retired)
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.mu.Unlock()
+
+	return nil
+}
+
+func (m *KeyManager) generate() (*StoredKey, error) {
+	var private crypto.Signer
+	var err error
+
+	switch m.Alg {
+	case jwt.SigningMethodRS256.Alg():
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	kid := uuid.NewString()
+
+	return &StoredKey{
+		Kid:                 kid,
+		Alg:                 m.Alg,
+		EncryptedPrivateKey: m.seal(kid, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		CreatedAt:           time.Now(),
+	}, nil
+}
+
+// sealLegacyKey replaces a plain PEM key in Redis with its encrypted form.
+func (m *KeyManager) sealLegacyKey(ctx context.Context, stored *StoredKey) error {
+	stored.EncryptedPrivateKey = m.seal(stored.Kid, []byte(stored.PrivateKey))
+	stored.PrivateKey = ""
+
+	value, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	return m.RedisClient.HSet(ctx, signingKeysKey, stored.Kid, value).Err()
+}
+
+// seal encrypts a PEM key, the kid is authenticated so that a sealed key
+// cannot be moved to another kid.
+func (m *KeyManager) seal(kid string, plain []byte) string {
+	nonce := make([]byte, m.Kek.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(m.Kek.Seal(nonce, nonce, plain, []byte(kid)))
+}
+
+func (m *KeyManager) open(kid string, sealed string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < m.Kek.NonceSize() {
+		return nil, errors.New("sealed key too short")
+	}
+
+	nonce, ciphertext := data[:m.Kek.NonceSize()], data[m.Kek.NonceSize():]
+	return m.Kek.Open(nil, nonce, ciphertext, []byte(kid))
+}
+
+// loadSigningKeyKek reads the hex encoded AES-256 key encryption key from
+// SIGNING_KEY_KEK_FILE, a secret mounted into the auth service only.
+func loadSigningKeyKek() (cipher.AEAD, error) {
+	path := os.Getenv("SIGNING_KEY_KEK_FILE")
+	if path == "" {
+		return nil, errors.New("SIGNING_KEY_KEK_FILE is not set")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	kek, err := hex.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key encryption key: %w", err)
+	}
+	if len(kek) != 32 {
+		return nil, errors.New("signing key encryption key must be 32 bytes")
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (m *KeyManager) parseStoredKey(stored *StoredKey) (*signingKey, error) {
+	pemKey, err := m.open(stored.Kid, stored.EncryptedPrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt private key: %w", err)
+	}
+
+	block, _ := pem.Decode(pemKey)
+	if block == nil {
+		return nil, errors.New("invalid PEM block")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", parsed)
+	}
+
+	method := jwt.GetSigningMethod(stored.Alg)
+	if method == nil {
+		return nil, fmt.Errorf("unsupported signing algorithm %s", stored.Alg)
+	}
+
+	return &signingKey{
+		kid:       stored.Kid,
+		method:    method,
+		private:   private,
+		createdAt: stored.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	upb "github.com/akmmp241/topupstore-microservice/user-proto/v1"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const magicLinkAudience = "magic-link"
+
+const (
+	magicLinkExpiry         = 10 * time.Minute
+	magicCodeAttempts       = 5
+	magicLinkRequestsPerIp  = 10
+	magicLinkRequestsPerKey = 3
+	magicLinkRequestWindow  = 15 * time.Minute
+)
+
+// Both the link and the code of one request live in the magic-code hash so
+// that using either one burns the other.
+func magicCodeKey(email string) string {
+	return "magic-code:" + strings.ToLower(email)
+}
+
+func magicLinkKey(tokenHash string) string {
+	return "magic-link:" + tokenHash
+}
+
+func (s *AuthService) handleRequestMagicLink(c *fiber.Ctx) error {
+	magicLinkRequest := &MagicLinkRequest{}
+	err := c.BodyParser(magicLinkRequest)
+	if err != nil {
+		slog.Error("Error occurred while parsing request body", "err", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err = s.Validator.Struct(magicLinkRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*magicLinkRequest, err.(validator.ValidationErrors))
+	}
+
+	email := strings.ToLower(magicLinkRequest.Email)
+
+	limits := map[string]int64{
+		"magic-link:ip:" + c.IP():   magicLinkRequestsPerIp,
+		"magic-link:email:" + email: magicLinkRequestsPerKey,
+	}
+	for key, limit := range limits {
+		allowed, err := shared.AllowRequest(c.Context(), s.RedisClient, key, limit, magicLinkRequestWindow)
+		if err != nil {
+			slog.Error("Error occurred while checking rate limit", "err", err)
+			return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+		}
+		if !allowed {
+			return fiber.NewError(fiber.StatusTooManyRequests, "Too many requests, please try again later")
+		}
+	}
+
+	// the response never tells whether the email is registered
+	response := fiber.Map{
+		"message": "If the email is registered, a sign in link and code have been sent",
+		"data":    nil,
+		"errors":  nil,
+	}
+
+	getUserRes, err := (*s.UserServiceClient).GetUserByEmail(c.Context(), &upb.GetUserByEmailReq{
+		Email: magicLinkRequest.Email,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && st.Code() == codes.NotFound {
+			return c.JSON(response)
+		}
+
+		slog.Error("Error occurred while calling user service get user", "err", err)
+		return err
+	}
+	user := getUserRes.GetUser()
+
+	link, err := newVerificationLink(magicLinkAudience, user.GetEmail(), "/api/auth/magic-link/", magicLinkExpiry)
+	if err != nil {
+		slog.Error("Error occurred while creating magic link token", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	code, err := newOtp()
+	if err != nil {
+		slog.Error("Error occurred while generating OTP", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	codeKey := magicCodeKey(user.GetEmail())
+
+	// a new request replaces the previous link and code
+	previousLink, err := s.RedisClient.HGet(c.Context(), codeKey, "link").Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		slog.Error("Error occurred while getting Redis key", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	_, err = s.RedisClient.TxPipelined(c.Context(), func(pipe redis.Pipeliner) error {
+		if previousLink != "" {
+			pipe.Del(c.Context(), magicLinkKey(previousLink))
+		}
+		pipe.Del(c.Context(), codeKey)
+		pipe.HSet(c.Context(), codeKey, "code", hashToken(code), "link", link.TokenHash, "attempts", 0)
+		pipe.Expire(c.Context(), codeKey, magicLinkExpiry)
+		pipe.SetEx(c.Context(), magicLinkKey(link.TokenHash), user.GetEmail(), magicLinkExpiry)
+		return nil
+	})
+	if err != nil {
+		slog.Error("Error occurred while setting Redis key", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	baseEvent := AuthEvent[MagicLinkMessage]{
+		EventTye: MagicLink,
+		Data: &MagicLinkMessage{
+			Name:      user.GetName(),
+			Email:     user.GetEmail(),
+			LoginUrl:  link.Url,
+			Code:      code,
+			ExpiresAt: link.ExpiresAt,
+		},
+	}
+
+	magicLinkMsgBytes, err := json.Marshal(baseEvent)
+	if err != nil {
+		slog.Error("Error occurred while marshalling message", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	msg := [2]string{"", string(magicLinkMsgBytes)}
+	if err := s.Producer.Write(c.Context(), AuthTopic, msg); err != nil {
+		slog.Error("Error occurred while sending message to Kafka", "err", err)
+	}
+
+	return c.JSON(response)
+}
+
+func (s *AuthService) handleMagicLinkLogin(c *fiber.Ctx) error {
+	token := c.Params("token")
+	if token == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid token")
+	}
+
+	tokenHash, err := parseVerificationLink(magicLinkAudience, token)
+	if err != nil {
+		slog.Error("Error occurred while parsing magic link token", "err", err)
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired link")
+	}
+
+	// GETDEL makes the link single use even under concurrent clicks
+	email, err := s.RedisClient.GetDel(c.Context(), magicLinkKey(tokenHash)).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired link")
+		}
+		slog.Error("Error occurred while getting Redis key", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+	s.RedisClient.Del(c.Context(), magicCodeKey(email))
+
+	return s.completeMagicLogin(c, email)
+}
+
+func (s *AuthService) handleMagicCodeLogin(c *fiber.Ctx) error {
+	magicCodeRequest := &MagicCodeRequest{}
+	err := c.BodyParser(magicCodeRequest)
+	if err != nil {
+		slog.Error("Error occurred while parsing request body", "err", err)
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err = s.Validator.Struct(magicCodeRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*magicCodeRequest, err.(validator.ValidationErrors))
+	}
+
+	codeKey := magicCodeKey(magicCodeRequest.Email)
+
+	// counting before comparing caps the guesses even for parallel requests
+	attempts, err := s.RedisClient.HIncrBy(c.Context(), codeKey, "attempts", 1).Result()
+	if err != nil {
+		slog.Error("Error occurred while incrementing Redis key", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	stored, err := s.RedisClient.HMGet(c.Context(), codeKey, "code", "link").Result()
+	if err != nil {
+		slog.Error("Error occurred while getting Redis key", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+	codeHash, _ := stored[0].(string)
+	linkHash, _ := stored[1].(string)
+
+	if codeHash == "" {
+		// HIncrBy created an empty hash for an unknown email
+		s.RedisClient.Del(c.Context(), codeKey)
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired code")
+	}
+
+	if attempts > magicCodeAttempts {
+		s.RedisClient.Del(c.Context(), codeKey, magicLinkKey(linkHash))
+		return fiber.NewError(fiber.StatusTooManyRequests, "Too many attempts, please request a new code")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(codeHash), []byte(hashToken(magicCodeRequest.Code))) != 1 {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired code")
+	}
+
+	// only the request that actually deletes the key may log in
+	deleted, err := s.RedisClient.Del(c.Context(), codeKey).Result()
+	if err != nil {
+		slog.Error("Error occurred while deleting Redis key", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+	if deleted == 0 {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired code")
+	}
+	s.RedisClient.Del(c.Context(), magicLinkKey(linkHash))
+
+	return s.completeMagicLogin(c, magicCodeRequest.Email)
+}
+
+func (s *AuthService) completeMagicLogin(c *fiber.Ctx, email string) error {
+	getUserRes, err := (*s.UserServiceClient).GetUserByEmail(c.Context(), &upb.GetUserByEmailReq{
+		Email: email,
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && st.Code() == codes.NotFound {
+			return fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired link")
+		}
+
+		slog.Error("Error occurred while calling user service get user", "err", err)
+		return err
+	}
+
+	return s.completeLogin(c, getUserRes.GetUser())
+}
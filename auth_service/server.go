@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
 
@@ -18,7 +19,10 @@ func NewAppServer() *AppServer {
 	validate := validator.New()
 
 	server := fiber.New(fiber.Config{
-		ErrorHandler: shared.ErrorHandler,
+		ErrorHandler:            shared.ErrorHandler,
+		ProxyHeader:             shared.ClientIPHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          shared.TrustedProxies(),
 	})
 
 	app := server.Group("/api/auth")
@@ -38,9 +42,17 @@ func NewAppServer() *AppServer {
 
 	producer := NewKafkaProducer(bootstrapServer)
 
-	authService := NewAuthService(producer, validate, redisClient, &userServiceGrpc)
+	keyManager := NewKeyManager(redisClient)
+	go keyManager.Run(context.Background())
+
+	// verify our own tokens straight from the key manager
+	shared.UseJWKSSource(keyManager.JWKS)
+
+	authService := NewAuthService(producer, validate, redisClient, &userServiceGrpc, keyManager, NewSmsSender())
 	authService.RegisterRoutes(app)
 
+	server.Get("/.well-known/jwks.json", authService.handleGetJWKS)
+
 	return &AppServer{
 		server: server,
 	}
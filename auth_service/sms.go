@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SmsSender delivers short text messages such as OTPs to a phone number.
+type SmsSender interface {
+	Send(ctx context.Context, to string, message string) error
+}
+
+// NewSmsSender picks the sender from SMS_SENDER: "log" (default) or "http".
+func NewSmsSender() SmsSender {
+	switch os.Getenv("SMS_SENDER") {
+	case "", "log":
+		if os.Getenv("APP_ENV") == "production" {
+			slog.Warn("SMS messages are only logged, set SMS_SENDER to deliver them")
+		}
+		return &LogSmsSender{}
+	case "http":
+		channel := os.Getenv("SMS_CHANNEL")
+		if channel == "" {
+			channel = "sms"
+		}
+		return &HttpSmsSender{
+			Url:     os.Getenv("SMS_GATEWAY_URL"),
+			Token:   os.Getenv("SMS_GATEWAY_TOKEN"),
+			Channel: channel,
+		}
+	default:
+		panic("unsupported SMS_SENDER " + os.Getenv("SMS_SENDER"))
+	}
+}
+
+// LogSmsSender is the local fake, it writes messages to the log instead of
+// sending them.
+type LogSmsSender struct{}
+
+func (l *LogSmsSender) Send(_ context.Context, to string, message string) error {
+	slog.Info("SMS sent", "to", to, "message", message)
+	return nil
+}
+
+// HttpSmsSender posts messages to an SMS or WhatsApp gateway.
+type HttpSmsSender struct {
+	Url     string
+	Token   string
+	Channel string // "sms" or "whatsapp"
+}
+
+func (h *HttpSmsSender) Send(_ context.Context, to string, message string) error {
+	agent := fiber.Post(h.Url).Timeout(10 * time.Second).JSON(fiber.Map{
+		"channel": h.Channel,
+		"to":      to,
+		"message": message,
+	})
+	if h.Token != "" {
+		agent.Set(fiber.HeaderAuthorization, "Bearer "+h.Token)
+	}
+
+	statusCode, body, errs := agent.Bytes()
+	if len(errs) > 0 {
+		return errs[0]
+	}
+
+	if statusCode < 200 || statusCode >= 300 {
+		return fmt.Errorf("sms gateway returned status %d: %s", statusCode, body)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	emailVerificationAudience = "email-verification"
+	emailChangeAudience       = "email-change"
+)
+
+const defaultEmailVerificationExpiry = time.Hour * 24
+
+// VerificationLink is what a registration, resend or email change produces:
+// the link that is mailed to the user and the hash that user service stores.
+// The raw token only ever lives inside the signed link.
+type VerificationLink struct {
+	Url       string
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+func newEmailVerification(email string) (*VerificationLink, error) {
+	return newVerificationLink(emailVerificationAudience, email, "/api/auth/verify/", getEmailVerificationExpiry())
+}
+
+func parseEmailVerification(signed string) (string, error) {
+	return parseVerificationLink(emailVerificationAudience, signed)
+}
+
+func newVerificationLink(audience string, email string, path string, expiry time.Duration) (*VerificationLink, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(raw)
+
+	expiresAt := time.Now().Add(expiry)
+
+	claims := jwt.RegisteredClaims{
+		ID:        token,
+		Subject:   email,
+		Audience:  jwt.ClaimStrings{audience},
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+	}
+
+	secret, err := getEmailVerificationSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VerificationLink{
+		Url:       fmt.Sprintf("%s%s%s", os.Getenv("APP_URL"), path, signed),
+		TokenHash: hashToken(token),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// parseVerificationLink checks the signature, audience and expiry of a link
+// token and returns the hash to look the pending record up with.
+func parseVerificationLink(audience string, signed string) (string, error) {
+	secret, err := getEmailVerificationSecret()
+	if err != nil {
+		return "", err
+	}
+
+	claims := &jwt.RegisteredClaims{}
+	_, err = jwt.ParseWithClaims(signed, claims, func(token *jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(audience))
+	if err != nil {
+		return "", err
+	}
+
+	if claims.ID == "" {
+		return "", errors.New("missing verification token")
+	}
+
+	return hashToken(claims.ID), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func getEmailVerificationSecret() ([]byte, error) {
+	secret := os.Getenv("EMAIL_VERIFICATION_SECRET")
+	if secret == "" {
+		return nil, errors.New("missing configuration: EMAIL_VERIFICATION_SECRET")
+	}
+
+	return []byte(secret), nil
+}
+
+func getEmailVerificationExpiry() time.Duration {
+	expiry, err := time.ParseDuration(os.Getenv("EMAIL_VERIFICATION_EXPIRY"))
+	if err != nil || expiry <= 0 {
+		return defaultEmailVerificationExpiry
+	}
+
+	return expiry
+}
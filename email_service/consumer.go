@@ -13,6 +13,7 @@ import (
 const (
 	AuthTopic  = "auth-mail-service"
 	OrderTopic = "order-mail-service"
+	UserTopic  = "user-events"
 )
 
 type HandlerKafka func(msg *kafka.Message) error
@@ -20,6 +21,7 @@ type HandlerKafka func(msg *kafka.Message) error
 type KafkaConsumer struct {
 	AuthReader   *kafka.Reader
 	OrderReader  *kafka.Reader
+	UserReader   *kafka.Reader
 	EmailService *EmailService
 }
 
@@ -37,6 +39,7 @@ func NewKafkaConsumer(bootstrapServer string, groupId string) *KafkaConsumer {
 	return &KafkaConsumer{
 		AuthReader:  initReader(kafkaConfig, AuthTopic),
 		OrderReader: initReader(kafkaConfig, OrderTopic),
+		UserReader:  initReader(kafkaConfig, UserTopic),
 	}
 }
 
@@ -90,3 +93,26 @@ func (c *KafkaConsumer) StartOrderConsumer(handler HandlerKafka) {
 		slog.Debug("Received message", "message:", string(message.Value), "key", string(message.Key))
 	}
 }
+
+func (c *KafkaConsumer) StartUserConsumer(handler HandlerKafka) {
+	defer c.UserReader.Close()
+	for {
+		message, err := c.UserReader.ReadMessage(context.Background())
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				slog.Warn("Reached EOF, possibly no messages yet.")
+				continue
+			}
+			slog.Error("Error while reading", "error:", err)
+			break
+		}
+
+		err = handler(&message)
+		if err != nil {
+			slog.Error("Error while handling message", "error:", err)
+			continue
+		}
+
+		slog.Debug("Received message", "message:", string(message.Value), "key", string(message.Key))
+	}
+}
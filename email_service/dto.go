@@ -6,10 +6,18 @@ type BaseEvent struct {
 	EventType string `json:"event_type"`
 }
 
-type AuthEvent[T NewLoginMessage | NewRegistrationMessage | ForgotPasswordMessage] struct {
+type AuthEvent[T NewLoginMessage | NewRegistrationMessage | ForgotPasswordMessage | EmailVerificationMessage | EmailChangeMessage | ContactChangeMessage | MagicLinkMessage] struct {
 	Data *T `json:"data"`
 }
 
+type UserEvent struct {
+	Data *AccountDeletionMessage `json:"data"`
+}
+
+type ReferralRewardedEvent struct {
+	Data *ReferralRewardedMessage `json:"data"`
+}
+
 type OrderEvent struct {
 	Data *OrderMsg `json:"data"`
 }
@@ -29,9 +37,59 @@ type NewLoginMessage struct {
 }
 
 type NewRegistrationMessage struct {
-	Name            string `json:"name"`
-	VerificationUrl string `json:"verification_url"`
-	Email           string `json:"email"`
+	Name            string    `json:"name"`
+	VerificationUrl string    `json:"verification_url"`
+	Email           string    `json:"email"`
+	ExpiresAt       time.Time `json:"expired_at"`
+}
+
+type EmailVerificationMessage struct {
+	Name            string    `json:"name"`
+	VerificationUrl string    `json:"verification_url"`
+	Email           string    `json:"email"`
+	ExpiresAt       time.Time `json:"expired_at"`
+}
+
+type EmailChangeMessage struct {
+	Name            string    `json:"name"`
+	Email           string    `json:"email"`
+	ConfirmationUrl string    `json:"confirmation_url"`
+	ExpiresAt       time.Time `json:"expired_at"`
+}
+
+type ContactChangeMessage struct {
+	Name        string    `json:"name"`
+	Email       string    `json:"email"`
+	Field       string    `json:"field"`
+	NewValue    string    `json:"new_value"`
+	RequestedAt time.Time `json:"requested_at"`
+	IpAddress   string    `json:"ip_address"`
+}
+
+type MagicLinkMessage struct {
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	LoginUrl  string    `json:"login_url"`
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expired_at"`
+}
+
+type AccountDeletionMessage struct {
+	Name            string    `json:"name"`
+	Email           string    `json:"email"`
+	ConfirmationUrl string    `json:"confirmation_url"`
+	GraceDays       int       `json:"grace_days"`
+	ExpiresAt       time.Time `json:"expired_at"`
+}
+
+// ReferralRewardedMessage goes to both users of a referral, Role tells
+// whether the receiver invited or was invited.
+type ReferralRewardedMessage struct {
+	UserId int    `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	Amount int    `json:"amount"`
 }
 
 type ForgotPasswordMessage struct {
@@ -17,6 +17,24 @@ var NewLoginEmail string
 //go:embed templates/user-registration.html
 var NewRegistrationEmail string
 
+//go:embed templates/email-verification.html
+var EmailVerificationEmail string
+
+//go:embed templates/email-change.html
+var EmailChangeEmail string
+
+//go:embed templates/contact-change-notice.html
+var ContactChangeEmail string
+
+//go:embed templates/magic-link.html
+var MagicLinkEmail string
+
+//go:embed templates/account-deletion.html
+var AccountDeletionEmail string
+
+//go:embed templates/referral-reward.html
+var ReferralRewardEmail string
+
 //go:embed templates/forget-password.html
 var ForgetPasswordEmail string
 
@@ -30,12 +48,18 @@ var SuccessOrderEmail string
 var FailedOrderEmail string
 
 const (
-	UserRegistration = "user-registration"
-	UserLogin        = "user-login"
-	ForgotPassword   = "forgot-password"
-	NewOrder         = "new-order"
-	SuccessOrder     = "order-succeeded"
-	FailedOrder      = "order-failed"
+	UserRegistration  = "user-registration"
+	UserLogin         = "user-login"
+	ForgotPassword    = "forgot-password"
+	EmailVerification = "email-verification"
+	EmailChange       = "email-change-requested"
+	ContactChange     = "contact-change-notice"
+	MagicLink         = "magic-link"
+	AccountDeletion   = "account-deletion-requested"
+	ReferralRewarded  = "referral-rewarded"
+	NewOrder          = "new-order"
+	SuccessOrder      = "order-succeeded"
+	FailedOrder       = "order-failed"
 )
 
 type EmailService struct {
@@ -76,12 +100,70 @@ func (e *EmailService) HandleAuth(msg *kafka.Message) error {
 			return err
 		}
 		return e.handleForgotPassword(data.Data)
+	case EmailVerification:
+		var data *AuthEvent[EmailVerificationMessage]
+		if err := json.Unmarshal(msg.Value, &data); err != nil {
+			slog.Error("Error unmarshalling message", "error", err)
+			return err
+		}
+		return e.handleEmailVerification(data.Data)
+	case EmailChange:
+		var data *AuthEvent[EmailChangeMessage]
+		if err := json.Unmarshal(msg.Value, &data); err != nil {
+			slog.Error("Error unmarshalling message", "error", err)
+			return err
+		}
+		return e.handleEmailChange(data.Data)
+	case ContactChange:
+		var data *AuthEvent[ContactChangeMessage]
+		if err := json.Unmarshal(msg.Value, &data); err != nil {
+			slog.Error("Error unmarshalling message", "error", err)
+			return err
+		}
+		return e.handleContactChange(data.Data)
+	case MagicLink:
+		var data *AuthEvent[MagicLinkMessage]
+		if err := json.Unmarshal(msg.Value, &data); err != nil {
+			slog.Error("Error unmarshalling message", "error", err)
+			return err
+		}
+		return e.handleMagicLink(data.Data)
 	default:
 		slog.Warn("Unknown event type", "event-type", base.EventType)
 		return nil
 	}
 }
 
+// HandleUser only cares about events that need a mail, the user topic also
+// carries events meant for other services.
+func (e *EmailService) HandleUser(msg *kafka.Message) error {
+	var base BaseEvent
+
+	if err := json.Unmarshal(msg.Value, &base); err != nil {
+		slog.Error("Error unmarshalling message", "error", err)
+		return err
+	}
+
+	switch base.EventType {
+	case AccountDeletion:
+		var data *UserEvent
+		if err := json.Unmarshal(msg.Value, &data); err != nil {
+			slog.Error("Error unmarshalling message", "error", err)
+			return err
+		}
+		return e.handleAccountDeletion(data.Data)
+	case ReferralRewarded:
+		var data *ReferralRewardedEvent
+		if err := json.Unmarshal(msg.Value, &data); err != nil {
+			slog.Error("Error unmarshalling message", "error", err)
+			return err
+		}
+		return e.handleReferralRewarded(data.Data)
+	default:
+		return nil
+	}
+}
+
 func (e *EmailService) HandleOrder(msg *kafka.Message) error {
 	var base BaseEvent
 
@@ -220,6 +302,210 @@ func (e *EmailService) handleForgotPassword(msg *ForgotPasswordMessage) error {
 	return nil
 }
 
+func (e *EmailService) handleEmailVerification(msg *EmailVerificationMessage) error {
+	tmpl, err := template.New("email-verification").Parse(EmailVerificationEmail)
+	if err != nil {
+		slog.Error("Error parsing template", "error", err)
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, msg); err != nil {
+		slog.Error("Error creating buffer", "error", err)
+		return err
+	}
+
+	to := os.Getenv("SMTP_FROM")
+	if os.Getenv("APP_ENV") == "production" {
+		to = msg.Email
+	}
+
+	emailData := &SendMail{
+		To:      to,
+		Subject: "Verify Your Email Address",
+		Body:    body.String(),
+	}
+
+	if err := e.Mailer.SendMail(emailData); err != nil {
+		slog.Error("Error sending mail", "error", err)
+		return err
+	}
+
+	slog.Info("Email sent successfully", "to", to, "subject", emailData.Subject)
+
+	return nil
+}
+
+func (e *EmailService) handleEmailChange(msg *EmailChangeMessage) error {
+	tmpl, err := template.New("email-change").Parse(EmailChangeEmail)
+	if err != nil {
+		slog.Error("Error parsing template", "error", err)
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, msg); err != nil {
+		slog.Error("Error creating buffer", "error", err)
+		return err
+	}
+
+	to := os.Getenv("SMTP_FROM")
+	if os.Getenv("APP_ENV") == "production" {
+		to = msg.Email
+	}
+
+	emailData := &SendMail{
+		To:      to,
+		Subject: "Confirm Your New Email Address",
+		Body:    body.String(),
+	}
+
+	if err := e.Mailer.SendMail(emailData); err != nil {
+		slog.Error("Error sending mail", "error", err)
+		return err
+	}
+
+	slog.Info("Email sent successfully", "to", to, "subject", emailData.Subject)
+
+	return nil
+}
+
+func (e *EmailService) handleContactChange(msg *ContactChangeMessage) error {
+	tmpl, err := template.New("contact-change-notice").Parse(ContactChangeEmail)
+	if err != nil {
+		slog.Error("Error parsing template", "error", err)
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, msg); err != nil {
+		slog.Error("Error creating buffer", "error", err)
+		return err
+	}
+
+	to := os.Getenv("SMTP_FROM")
+	if os.Getenv("APP_ENV") == "production" {
+		to = msg.Email
+	}
+
+	emailData := &SendMail{
+		To:      to,
+		Subject: "Account Change Requested",
+		Body:    body.String(),
+	}
+
+	if err := e.Mailer.SendMail(emailData); err != nil {
+		slog.Error("Error sending mail", "error", err)
+		return err
+	}
+
+	slog.Info("Email sent successfully", "to", to, "subject", emailData.Subject)
+
+	return nil
+}
+
+func (e *EmailService) handleMagicLink(msg *MagicLinkMessage) error {
+	tmpl, err := template.New("magic-link").Parse(MagicLinkEmail)
+	if err != nil {
+		slog.Error("Error parsing template", "error", err)
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, msg); err != nil {
+		slog.Error("Error creating buffer", "error", err)
+		return err
+	}
+
+	to := os.Getenv("SMTP_FROM")
+	if os.Getenv("APP_ENV") == "production" {
+		to = msg.Email
+	}
+
+	emailData := &SendMail{
+		To:      to,
+		Subject: "Your Sign In Link",
+		Body:    body.String(),
+	}
+
+	if err := e.Mailer.SendMail(emailData); err != nil {
+		slog.Error("Error sending mail", "error", err)
+		return err
+	}
+
+	slog.Info("Email sent successfully", "to", to, "subject", emailData.Subject)
+
+	return nil
+}
+
+func (e *EmailService) handleAccountDeletion(msg *AccountDeletionMessage) error {
+	tmpl, err := template.New("account-deletion").Parse(AccountDeletionEmail)
+	if err != nil {
+		slog.Error("Error parsing template", "error", err)
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, msg); err != nil {
+		slog.Error("Error creating buffer", "error", err)
+		return err
+	}
+
+	to := os.Getenv("SMTP_FROM")
+	if os.Getenv("APP_ENV") == "production" {
+		to = msg.Email
+	}
+
+	emailData := &SendMail{
+		To:      to,
+		Subject: "Confirm Account Deletion",
+		Body:    body.String(),
+	}
+
+	if err := e.Mailer.SendMail(emailData); err != nil {
+		slog.Error("Error sending mail", "error", err)
+		return err
+	}
+
+	slog.Info("Email sent successfully", "to", to, "subject", emailData.Subject)
+
+	return nil
+}
+
+func (e *EmailService) handleReferralRewarded(msg *ReferralRewardedMessage) error {
+	tmpl, err := template.New("referral-reward").Parse(ReferralRewardEmail)
+	if err != nil {
+		slog.Error("Error parsing template", "error", err)
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, msg); err != nil {
+		slog.Error("Error creating buffer", "error", err)
+		return err
+	}
+
+	to := os.Getenv("SMTP_FROM")
+	if os.Getenv("APP_ENV") == "production" {
+		to = msg.Email
+	}
+
+	emailData := &SendMail{
+		To:      to,
+		Subject: "You Earned a Referral Reward",
+		Body:    body.String(),
+	}
+
+	if err := e.Mailer.SendMail(emailData); err != nil {
+		slog.Error("Error sending mail", "error", err)
+		return err
+	}
+
+	slog.Info("Email sent successfully", "to", to, "subject", emailData.Subject)
+
+	return nil
+}
+
 func (e *EmailService) handleNewOrder(msg *OrderMsg) error {
 	tmpl, err := template.New("new-order").Parse(NewOrderEmail)
 	if err != nil {
@@ -25,7 +25,7 @@ func NewAppServer() *AppServer {
 }
 
 func (a *AppServer) RunConsumer(wg *sync.WaitGroup) {
-	wg.Add(2)
+	wg.Add(3)
 
 	go func() {
 		slog.Info("Starting Auth Mail Consumer")
@@ -38,4 +38,10 @@ func (a *AppServer) RunConsumer(wg *sync.WaitGroup) {
 		a.Consumer.StartOrderConsumer(a.EmailService.HandleOrder)
 		defer wg.Done()
 	}()
+
+	go func() {
+		slog.Info("Starting User Mail Consumer")
+		a.Consumer.StartUserConsumer(a.EmailService.HandleUser)
+		defer wg.Done()
+	}()
 }
@@ -203,6 +203,7 @@ type Product struct {
 	Category      *Category              `protobuf:"bytes,6,opt,name=category,proto3" json:"category,omitempty"`
 	Operator      *Operator              `protobuf:"bytes,7,opt,name=operator,proto3" json:"operator,omitempty"`
 	ProductType   *ProductType           `protobuf:"bytes,8,opt,name=product_type,json=productType,proto3" json:"product_type,omitempty"`
+	CreatedAt     string                 `protobuf:"bytes,9,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // RFC 3339, sorts newest first
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -293,6 +294,13 @@ func (x *Product) GetProductType() *ProductType {
 	return nil
 }
 
+func (x *Product) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
 type BulkIndexSummary struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	TotalIndexed  uint64                 `protobuf:"varint,1,opt,name=total_indexed,json=totalIndexed,proto3" json:"total_indexed,omitempty"`
@@ -361,7 +369,7 @@ const file_indexer_proto_rawDesc = "" +
 	"\timage_url\x18\x04 \x01(\tR\bimageUrl\"1\n" +
 	"\vProductType\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\"\xa2\x02\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"\xc1\x02\n" +
 	"\aProduct\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
@@ -370,7 +378,9 @@ const file_indexer_proto_rawDesc = "" +
 	"\vdescription\x18\x05 \x01(\tR\vdescription\x120\n" +
 	"\bcategory\x18\x06 \x01(\v2\x14.indexer.v1.CategoryR\bcategory\x120\n" +
 	"\boperator\x18\a \x01(\v2\x14.indexer.v1.OperatorR\boperator\x12:\n" +
-	"\fproduct_type\x18\b \x01(\v2\x17.indexer.v1.ProductTypeR\vproductType\"Z\n" +
+	"\fproduct_type\x18\b \x01(\v2\x17.indexer.v1.ProductTypeR\vproductType\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\t \x01(\tR\tcreatedAt\"Z\n" +
 	"\x10BulkIndexSummary\x12#\n" +
 	"\rtotal_indexed\x18\x01 \x01(\x04R\ftotalIndexed\x12!\n" +
 	"\ftotal_failed\x18\x02 \x01(\x04R\vtotalFailed2Z\n" +
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"io"
 	"log/slog"
+	"strconv"
 
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
@@ -54,7 +55,7 @@ func (s *IndexerService) HandleProductIndexer(msg *kafka.Message) error {
 func (s *IndexerService) indexProduct(product Product, body io.Reader) error {
 	req := esapi.IndexRequest{
 		Index:      ProductIndex,
-		DocumentID: product.ID,
+		DocumentID: strconv.Itoa(product.ID),
 		Body:       body,
 		Refresh:    "true",
 	}
@@ -78,7 +79,7 @@ func (s *IndexerService) indexProduct(product Product, body io.Reader) error {
 func (s *IndexerService) deleteProduct(product Product) error {
 	req := esapi.DeleteRequest{
 		Index:      ProductIndex,
-		DocumentID: product.ID,
+		DocumentID: strconv.Itoa(product.ID),
 		Refresh:    "true",
 	}
 
@@ -28,3 +28,6 @@ var VirtualAccountChannelCodes = []string{
 var QrisChannelCode = []string{
 	"QRIS",
 }
+
+// BalanceChannelCode pays from the buyer's wallet instead of through Xendit.
+const BalanceChannelCode = "BALANCE"
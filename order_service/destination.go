@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	prpb "github.com/akmmp241/topupstore-microservice/product-proto/v1"
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (o *OrderService) getProductType(ctx context.Context, product *prpb.Product) (*prpb.ProductType, error) {
+	getProductTypeByIdRes, err := (*o.ProductService).GetProductTypeById(ctx, &prpb.GetProductTypeByIdReq{ProductTypeId: product.GetProductTypeId()})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && st.Code() == codes.NotFound {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Product not found")
+		}
+
+		slog.Error("Error occurred while calling product service get product type", "err", err)
+		return nil, err
+	}
+
+	return getProductTypeByIdRes.GetProductType(), nil
+}
+
+// validateDestination checks the destination values against the format form
+// of the product type and returns them as destination and server id.
+func validateDestination(productType *prpb.ProductType, fields map[string]string, destination string, serverId string) (string, string, error) {
+	form, err := shared.ParseFormSchema(productType.GetFormatForm())
+	if err != nil {
+		// a broken form is a catalog mistake, it should not stop sales
+		slog.Warn("Malformed format form, skipping destination validation", "product-type-id", productType.GetId(), "err", err)
+		if len(fields) > 0 {
+			return "", "", fiber.NewError(fiber.StatusBadRequest, "Destination fields are not supported for this product")
+		}
+		return destination, serverId, nil
+	}
+
+	if len(fields) == 0 {
+		fields = form.Values(destination, serverId)
+	}
+
+	if errMsgs := form.Validate(fields); errMsgs != nil {
+		return "", "", shared.FailedValidationError{Errors: errMsgs}
+	}
+
+	destination, serverId = form.Destination(fields)
+	return destination, serverId, nil
+}
+
+// checkOperatorPrefix rejects phone numbers of another operator than the
+// one of the product, a wrong pick would top up the wrong network.
+func (o *OrderService) checkOperatorPrefix(ctx context.Context, product *prpb.Product, destination string) error {
+	matchRes, err := (*o.ProductService).MatchOperatorPrefix(ctx, &prpb.MatchOperatorPrefixReq{
+		OperatorId: product.GetOperatorId(),
+		Msisdn:     destination,
+	})
+	if err != nil {
+		slog.Error("Error occurred while calling product service match operator prefix", "err", err)
+		return err
+	}
+
+	if !matchRes.GetApplies() || matchRes.GetMatches() {
+		return nil
+	}
+
+	msg := "The destination is not a phone number of this operator"
+	if matchRes.GetDetectedOperatorName() != "" {
+		msg = "The destination is a " + matchRes.GetDetectedOperatorName() + " number, please pick a " + matchRes.GetDetectedOperatorName() + " product"
+	}
+
+	return shared.FailedValidationError{Errors: shared.Errors{"destination": msg}}
+}
+
+// checkInquiryToken makes sure the buyer looked the account up before paying.
+// Tokens are checked whenever sent, and required when RequireAccountInquiry
+// is on and the operator supports inquiry.
+func (o *OrderService) checkInquiryToken(productType *prpb.ProductType, destination string, serverId string, token string) error {
+	if token == "" {
+		if o.RequireAccountInquiry && productType.GetInquiryEnabled() {
+			return shared.FailedValidationError{Errors: shared.Errors{"inquiry_token": "Please check the account before ordering"}}
+		}
+		return nil
+	}
+
+	claims, err := shared.ParseInquiryToken(token)
+	if err != nil {
+		slog.Info("Invalid inquiry token", "err", err)
+		return shared.FailedValidationError{Errors: shared.Errors{"inquiry_token": "The account check has expired, please check the account again"}}
+	}
+
+	if claims.ProductTypeId != int(productType.GetId()) || claims.Destination != destination || claims.ServerId != serverId {
+		return shared.FailedValidationError{Errors: shared.Errors{"inquiry_token": "The account check does not match the destination"}}
+	}
+
+	return nil
+}
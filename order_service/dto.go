@@ -6,12 +6,23 @@ import (
 	ppb "github.com/akmmp241/topupstore-microservice/payment-proto/v1"
 )
 
+// CreateOrderRequest takes the destination either raw, as the fields of the
+// product type form, or as the id of one of the buyer's saved destinations.
 type CreateOrderRequest struct {
-	Destination   string `json:"destination"    validate:"required"`
-	ServerId      string `json:"server_id"`
-	ProductId     int    `json:"product_id"     validate:"required"`
+	Destination        string            `json:"destination"          validate:"required_without_all=SavedDestinationId DestinationFields"`
+	ServerId           string            `json:"server_id"`
+	DestinationFields  map[string]string `json:"destination_fields"`
+	SavedDestinationId int               `json:"saved_destination_id" validate:"omitempty,min=1"`
+	ProductId          int               `json:"product_id"           validate:"required"`
+	PaymentMethod      string            `json:"payment_method"       validate:"required"`
+	BuyerEmail         string            `json:"buyer_email"          validate:"required"`
+	PointsToRedeem     int               `json:"points_to_redeem"     validate:"omitempty,min=1"`
+	InquiryToken       string            `json:"inquiry_token"`
+}
+
+type CreateTopupRequest struct {
+	Amount        int    `json:"amount"         validate:"required,min=10000,max=10000000"`
 	PaymentMethod string `json:"payment_method" validate:"required"`
-	BuyerEmail    string `json:"buyer_email"    validate:"required"`
 }
 
 type CreatePaymentRequest struct {
@@ -28,6 +39,22 @@ type CreatePaymentResponse struct {
 	FailureCode     string `json:"failure_code"`
 }
 
+// PublicOrderResponse is what anyone knowing the order id may see, without
+// the buyer's contact details or destination.
+type PublicOrderResponse struct {
+	Id                 string    `json:"id"`
+	ProductId          int       `json:"product_id"`
+	ProductName        string    `json:"product_name"`
+	ChannelCode        string    `json:"channel_code"`
+	TotalProductAmount int       `json:"total_product_amount"`
+	ServiceCharge      float64   `json:"service_charge"`
+	TotalAmount        int       `json:"total_amount"`
+	Status             string    `json:"status"`
+	FailureCode        string    `json:"failure_code"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
 type OrderEvent struct {
 	EventTye string    `json:"event_type"`
 	Data     *OrderMsg `json:"data"`
@@ -43,10 +70,13 @@ type OrderMsg struct {
 	Destination        string    `json:"destination"          validate:"required"`
 	ServerId           string    `json:"server_id"            validate:"required"`
 	ChannelCode        string    `json:"channel_code"         validate:"required"`
+	BuyerId            int       `json:"buyer_id"`
 	BuyerEmail         string    `json:"buyer_email"          validate:"required,email"`
 	ServiceCharge      float64   `json:"service_charge"       validate:"required,min=0"`
 	TotalProductAmount int       `json:"total_product_amount" validate:"required,min=1"`
 	TotalAmount        int       `json:"total_amount"         validate:"required,min=1"`
+	PointsDiscount     int       `json:"points_discount"`
+	PointsEarned       int       `json:"points_earned"`
 	CreatedAt          time.Time `json:"created_at"           validate:"required"`
 }
 
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	prpb "github.com/akmmp241/topupstore-microservice/product-proto/v1"
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// orders.fulfillment_status, unknown orders need a look at the supplier
+// before they are retried or refunded
+const (
+	FulfillmentPending    = "pending"
+	FulfillmentProcessing = "processing"
+	FulfillmentSucceeded  = "succeeded"
+	FulfillmentFailed     = "failed"
+	FulfillmentUnknown    = "unknown"
+)
+
+// fulfillment tries every supplier of a product in turn, give it time
+const fulfillmentTimeout = 2 * time.Minute
+
+type FulfillmentAttempt struct {
+	Attempt      int       `json:"attempt"`
+	Policy       string    `json:"policy"`
+	SupplierId   int       `json:"supplier_id"`
+	SupplierCode string    `json:"supplier_code"`
+	SupplierSku  string    `json:"supplier_sku"`
+	Cost         int       `json:"cost"`
+	Outcome      string    `json:"outcome"`
+	Message      string    `json:"message"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// fulfillOrder delivers a paid order through the product service, which
+// routes it over the suppliers. Only the caller that moves the order out of
+// pending fulfills it, so a repeated payment webhook does not buy twice.
+func (o *OrderService) fulfillOrder(ctx context.Context, order *Order) {
+	result, err := o.DB.ExecContext(ctx, "UPDATE orders SET fulfillment_status = ? WHERE id = ? AND fulfillment_status = ?",
+		FulfillmentProcessing, order.Id, FulfillmentPending)
+	if err != nil {
+		slog.Error("Error occurred while claiming order fulfillment", "order-id", order.Id, "err", err)
+		return
+	}
+	if claimed, _ := result.RowsAffected(); claimed == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fulfillmentTimeout)
+	defer cancel()
+
+	fulfillOrderRes, err := (*o.ProductService).FulfillOrder(ctx, &prpb.FulfillOrderReq{
+		OrderId:     order.Id,
+		ProductId:   int32(order.ProductId),
+		Destination: order.Destination,
+		ServerId:    order.ServerId,
+	})
+	if err != nil {
+		fulfillOrderRes = &prpb.FulfillOrderRes{Status: FulfillmentUnknown}
+		if st, ok := status.FromError(err); ok && (st.Code() == codes.FailedPrecondition || st.Code() == codes.NotFound) {
+			fulfillOrderRes.Status = FulfillmentFailed
+		}
+		slog.Error("Error occurred while fulfilling order", "order-id", order.Id, "err", err)
+	}
+
+	if err := o.saveFulfillment(o.Ctx, order.Id, fulfillOrderRes); err != nil {
+		slog.Error("Error occurred while saving order fulfillment", "order-id", order.Id, "err", err)
+		return
+	}
+
+	slog.Info("Order fulfillment finished", "order-id", order.Id, "status", fulfillOrderRes.GetStatus(),
+		"supplier", fulfillOrderRes.GetSupplierCode(), "attempts", len(fulfillOrderRes.GetAttempts()))
+
+	// a delivered order is final, only now may it count for a referral
+	if fulfillOrderRes.GetStatus() == FulfillmentSucceeded && order.BuyerId != 0 {
+		o.rewardReferral(o.Ctx, order.BuyerId, order.Id)
+	}
+}
+
+// saveFulfillment keeps the routing decisions next to the order for audit.
+func (o *OrderService) saveFulfillment(ctx context.Context, orderId string, fulfillOrderRes *prpb.FulfillOrderRes) (err error) {
+	tx, err := o.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	// a retried order continues the numbering of its earlier attempts
+	var previousAttempts int
+	err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM order_fulfillment_attempts WHERE order_id = ?", orderId).Scan(&previousAttempts)
+	if err != nil {
+		return err
+	}
+
+	for i, attempt := range fulfillOrderRes.GetAttempts() {
+		_, err = tx.ExecContext(ctx, `INSERT INTO order_fulfillment_attempts (order_id, attempt, policy, supplier_id, supplier_code, supplier_sku, cost, outcome, message)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			orderId, previousAttempts+i+1, fulfillOrderRes.GetPolicy(), attempt.GetSupplierId(), attempt.GetSupplierCode(), attempt.GetSupplierSku(), attempt.GetCost(),
+			attempt.GetOutcome(), attempt.GetMessage())
+		if err != nil {
+			return err
+		}
+	}
+
+	var fulfilledBy, serialNumber sql.NullString
+	if fulfillOrderRes.GetStatus() == FulfillmentSucceeded {
+		fulfilledBy = sql.NullString{String: fulfillOrderRes.GetSupplierCode(), Valid: true}
+		serialNumber = sql.NullString{String: fulfillOrderRes.GetSerialNumber(), Valid: true}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE orders SET fulfillment_status = ?, fulfilled_by = ?, serial_number = ? WHERE id = ?",
+		fulfillOrderRes.GetStatus(), fulfilledBy, serialNumber, orderId)
+	return err
+}
+
+// handleRetryFulfillment sends a failed or unknown order to the suppliers
+// again. Suppliers drop a purchase they already took by its ref id, so an
+// unknown order is not delivered twice. Orders that cannot be delivered are
+// refunded with POST /orders/:id/refund.
+func (o *OrderService) handleRetryFulfillment(c *fiber.Ctx) error {
+	orderId := c.Params("id")
+
+	var order Order
+	var buyerId sql.NullInt64
+	var fulfillmentStatus string
+	err := o.DB.QueryRowContext(c.Context(), "SELECT id, buyer_id, product_id, destination, server_id, status, fulfillment_status FROM orders WHERE id = ?", orderId).
+		Scan(&order.Id, &buyerId, &order.ProductId, &order.Destination, &order.ServerId, &order.Status, &fulfillmentStatus)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fiber.NewError(fiber.StatusNotFound, "Order not found")
+		}
+		slog.Error("Error occurred while querying order", "err", err)
+		return err
+	}
+	order.BuyerId = int(buyerId.Int64)
+
+	result, err := o.DB.ExecContext(c.Context(), "UPDATE orders SET fulfillment_status = ? WHERE id = ? AND status = ? AND fulfillment_status IN (?, ?)",
+		FulfillmentPending, orderId, PaymentStatusSucceeded, FulfillmentFailed, FulfillmentUnknown)
+	if err != nil {
+		slog.Error("Error occurred while resetting order fulfillment", "err", err)
+		return err
+	}
+	if reset, _ := result.RowsAffected(); reset == 0 {
+		return fiber.NewError(fiber.StatusConflict, "Only paid orders whose fulfillment failed or is unknown can be retried")
+	}
+
+	slog.Info("Order fulfillment retried", "order-id", orderId, "previous-status", fulfillmentStatus, "by", shared.GetUserClaims(c).Subject)
+
+	go o.fulfillOrder(o.Ctx, &order)
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Order fulfillment retried",
+		"data":    nil,
+		"errors":  nil,
+	})
+}
+
+func (o *OrderService) handleGetOrderFulfillment(c *fiber.Ctx) error {
+	orderId := c.Params("id")
+
+	var fulfillmentStatus string
+	var fulfilledBy, serialNumber sql.NullString
+	err := o.DB.QueryRowContext(c.Context(), "SELECT fulfillment_status, fulfilled_by, serial_number FROM orders WHERE id = ?", orderId).
+		Scan(&fulfillmentStatus, &fulfilledBy, &serialNumber)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fiber.NewError(fiber.StatusNotFound, "Order not found")
+		}
+		slog.Error("Error occurred while querying order fulfillment", "err", err)
+		return err
+	}
+
+	rows, err := o.DB.QueryContext(c.Context(), `SELECT attempt, policy, supplier_id, supplier_code, supplier_sku, cost, outcome, COALESCE(message, ''), created_at
+				FROM order_fulfillment_attempts WHERE order_id = ? ORDER BY attempt`, orderId)
+	if err != nil {
+		slog.Error("Error occurred while querying fulfillment attempts", "err", err)
+		return err
+	}
+	defer rows.Close()
+
+	attempts := []FulfillmentAttempt{}
+	for rows.Next() {
+		var attempt FulfillmentAttempt
+		err := rows.Scan(&attempt.Attempt, &attempt.Policy, &attempt.SupplierId, &attempt.SupplierCode, &attempt.SupplierSku, &attempt.Cost,
+			&attempt.Outcome, &attempt.Message, &attempt.CreatedAt)
+		if err != nil {
+			slog.Error("Error occurred while scanning fulfillment attempt row", "err", err)
+			return err
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Order fulfillment retrieved successfully",
+		"data": fiber.Map{
+			"status":        fulfillmentStatus,
+			"fulfilled_by":  fulfilledBy.String,
+			"serial_number": serialNumber.String,
+			"attempts":      attempts,
+		},
+		"errors": nil,
+	})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	DirectionCredit = "credit"
+	DirectionDebit  = "debit"
+)
+
+// system accounts on the other side of wallet entries
+const (
+	AccountPaymentGateway  = "payment-gateway"
+	AccountSales           = "sales"
+	AccountReferralRewards = "referral-rewards"
+)
+
+const (
+	ReferenceTopup    = "topup"
+	ReferenceOrder    = "order"
+	ReferenceRefund   = "refund"
+	ReferenceReferral = "referral"
+	ReferenceClawback = "referral-clawback"
+)
+
+var (
+	errInsufficientBalance = errors.New("insufficient balance")
+	errJournalPosted       = errors.New("journal already posted")
+)
+
+type LedgerEntry struct {
+	Id            int       `json:"id"`
+	JournalId     string    `json:"journal_id"`
+	Direction     string    `json:"direction"`
+	Amount        int64     `json:"amount"`
+	BalanceAfter  int64     `json:"balance_after"`
+	ReferenceType string    `json:"reference_type"`
+	ReferenceId   string    `json:"reference_id"`
+	Description   string    `json:"description"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func walletAccount(walletId int64) string {
+	return fmt.Sprintf("wallet:%d", walletId)
+}
+
+func journalId(referenceType string, referenceId string) string {
+	return referenceType + ":" + referenceId
+}
+
+// lockWallet creates the wallet of a user on first use and locks its row until
+// tx ends, so concurrent postings on the same wallet run one after another.
+func lockWallet(ctx context.Context, tx *sql.Tx, userId int) (walletId int64, balance int64, err error) {
+	_, err = tx.ExecContext(ctx, "INSERT IGNORE INTO wallets (user_id) VALUES (?)", userId)
+	if err != nil {
+		slog.Error("Error occurred while creating wallet", "err", err)
+		return 0, 0, err
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT id, balance FROM wallets WHERE user_id = ? FOR UPDATE", userId).Scan(&walletId, &balance)
+	if err != nil {
+		slog.Error("Error occurred while locking wallet", "err", err)
+		return 0, 0, err
+	}
+
+	return walletId, balance, nil
+}
+
+// postWalletJournal moves amount between the wallet of a user and a system
+// account, a credit raises the wallet balance. Every journal is posted once,
+// posting it again returns errJournalPosted.
+func postWalletJournal(ctx context.Context, tx *sql.Tx, userId int, direction string, amount int64, counterAccount string,
+	referenceType string, referenceId string, description string) (*LedgerEntry, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("invalid journal amount %d", amount)
+	}
+
+	walletId, balance, err := lockWallet(ctx, tx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	counterDirection := DirectionDebit
+	balanceAfter := balance + amount
+	if direction == DirectionDebit {
+		counterDirection = DirectionCredit
+		balanceAfter = balance - amount
+	}
+	if balanceAfter < 0 {
+		return nil, errInsufficientBalance
+	}
+
+	entry := &LedgerEntry{
+		JournalId:     journalId(referenceType, referenceId),
+		Direction:     direction,
+		Amount:        amount,
+		BalanceAfter:  balanceAfter,
+		ReferenceType: referenceType,
+		ReferenceId:   referenceId,
+		Description:   description,
+		CreatedAt:     time.Now(),
+	}
+
+	query := `INSERT INTO ledger_entries (journal_id, account, wallet_id, direction, amount, balance_after, reference_type, reference_id, description, created_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?), (?, ?, NULL, ?, ?, NULL, ?, ?, ?, ?)`
+	result, err := tx.ExecContext(ctx, query,
+		entry.JournalId, walletAccount(walletId), walletId, direction, amount, balanceAfter, referenceType, referenceId, description, entry.CreatedAt,
+		entry.JournalId, counterAccount, counterDirection, amount, referenceType, referenceId, description, entry.CreatedAt,
+	)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return nil, errJournalPosted
+		}
+		slog.Error("Error occurred while inserting ledger entries", "err", err)
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	entry.Id = int(id)
+
+	_, err = tx.ExecContext(ctx, "UPDATE wallets SET balance = ? WHERE id = ?", balanceAfter, walletId)
+	if err != nil {
+		slog.Error("Error occurred while updating wallet balance", "err", err)
+		return nil, err
+	}
+
+	return entry, nil
+}
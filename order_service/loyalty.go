@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+
+	upb "github.com/akmmp241/topupstore-microservice/user-proto/v1"
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultPointValue = 1
+
+// getPointValue is the discount in rupiah a redeemed point is worth.
+func getPointValue() int {
+	value, err := strconv.Atoi(os.Getenv("LOYALTY_POINT_VALUE"))
+	if err != nil || value <= 0 {
+		return defaultPointValue
+	}
+
+	return value
+}
+
+// pointsEarned rounds down, points are only earned on the price paid for the
+// product, not on service charges.
+func pointsEarned(amount int, pointsPerThousand int) int {
+	if amount <= 0 || pointsPerThousand <= 0 {
+		return 0
+	}
+
+	return amount * pointsPerThousand / 1000
+}
+
+// applyPointsDiscount sets the discount of the points the buyer wants to
+// redeem, the points are only taken by redeemPoints.
+func applyPointsDiscount(user *upb.User, orderData *Order, points int) error {
+	if user == nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "Please log in to redeem points")
+	}
+
+	discount := points * getPointValue()
+	if discount > orderData.TotalProductAmount {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "Redeemed points are worth more than the product price")
+	}
+
+	orderData.PointsRedeemed = points
+	orderData.PointsDiscount = discount
+
+	return nil
+}
+
+// redeemPoints takes the points of the order from the buyer in user service.
+// They are restored when the order is not stored or its payment fails.
+func (o *OrderService) redeemPoints(ctx context.Context, user *upb.User, orderData *Order) error {
+	_, err := (*o.UserService).RedeemPoints(ctx, &upb.RedeemPointsReq{UserId: user.GetId(), OrderId: orderData.Id, Points: int32(orderData.PointsRedeemed)})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.FailedPrecondition {
+			return fiber.NewError(fiber.StatusUnprocessableEntity, st.Message())
+		}
+		slog.Error("Error occurred while redeeming points", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (o *OrderService) restorePoints(ctx context.Context, orderData *Order) {
+	if orderData.PointsRedeemed == 0 {
+		return
+	}
+
+	_, err := (*o.UserService).RestorePoints(ctx, &upb.RestorePointsReq{OrderId: orderData.Id})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && st.Code() == codes.NotFound {
+			return
+		}
+		slog.Error("Error occurred while restoring points", "order-id", orderData.Id, "err", err)
+		return
+	}
+
+	slog.Info("Points restored", "order-id", orderData.Id, "points", orderData.PointsRedeemed)
+}
@@ -16,6 +16,9 @@ type Order struct {
 	TotalProductAmount int       `json:"total_product_amount"`
 	ServiceCharge      float64   `json:"service_charge"`
 	TotalAmount        int       `json:"total_amount"`
+	PointsRedeemed     int       `json:"points_redeemed"`
+	PointsDiscount     int       `json:"points_discount"`
+	PointsEarned       int       `json:"points_earned"`
 	Status             string    `json:"status"`
 	FailureCode        string    `json:"failure_code"`
 	CreatedAt          time.Time `json:"created_at"`
@@ -11,6 +11,7 @@ import (
 	"math"
 	urllib "net/url"
 	"os"
+	"strconv"
 	"time"
 
 	ppb "github.com/akmmp241/topupstore-microservice/payment-proto/v1"
@@ -47,6 +48,11 @@ type OrderService struct {
 	PaymentService *ppb.PaymentServiceClient
 	ProductService *prpb.ProductServiceClient
 	UserService    *upb.UserServiceClient
+	// orders above this total require a logged-in buyer to have a verified
+	// email, zero disables the check
+	VerifiedEmailThreshold int
+	// orders of operators with account inquiry need an inquiry token
+	RequireAccountInquiry bool
 }
 
 func NewOrderService(
@@ -57,16 +63,40 @@ func NewOrderService(
 	ProductService *prpb.ProductServiceClient,
 	UserService *upb.UserServiceClient,
 ) *OrderService {
-	return &OrderService{DB: DB, Validate: validate, Ctx: context.Background(), Producer: producer, PaymentService: PaymentService, ProductService: ProductService, UserService: UserService}
+	verifiedEmailThreshold := 0
+	if v := os.Getenv("REQUIRE_VERIFIED_EMAIL_ABOVE"); v != "" {
+		threshold, err := strconv.Atoi(v)
+		if err != nil || threshold < 0 {
+			slog.Error("Invalid verified email threshold", "value", v, "err", err)
+			panic("invalid REQUIRE_VERIFIED_EMAIL_ABOVE " + v)
+		}
+		verifiedEmailThreshold = threshold
+	}
+
+	requireAccountInquiry := os.Getenv("REQUIRE_ACCOUNT_INQUIRY") == "true"
+
+	return &OrderService{DB: DB, Validate: validate, Ctx: context.Background(), Producer: producer, PaymentService: PaymentService, ProductService: ProductService, UserService: UserService, VerifiedEmailThreshold: verifiedEmailThreshold, RequireAccountInquiry: requireAccountInquiry}
 }
 
 func (o *OrderService) RegisterRoutes(app fiber.Router) {
-	app.Get("/orders", o.handleGetOrders)
+	app.Get("/orders", shared.RequirePermission(shared.PermOrdersReadAll), o.handleGetOrders)
 	app.Get("/orders/:id", o.handleGetOrderById)
+	app.Get("/orders/:id/fulfillment", shared.RequirePermission(shared.PermOrdersReadAll), o.handleGetOrderFulfillment)
+	app.Post("/orders/:id/fulfillment/retry", shared.RequirePermission(shared.PermOrdersManage), o.handleRetryFulfillment)
 	app.Post("/orders", o.handleCreateOrders)
 
 	app.Post("/orders/:id/simulate", shared.DevOnlyMiddleware, o.handleSimulatePayment)
 
+	app.Get("/internal/users/:id/orders", shared.JWTServiceMiddleware, o.handleGetOrdersByBuyer)
+
+	wallet := app.Group("/wallet", shared.JWTUserMiddleware)
+	wallet.Get("/", o.handleGetWallet)
+	wallet.Get("/statement", o.handleGetWalletStatement)
+	wallet.Post("/topups", o.handleCreateTopup)
+	wallet.Get("/topups/:id", o.handleGetTopup)
+
+	app.Post("/orders/:id/refund", shared.RequirePermission(shared.PermWalletManage), o.handleRefundOrder)
+
 	app.Use(WebhookTokenMiddleware)
 	app.Post("/webhook/orders/succeeded", o.handleOrderSucceededWebhook)
 	app.Post("/webhook/orders/failed", o.handleOrderFailedWebhook)
@@ -136,7 +166,7 @@ func (o *OrderService) handleGetOrderById(c *fiber.Ctx) error {
 	}
 	defer shared.CommitOrRollback(tx, nil)
 
-	query := `SELECT id, payment_reference_id, buyer_id, buyer_email, buyer_phone, product_id, product_name, destination, server_id, total_product_amount, service_charge, total_amount, status, failure_code, created_at, updated_at FROM orders WHERE id = ?`
+	query := `SELECT id, payment_reference_id, buyer_id, buyer_email, buyer_phone, product_id, product_name, destination, server_id, channel_code, total_product_amount, service_charge, total_amount, status, failure_code, created_at, updated_at FROM orders WHERE id = ?`
 
 	row := tx.QueryRowContext(o.Ctx, query, orderId)
 
@@ -152,6 +182,7 @@ func (o *OrderService) handleGetOrderById(c *fiber.Ctx) error {
 		&order.Destination,
 
 		&order.ServerId,
+		&order.ChannelCode,
 		&order.TotalProductAmount,
 		&order.ServiceCharge,
 		&order.TotalAmount,
@@ -168,6 +199,28 @@ func (o *OrderService) handleGetOrderById(c *fiber.Ctx) error {
 		return err
 	}
 
+	// balance orders never went through the payment service, the route is
+	// unauthenticated so the buyer's details stay out
+	if order.ChannelCode == BalanceChannelCode {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"message": "Order retrieved successfully",
+			"data": PublicOrderResponse{
+				Id:                 order.Id,
+				ProductId:          order.ProductId,
+				ProductName:        order.ProductName,
+				ChannelCode:        order.ChannelCode,
+				TotalProductAmount: order.TotalProductAmount,
+				ServiceCharge:      order.ServiceCharge,
+				TotalAmount:        order.TotalAmount,
+				Status:             order.Status,
+				FailureCode:        order.FailureCode,
+				CreatedAt:          order.CreatedAt,
+				UpdatedAt:          order.UpdatedAt,
+			},
+			"errors": nil,
+		})
+	}
+
 	getPaymentIdReq := ppb.GetPaymentByIdReq{
 		PaymentId: order.PaymentReferenceId,
 	}
@@ -220,6 +273,13 @@ func (o *OrderService) handleCreateOrders(c *fiber.Ctx) error {
 	var user *upb.User
 	var product *prpb.Product
 
+	// the token tier lets the product lookup run next to the user lookup,
+	// the tier stored on the user is checked once both are back
+	tier := shared.TierRetail
+	if claims := shared.OptionalUserClaims(c); claims != nil && claims.Tier != "" {
+		tier = claims.Tier
+	}
+
 	// get user if logged in
 	userChannel := make(chan *upb.User, 1)
 	go func() {
@@ -267,6 +327,7 @@ func (o *OrderService) handleCreateOrders(c *fiber.Ctx) error {
 
 		getProductByIdReq := prpb.GetProductByIdReq{
 			ProductId: int32(orderRequest.ProductId),
+			Tier:      tier,
 		}
 
 		getProductByIdRes, err := (*o.ProductService).GetProductById(c.Context(), &getProductByIdReq)
@@ -304,12 +365,68 @@ func (o *OrderService) handleCreateOrders(c *fiber.Ctx) error {
 	}
 
 	product = <-productChannel
+	if user != nil && user.GetTier() != "" && user.GetTier() != tier {
+		slog.Info("token tier is outdated, resolving price again", "user-id", user.Id, "token-tier", tier, "tier", user.GetTier())
+		getProductByIdRes, err := (*o.ProductService).GetProductById(c.Context(), &prpb.GetProductByIdReq{ProductId: product.Id, Tier: user.GetTier()})
+		if err != nil {
+			slog.Error("Error occurred while calling product service", "err", err)
+			return err
+		}
+		product = getProductByIdRes.Product
+	}
+
+	if err = checkAvailability(product); err != nil {
+		return err
+	}
+
 	orderData.ProductId = int(product.Id)
 	orderData.ProductName = product.Name
 	orderData.TotalProductAmount = int(product.Price)
 
+	if orderRequest.SavedDestinationId != 0 {
+		destination, err := o.getSavedDestination(c, user, orderRequest.SavedDestinationId, product)
+		if err != nil {
+			return err
+		}
+		orderData.Destination = destination.GetDestination()
+		orderData.ServerId = destination.GetServerId()
+	}
+
+	destinationFields := orderRequest.DestinationFields
+	if orderRequest.SavedDestinationId != 0 {
+		destinationFields = nil
+	}
+	productType, err := o.getProductType(c.Context(), product)
+	if err != nil {
+		return err
+	}
+
+	orderData.Destination, orderData.ServerId, err = validateDestination(productType, destinationFields, orderData.Destination, orderData.ServerId)
+	if err != nil {
+		return err
+	}
+
+	if err = o.checkInquiryToken(productType, orderData.Destination, orderData.ServerId, orderRequest.InquiryToken); err != nil {
+		return err
+	}
+
+	if err = o.checkOperatorPrefix(c.Context(), product, orderData.Destination); err != nil {
+		return err
+	}
+
+	if orderRequest.PointsToRedeem > 0 {
+		if err := applyPointsDiscount(user, orderData, orderRequest.PointsToRedeem); err != nil {
+			return err
+		}
+	}
+
+	productAmount := orderData.TotalProductAmount - orderData.PointsDiscount
+	if user != nil {
+		orderData.PointsEarned = pointsEarned(productAmount, int(product.GetLoyaltyPointsPerThousand()))
+	}
+
 	// set payment method
-	paymentMethod, err := getPaymentMethodDetails(orderRequest.PaymentMethod, int(product.Price))
+	paymentMethod, err := getPaymentMethodDetails(orderRequest.PaymentMethod, productAmount)
 	if err != nil {
 		slog.Error("Invalid Channel Code", "err", err)
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
@@ -318,6 +435,28 @@ func (o *OrderService) handleCreateOrders(c *fiber.Ctx) error {
 	orderData.ServiceCharge = paymentMethod.ServiceCharge
 	orderData.TotalAmount = paymentMethod.TotalAmount
 
+	if user != nil && user.GetEmailVerifiedAt() == nil &&
+		o.VerifiedEmailThreshold > 0 && orderData.TotalAmount > o.VerifiedEmailThreshold {
+		slog.Info("unverified buyer exceeded order threshold", "user-id", user.Id, "total-amount", orderData.TotalAmount)
+		return fiber.NewError(fiber.StatusForbidden, "Please verify your email address before placing this order")
+	}
+
+	if err = o.reserveStock(c.Context(), product, orderData); err != nil {
+		return err
+	}
+
+	if orderData.PointsRedeemed > 0 {
+		if err := o.redeemPoints(c.Context(), user, orderData); err != nil {
+			o.releaseStock(o.Ctx, orderData.Id)
+			return err
+		}
+	}
+
+	orderData.ChannelCode = paymentMethod.ChannelCode
+	if paymentMethod.ChannelCode == BalanceChannelCode {
+		return o.createBalanceOrder(c, user, orderData)
+	}
+
 	// call create payment
 	paymentServiceErrChan := make(chan error, 1)
 	defer close(paymentServiceErrChan)
@@ -357,6 +496,8 @@ func (o *OrderService) handleCreateOrders(c *fiber.Ctx) error {
 	}(paymentMethod)
 
 	if err = <-paymentServiceErrChan; err != nil {
+		o.restorePoints(o.Ctx, orderData)
+		o.releaseStock(o.Ctx, orderData.Id)
 		return err
 	}
 
@@ -370,41 +511,16 @@ func (o *OrderService) handleCreateOrders(c *fiber.Ctx) error {
 	tx, err := o.DB.Begin()
 	if err != nil {
 		slog.Error("Error occurred while starting transaction", "err", err)
+		o.restorePoints(o.Ctx, orderData)
+		o.releaseStock(o.Ctx, orderData.Id)
 		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
 	}
 	defer shared.CommitOrRollback(tx, err)
 
-	query := `INSERT INTO orders (id, payment_reference_id, product_id, product_name, destination, server_id, buyer_id, buyer_email,
-					buyer_phone, service_charge, channel_code, total_product_amount, total_amount,
-					status, failure_code, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-
-	result, err := tx.ExecContext(o.Ctx, query,
-		orderData.Id,
-		orderData.PaymentReferenceId,
-		orderData.ProductId,
-		orderData.ProductName,
-		orderData.Destination,
-		orderData.ServerId,
-		orderData.BuyerId,
-		orderData.BuyerEmail,
-		orderData.BuyerPhone,
-		orderData.ServiceCharge,
-		orderData.ChannelCode,
-		orderData.TotalProductAmount,
-		orderData.TotalAmount,
-		orderData.Status,
-		orderData.FailureCode,
-		orderData.CreatedAt,
-		orderData.CreatedAt, // assuming updated_at is the same as created_at for new orders
-	)
-	if err != nil {
-		slog.Error("Error occurred while inserting order", "err", err)
-		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
-	}
-
-	if affectedRows, _ := result.RowsAffected(); affectedRows == 0 {
-		slog.Error("No rows affected while inserting order")
-		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create order")
+	if err = insertOrder(o.Ctx, tx, orderData); err != nil {
+		o.restorePoints(o.Ctx, orderData)
+		o.releaseStock(o.Ctx, orderData.Id)
+		return err
 	}
 
 	baseMsg := &OrderEvent{
@@ -418,10 +534,13 @@ func (o *OrderService) handleCreateOrders(c *fiber.Ctx) error {
 			Destination:        orderData.Destination,
 			ServerId:           orderData.ServerId,
 			ChannelCode:        orderData.ChannelCode,
+			BuyerId:            orderData.BuyerId,
 			BuyerEmail:         orderData.BuyerEmail,
 			ServiceCharge:      orderData.ServiceCharge,
 			TotalProductAmount: orderData.TotalProductAmount,
 			TotalAmount:        orderData.TotalAmount,
+			PointsDiscount:     orderData.PointsDiscount,
+			PointsEarned:       orderData.PointsEarned,
 			CreatedAt:          orderData.CreatedAt,
 		},
 	}
@@ -455,6 +574,11 @@ func (o *OrderService) handleOrderSucceededWebhook(c *fiber.Ctx) error {
 		return c.SendStatus(fiber.StatusOK)
 	}
 
+	// wallet top-ups are paid through the same Xendit account
+	if isTopupReference(webhookRequest.ReferenceId) {
+		return o.handleTopupWebhook(c, &webhookRequest)
+	}
+
 	tx, err := o.DB.Begin()
 	if err != nil {
 		slog.Error("Error occurred while starting transaction", "err", err)
@@ -498,11 +622,13 @@ func (o *OrderService) handleOrderSucceededWebhook(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, "Order not found")
 	}
 
-	query = `SELECT id, product_id, product_name, destination, server_id, service_charge, total_product_amount, total_amount, created_at FROM orders WHERE id = ?`
+	query = `SELECT id, buyer_id, product_id, product_name, destination, server_id, service_charge, total_product_amount, total_amount, points_discount, points_earned, created_at FROM orders WHERE id = ?`
 	row := tx.QueryRowContext(o.Ctx, query, webhookRequest.ReferenceId)
 	var order Order
+	var buyerId sql.NullInt64
 	err = row.Scan(
 		&order.Id,
+		&buyerId,
 		&order.ProductId,
 		&order.ProductName,
 		&order.Destination,
@@ -510,6 +636,8 @@ func (o *OrderService) handleOrderSucceededWebhook(c *fiber.Ctx) error {
 		&order.ServiceCharge,
 		&order.TotalProductAmount,
 		&order.TotalAmount,
+		&order.PointsDiscount,
+		&order.PointsEarned,
 		&order.CreatedAt,
 	)
 	if err != nil {
@@ -520,6 +648,7 @@ func (o *OrderService) handleOrderSucceededWebhook(c *fiber.Ctx) error {
 		slog.Error("Error occurred while scanning order row", "err", err)
 		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
 	}
+	order.BuyerId = int(buyerId.Int64)
 
 	baseMsg := &OrderEvent{
 		EventTye: SuccessOrder,
@@ -531,10 +660,13 @@ func (o *OrderService) handleOrderSucceededWebhook(c *fiber.Ctx) error {
 			ProductPrice:       order.TotalProductAmount,
 			Destination:        order.Destination,
 			ServerId:           order.ServerId,
+			BuyerId:            order.BuyerId,
 			BuyerEmail:         order.BuyerEmail,
 			ServiceCharge:      order.ServiceCharge,
 			TotalProductAmount: order.TotalProductAmount,
 			TotalAmount:        order.TotalAmount,
+			PointsDiscount:     order.PointsDiscount,
+			PointsEarned:       order.PointsEarned,
 			CreatedAt:          order.CreatedAt,
 		},
 	}
@@ -554,6 +686,8 @@ func (o *OrderService) handleOrderSucceededWebhook(c *fiber.Ctx) error {
 		)
 	}
 
+	go o.fulfillOrder(o.Ctx, &order)
+
 	return c.SendStatus(fiber.StatusOK)
 }
 
@@ -566,11 +700,17 @@ func (o *OrderService) handleOrderFailedWebhook(c *fiber.Ctx) error {
 
 	webhookRequest := request.Data
 
-	if webhookRequest.Status != "FAILED" {
+	// an expired payment ends the order the same way a failed one does
+	if webhookRequest.Status != PaymentStatusFailed && webhookRequest.Status != PaymentStatusExpired {
 		slog.Info("Order payment not failed", "status", webhookRequest.Status)
 		return c.SendStatus(fiber.StatusOK)
 	}
 
+	// wallet top-ups are paid through the same Xendit account
+	if isTopupReference(webhookRequest.ReferenceId) {
+		return o.handleTopupWebhook(c, &webhookRequest)
+	}
+
 	tx, err := o.DB.Begin()
 	if err != nil {
 		slog.Error("Error occurred while starting transaction", "err", err)
@@ -596,7 +736,7 @@ func (o *OrderService) handleOrderFailedWebhook(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, "Order not found")
 	}
 
-	query = `SELECT id, product_id, product_name, destination, server_id, service_charge, total_product_amount, total_amount, created_at FROM orders WHERE id = ?`
+	query = `SELECT id, product_id, product_name, destination, server_id, service_charge, total_product_amount, total_amount, points_redeemed, created_at FROM orders WHERE id = ?`
 	row := tx.QueryRowContext(o.Ctx, query, webhookRequest.ReferenceId)
 	var order Order
 	err = row.Scan(
@@ -608,6 +748,7 @@ func (o *OrderService) handleOrderFailedWebhook(c *fiber.Ctx) error {
 		&order.ServiceCharge,
 		&order.TotalProductAmount,
 		&order.TotalAmount,
+		&order.PointsRedeemed,
 		&order.CreatedAt,
 	)
 	if err != nil {
@@ -650,6 +791,9 @@ func (o *OrderService) handleOrderFailedWebhook(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to send order failed event")
 	}
 
+	o.restorePoints(o.Ctx, &order)
+	o.releaseStock(o.Ctx, order.Id)
+
 	return c.SendStatus(fiber.StatusOK)
 }
 
@@ -715,6 +859,7 @@ func (o *OrderService) handleSimulatePayment(c *fiber.Ctx) error {
 		paymentServicePort := os.Getenv("PAYMENT_SERVICE_PORT")
 		url := fmt.Sprintf("/payments/%s", order.PaymentReferenceId)
 		paymentServiceResponse, err := shared.CallService(
+			"order-service",
 			paymentServiceHost,
 			paymentServicePort,
 			url,
@@ -876,7 +1021,57 @@ func handleOthersPaymentSimulation(prId string, amount int) error {
 	return nil
 }
 
+func insertOrder(ctx context.Context, tx *sql.Tx, order *Order) error {
+	query := `INSERT INTO orders (id, payment_reference_id, product_id, product_name, destination, server_id, buyer_id, buyer_email,
+					buyer_phone, service_charge, channel_code, total_product_amount, total_amount, points_redeemed, points_discount, points_earned,
+					status, failure_code, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := tx.ExecContext(ctx, query,
+		order.Id,
+		order.PaymentReferenceId,
+		order.ProductId,
+		order.ProductName,
+		order.Destination,
+		order.ServerId,
+		order.BuyerId,
+		order.BuyerEmail,
+		order.BuyerPhone,
+		order.ServiceCharge,
+		order.ChannelCode,
+		order.TotalProductAmount,
+		order.TotalAmount,
+		order.PointsRedeemed,
+		order.PointsDiscount,
+		order.PointsEarned,
+		order.Status,
+		order.FailureCode,
+		order.CreatedAt,
+		order.CreatedAt, // assuming updated_at is the same as created_at for new orders
+	)
+	if err != nil {
+		slog.Error("Error occurred while inserting order", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	if affectedRows, _ := result.RowsAffected(); affectedRows == 0 {
+		slog.Error("No rows affected while inserting order")
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create order")
+	}
+
+	return nil
+}
+
 func getPaymentMethodDetails(channelCode string, productPrice int) (*Order, error) {
+	if channelCode == BalanceChannelCode {
+		// no payment gateway involved, only the app charge applies
+		serviceCharge := float64(AppServiceCharge)
+		return &Order{
+			ChannelCode:   channelCode,
+			TotalAmount:   productPrice + AppServiceCharge,
+			ServiceCharge: serviceCharge,
+		}, nil
+	}
+
 	// Check if the payment method is valid
 	for _, channel := range EwalletChannelCodes {
 		if channelCode == channel {
@@ -919,3 +1114,33 @@ func getPaymentMethodDetails(channelCode string, productPrice int) (*Order, erro
 
 	return nil, errors.New("invalid channel code")
 }
+
+// getSavedDestination resolves a saved destination of the logged-in buyer and
+// makes sure it was saved for the operator or product type being ordered.
+func (o *OrderService) getSavedDestination(c *fiber.Ctx, user *upb.User, id int, product *prpb.Product) (*upb.SavedDestination, error) {
+	if user == nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "Please log in to use a saved destination")
+	}
+
+	getSavedDestinationRes, err := (*o.UserService).GetSavedDestination(c.Context(), &upb.GetSavedDestinationReq{
+		Id:     int32(id),
+		UserId: user.GetId(),
+	})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && st.Code() == codes.NotFound {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Saved destination not found")
+		}
+
+		slog.Error("Error occurred while calling user service get saved destination", "err", err)
+		return nil, err
+	}
+	destination := getSavedDestinationRes.GetDestination()
+
+	if (destination.GetOperatorId() != 0 && destination.GetOperatorId() != product.GetOperatorId()) ||
+		(destination.GetProductTypeId() != 0 && destination.GetProductTypeId() != product.GetProductTypeId()) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Saved destination does not match the product")
+	}
+
+	return destination, nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	upb "github.com/akmmp241/topupstore-microservice/user-proto/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// rewardReferral pays the referral rewards once the first order of a
+// referred buyer is fulfilled. Every step can be repeated, so a failed run
+// can be retried for the same order.
+func (o *OrderService) rewardReferral(ctx context.Context, buyerId int, orderId string) {
+	// refunded orders are not successful, their status moved on from succeeded
+	var successfulOrders int
+	err := o.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM orders WHERE buyer_id = ? AND status = ? AND fulfillment_status = ? AND id <> ?",
+		buyerId, PaymentStatusSucceeded, FulfillmentSucceeded, orderId).Scan(&successfulOrders)
+	if err != nil {
+		slog.Error("Error occurred while counting successful orders", "err", err)
+		return
+	}
+	if successfulOrders > 0 {
+		return
+	}
+
+	// two first orders fulfilled at once both get here, QualifyReferral locks
+	// the referral and lets only one order qualify it, the other one gets
+	// FailedPrecondition and rewards nothing
+	qualifyRes, err := (*o.UserService).QualifyReferral(ctx, &upb.QualifyReferralReq{UserId: int32(buyerId), OrderId: orderId})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && (st.Code() == codes.NotFound || st.Code() == codes.FailedPrecondition) {
+			return
+		}
+		slog.Error("Error occurred while qualifying referral", "err", err)
+		return
+	}
+
+	referral := qualifyRes.GetReferral()
+	if err := o.creditReferralRewards(ctx, referral); err != nil {
+		slog.Error("Error occurred while crediting referral rewards", "referral-id", referral.GetId(), "err", err)
+		return
+	}
+
+	if _, err := (*o.UserService).CompleteReferral(ctx, &upb.CompleteReferralReq{Id: referral.GetId()}); err != nil {
+		slog.Error("Error occurred while completing referral", "referral-id", referral.GetId(), "err", err)
+		return
+	}
+
+	slog.Info("Referral rewarded", "referral-id", referral.GetId(), "order-id", orderId)
+}
+
+func (o *OrderService) creditReferralRewards(ctx context.Context, referral *upb.Referral) (err error) {
+	tx, err := o.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	rewards := []struct {
+		userId int32
+		amount int32
+		side   string
+	}{
+		{referral.GetReferrerId(), referral.GetReferrerReward(), "referrer"},
+		{referral.GetReferredId(), referral.GetReferredReward(), "referred"},
+	}
+
+	for _, reward := range rewards {
+		if reward.amount <= 0 {
+			continue
+		}
+
+		_, err = postWalletJournal(ctx, tx, int(reward.userId), DirectionCredit, int64(reward.amount), AccountReferralRewards,
+			ReferenceReferral, fmt.Sprintf("%d:%s", referral.GetId(), reward.side), "Referral reward")
+		if errors.Is(err, errJournalPosted) {
+			err = nil
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clawbackReferral takes back the rewards of a referral whose qualifying order
+// was refunded. Only rewards that were posted are taken back, at most down to
+// a zero balance, a reward that was already spent is logged.
+func (o *OrderService) clawbackReferral(ctx context.Context, orderId string) {
+	revokeRes, err := (*o.UserService).RevokeReferral(ctx, &upb.RevokeReferralReq{OrderId: orderId})
+	if err != nil {
+		if st, ok := status.FromError(err); ok && (st.Code() == codes.NotFound || st.Code() == codes.FailedPrecondition) {
+			return
+		}
+		slog.Error("Error occurred while revoking referral", "order-id", orderId, "err", err)
+		return
+	}
+
+	referral := revokeRes.GetReferral()
+	if err := o.debitReferralRewards(ctx, referral); err != nil {
+		slog.Error("Error occurred while clawing back referral rewards", "referral-id", referral.GetId(), "err", err)
+		return
+	}
+
+	slog.Info("Referral revoked", "referral-id", referral.GetId(), "order-id", orderId)
+}
+
+func (o *OrderService) debitReferralRewards(ctx context.Context, referral *upb.Referral) (err error) {
+	tx, err := o.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	rewards := []struct {
+		userId int32
+		side   string
+	}{
+		{referral.GetReferrerId(), "referrer"},
+		{referral.GetReferredId(), "referred"},
+	}
+
+	for _, reward := range rewards {
+		referenceId := fmt.Sprintf("%d:%s", referral.GetId(), reward.side)
+
+		var amount int64
+		err = tx.QueryRowContext(ctx, "SELECT amount FROM ledger_entries WHERE journal_id = ? AND wallet_id IS NOT NULL",
+			journalId(ReferenceReferral, referenceId)).Scan(&amount)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		var balance int64
+		_, balance, err = lockWallet(ctx, tx, int(reward.userId))
+		if err != nil {
+			return err
+		}
+		if balance < amount {
+			slog.Warn("Referral reward partly spent, clawing back the balance left", "referral-id", referral.GetId(),
+				"user-id", reward.userId, "reward", amount, "balance", balance)
+			amount = balance
+		}
+		if amount == 0 {
+			continue
+		}
+
+		_, err = postWalletJournal(ctx, tx, int(reward.userId), DirectionDebit, amount, AccountReferralRewards,
+			ReferenceClawback, referenceId, "Referral reward revoked")
+		if errors.Is(err, errJournalPosted) {
+			err = nil
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -20,7 +20,10 @@ type AppServer struct {
 func NewAppServer() *AppServer {
 	db := shared.GetConnection()
 	server := fiber.New(fiber.Config{
-		ErrorHandler: shared.ErrorHandler,
+		ErrorHandler:            shared.ErrorHandler,
+		ProxyHeader:             shared.ClientIPHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          shared.TrustedProxies(),
 	})
 	validate := validator.New()
 
@@ -31,14 +34,14 @@ func NewAppServer() *AppServer {
 	paymentServiceGrpcHost := os.Getenv("PAYMENT_SERVICE_GRPC_HOST")
 	paymentServiceGrpcPort := os.Getenv("PAYMENT_SERVICE_GRPC_PORT")
 	paymentTarget := paymentServiceGrpcHost + ":" + paymentServiceGrpcPort
-	paymentConn := shared.NewGrpcClientConn(paymentTarget)
+	paymentConn := shared.NewGrpcClientConn(paymentTarget, shared.WithServiceToken("order-service"))
 
 	paymentServiceGrpc := ppb.NewPaymentServiceClient(paymentConn)
 
 	productServiceGrpcHost := os.Getenv("PRODUCT_SERVICE_GRPC_HOST")
 	productServiceGrpcPort := os.Getenv("PRODUCT_SERVICE_GRPC_PORT")
 	productTarget := productServiceGrpcHost + ":" + productServiceGrpcPort
-	productConn := shared.NewGrpcClientConn(productTarget)
+	productConn := shared.NewGrpcClientConn(productTarget, shared.WithServiceToken("order-service"))
 
 	productServiceGrpc := prpb.NewProductServiceClient(productConn)
 
@@ -52,6 +55,8 @@ func NewAppServer() *AppServer {
 	orderService := NewOrderService(db, validate, producer, &paymentServiceGrpc, &productServiceGrpc, &userServiceGrpc)
 	orderService.RegisterRoutes(api)
 
+	go StartUserConsumer(orderService.HandleUserEvent)
+
 	return &AppServer{
 		server: server,
 	}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	prpb "github.com/akmmp241/topupstore-microservice/product-proto/v1"
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkAvailability refuses products that cannot be bought right now.
+func checkAvailability(product *prpb.Product) error {
+	if product.GetAvailable() {
+		return nil
+	}
+
+	switch product.GetAvailabilityStatus() {
+	case "out_of_stock":
+		return fiber.NewError(fiber.StatusConflict, product.GetName()+" is out of stock")
+	case "maintenance":
+		if product.GetMaintenanceEnd() != nil {
+			return fiber.NewError(fiber.StatusConflict, product.GetName()+" is under maintenance until "+
+				product.GetMaintenanceEnd().AsTime().Format("02 Jan 2006 15:04 MST"))
+		}
+		return fiber.NewError(fiber.StatusConflict, product.GetName()+" is under maintenance")
+	default:
+		return fiber.NewError(fiber.StatusConflict, product.GetName()+" is not available")
+	}
+}
+
+// reserveStock takes a unit of a stock tracked product for the order, it is
+// released again when the order is not stored or its payment fails.
+func (o *OrderService) reserveStock(ctx context.Context, product *prpb.Product, orderData *Order) error {
+	if !product.GetStockTracked() {
+		return nil
+	}
+
+	_, err := (*o.ProductService).ReserveStock(ctx, &prpb.ReserveStockReq{ProductId: product.GetId(), OrderId: orderData.Id})
+	if err != nil {
+		st, ok := status.FromError(err)
+		if ok && st.Code() == codes.FailedPrecondition {
+			return fiber.NewError(fiber.StatusConflict, product.GetName()+" is out of stock")
+		}
+		if ok && st.Code() == codes.NotFound {
+			return fiber.NewError(fiber.StatusNotFound, "Product not found")
+		}
+
+		slog.Error("Error occurred while reserving stock", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (o *OrderService) releaseStock(ctx context.Context, orderId string) {
+	releaseStockRes, err := (*o.ProductService).ReleaseStock(ctx, &prpb.ReleaseStockReq{OrderId: orderId})
+	if err != nil {
+		slog.Error("Error occurred while releasing stock", "order-id", orderId, "err", err)
+		return
+	}
+
+	if releaseStockRes.GetReleased() {
+		slog.Info("Stock released", "order-id", orderId)
+	}
+}
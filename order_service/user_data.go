@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/gofiber/fiber/v2"
+	"github.com/segmentio/kafka-go"
+)
+
+const UserTopic = "user-events"
+
+const UserDeleted = "user-deleted"
+
+const GroupId = "order-service-group"
+
+type UserEvent struct {
+	EventType string          `json:"event_type"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type UserDeletedMessage struct {
+	UserId          int       `json:"user_id"`
+	AnonymizedEmail string    `json:"anonymized_email"`
+	DeletedAt       time.Time `json:"deleted_at"`
+}
+
+// handleGetOrdersByBuyer is used by user service to build a data export.
+func (o *OrderService) handleGetOrdersByBuyer(c *fiber.Ctx) error {
+	buyerId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	query := `SELECT id, buyer_id, buyer_email, buyer_phone, product_id, product_name, destination, server_id, channel_code, total_product_amount, service_charge, total_amount, status, failure_code, created_at, updated_at
+			FROM orders WHERE buyer_id = ? ORDER BY created_at DESC`
+
+	rows, err := o.DB.QueryContext(c.Context(), query, buyerId)
+	if err != nil {
+		slog.Error("Error occurred while querying orders", "err", err)
+		return err
+	}
+	defer rows.Close()
+
+	orders := []Order{}
+	for rows.Next() {
+		var order Order
+		var buyerPhone sql.NullString
+		var failureCode sql.NullString
+		err := rows.Scan(
+			&order.Id,
+			&order.BuyerId,
+			&order.BuyerEmail,
+			&buyerPhone,
+			&order.ProductId,
+			&order.ProductName,
+			&order.Destination,
+			&order.ServerId,
+			&order.ChannelCode,
+			&order.TotalProductAmount,
+			&order.ServiceCharge,
+			&order.TotalAmount,
+			&order.Status,
+			&failureCode,
+			&order.CreatedAt,
+			&order.UpdatedAt,
+		)
+		if err != nil {
+			slog.Error("Error occurred while scanning order row", "err", err)
+			return err
+		}
+		order.BuyerPhone = buyerPhone.String
+		order.FailureCode = failureCode.String
+		orders = append(orders, order)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Orders retrieved successfully",
+		"data":    orders,
+		"errors":  nil,
+	})
+}
+
+// HandleUserEvent anonymises the buyer details of a deleted user. The orders
+// themselves stay, they are financial records.
+func (o *OrderService) HandleUserEvent(msg *kafka.Message) error {
+	var event UserEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		slog.Error("Error unmarshalling message", "error", err)
+		return err
+	}
+
+	if event.EventType != UserDeleted {
+		return nil
+	}
+
+	var data UserDeletedMessage
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		slog.Error("Error unmarshalling message", "error", err)
+		return err
+	}
+
+	result, err := o.DB.ExecContext(o.Ctx, "UPDATE orders SET buyer_email = ?, buyer_phone = NULL WHERE buyer_id = ?", data.AnonymizedEmail, data.UserId)
+	if err != nil {
+		slog.Error("Error occurred while anonymising orders", "err", err)
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	slog.Info("Anonymised orders of deleted user", "user-id", data.UserId, "orders", rowsAffected)
+
+	return nil
+}
+
+func StartUserConsumer(handler func(msg *kafka.Message) error) {
+	reader := shared.NewKafkaConsumer(GroupId, UserTopic)
+	defer reader.Close()
+
+	slog.Info("Kafka Consumer created with", "topic:", UserTopic, "group-id:", GroupId)
+
+	for {
+		message, err := reader.ReadMessage(context.Background())
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				slog.Warn("Reached EOF, possibly no messages yet.")
+				continue
+			}
+			slog.Error("Error while reading", "error:", err)
+			break
+		}
+
+		if err := handler(&message); err != nil {
+			slog.Error("Error while handling message", "error:", err)
+		}
+	}
+}
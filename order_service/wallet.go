@@ -0,0 +1,461 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	ppb "github.com/akmmp241/topupstore-microservice/payment-proto/v1"
+	"github.com/akmmp241/topupstore-microservice/shared"
+	upb "github.com/akmmp241/topupstore-microservice/user-proto/v1"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	PaymentStatusPending   = "PENDING"
+	PaymentStatusSucceeded = "SUCCEEDED"
+	PaymentStatusFailed    = "FAILED"
+	PaymentStatusExpired   = "EXPIRED"
+	OrderStatusRefunded    = "REFUNDED"
+)
+
+const (
+	topupReferencePrefix  = "topup-"
+	defaultStatementLimit = 50
+	maxStatementLimit     = 100
+)
+
+type WalletTopup struct {
+	Id                 string    `json:"id"`
+	UserId             int       `json:"user_id"`
+	Amount             int       `json:"amount"`
+	ServiceCharge      int       `json:"service_charge"`
+	TotalAmount        int       `json:"total_amount"`
+	ChannelCode        string    `json:"channel_code"`
+	PaymentReferenceId string    `json:"payment_reference_id"`
+	Status             string    `json:"status"`
+	FailureCode        string    `json:"failure_code"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+func isTopupReference(referenceId string) bool {
+	return strings.HasPrefix(referenceId, topupReferencePrefix)
+}
+
+func getClaimsUserId(c *fiber.Ctx) (int, error) {
+	userId, err := strconv.Atoi(shared.GetUserClaims(c).Subject)
+	if err != nil {
+		return 0, fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	return userId, nil
+}
+
+func (o *OrderService) handleGetWallet(c *fiber.Ctx) error {
+	userId, err := getClaimsUserId(c)
+	if err != nil {
+		return err
+	}
+
+	var balance int64
+	err = o.DB.QueryRowContext(c.Context(), "SELECT balance FROM wallets WHERE user_id = ?", userId).Scan(&balance)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error("Error occurred while querying wallet", "err", err)
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Wallet retrieved successfully",
+		"data":    fiber.Map{"balance": balance},
+		"errors":  nil,
+	})
+}
+
+// handleGetWalletStatement lists the wallet entries newest first with the
+// balance after each of them. Pass the id of the last entry as before to get
+// the next page.
+func (o *OrderService) handleGetWalletStatement(c *fiber.Ctx) error {
+	userId, err := getClaimsUserId(c)
+	if err != nil {
+		return err
+	}
+
+	limit := c.QueryInt("limit", defaultStatementLimit)
+	if limit <= 0 || limit > maxStatementLimit {
+		limit = maxStatementLimit
+	}
+
+	query := `SELECT le.id, le.journal_id, le.direction, le.amount, le.balance_after, le.reference_type, le.reference_id, le.description, le.created_at
+				FROM ledger_entries le JOIN wallets w ON w.id = le.wallet_id
+			WHERE w.user_id = ?`
+	args := []any{userId}
+	if before := c.QueryInt("before"); before > 0 {
+		query += " AND le.id < ?"
+		args = append(args, before)
+	}
+	query += " ORDER BY le.id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := o.DB.QueryContext(c.Context(), query, args...)
+	if err != nil {
+		slog.Error("Error occurred while querying ledger entries", "err", err)
+		return err
+	}
+	defer rows.Close()
+
+	entries := []*LedgerEntry{}
+	for rows.Next() {
+		var entry LedgerEntry
+		err := rows.Scan(&entry.Id, &entry.JournalId, &entry.Direction, &entry.Amount, &entry.BalanceAfter,
+			&entry.ReferenceType, &entry.ReferenceId, &entry.Description, &entry.CreatedAt)
+		if err != nil {
+			slog.Error("Error occurred while scanning ledger entry", "err", err)
+			return err
+		}
+		entries = append(entries, &entry)
+	}
+
+	var nextBefore *int
+	if len(entries) == limit {
+		nextBefore = &entries[len(entries)-1].Id
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Wallet statement retrieved successfully",
+		"data":    fiber.Map{"entries": entries, "next_before": nextBefore},
+		"errors":  nil,
+	})
+}
+
+func (o *OrderService) handleCreateTopup(c *fiber.Ctx) error {
+	topupRequest := &CreateTopupRequest{}
+	if err := c.BodyParser(topupRequest); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request")
+	}
+
+	err := o.Validate.Struct(topupRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*topupRequest, err.(validator.ValidationErrors))
+	}
+
+	if topupRequest.PaymentMethod == BalanceChannelCode {
+		return fiber.NewError(fiber.StatusBadRequest, "The balance cannot be topped up with itself")
+	}
+
+	paymentMethod, err := getPaymentMethodDetails(topupRequest.PaymentMethod, topupRequest.Amount)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	userId, err := getClaimsUserId(c)
+	if err != nil {
+		return err
+	}
+
+	getUserByIdRes, err := (*o.UserService).GetUserById(c.Context(), &upb.GetUserByIdReq{Id: strconv.Itoa(userId)})
+	if err != nil {
+		slog.Error("Error occurred while calling user service", "err", err)
+		return err
+	}
+	user := getUserByIdRes.GetUser()
+
+	topup := &WalletTopup{
+		Id:            topupReferencePrefix + uuid.NewString(),
+		UserId:        userId,
+		Amount:        topupRequest.Amount,
+		ServiceCharge: paymentMethod.TotalAmount - topupRequest.Amount,
+		TotalAmount:   paymentMethod.TotalAmount,
+		ChannelCode:   paymentMethod.ChannelCode,
+		Status:        PaymentStatusPending,
+		CreatedAt:     time.Now(),
+	}
+	topup.UpdatedAt = topup.CreatedAt
+
+	_, err = o.DB.ExecContext(c.Context(), "INSERT INTO wallet_topups (id, user_id, amount, service_charge, total_amount, channel_code, status) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		topup.Id, topup.UserId, topup.Amount, topup.ServiceCharge, topup.TotalAmount, topup.ChannelCode, topup.Status)
+	if err != nil {
+		slog.Error("Error occurred while inserting wallet top-up", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	createPaymentRes, err := (*o.PaymentService).CreatePayment(c.Context(), &ppb.CreatePaymentReq{
+		ReferenceId:       topup.Id,
+		ChannelCode:       topup.ChannelCode,
+		Amount:            int32(topup.TotalAmount),
+		BuyerEmail:        user.GetEmail(),
+		BuyerMobileNumber: user.GetPhoneNumber(),
+	})
+	if err != nil {
+		slog.Error("Error occurred while calling payment service", "err", err)
+		_, err = o.DB.ExecContext(c.Context(), "UPDATE wallet_topups SET status = ? WHERE id = ?", PaymentStatusFailed, topup.Id)
+		if err != nil {
+			slog.Error("Error occurred while failing wallet top-up", "id", topup.Id, "err", err)
+		}
+		return fiber.NewError(fiber.StatusBadGateway, "Failed to create payment")
+	}
+
+	topup.PaymentReferenceId = createPaymentRes.GetXenditPaymentId()
+	topup.Status = createPaymentRes.GetStatus()
+	topup.FailureCode = createPaymentRes.GetFailureCode()
+
+	_, err = o.DB.ExecContext(c.Context(), "UPDATE wallet_topups SET payment_reference_id = ?, status = ?, failure_code = ? WHERE id = ? AND status = ?",
+		topup.PaymentReferenceId, topup.Status, topup.FailureCode, topup.Id, PaymentStatusPending)
+	if err != nil {
+		slog.Error("Error occurred while updating wallet top-up", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Top-up created successfully",
+		"data":    topup,
+		"errors":  nil,
+	})
+}
+
+func (o *OrderService) handleGetTopup(c *fiber.Ctx) error {
+	userId, err := getClaimsUserId(c)
+	if err != nil {
+		return err
+	}
+
+	query := `SELECT id, user_id, amount, service_charge, total_amount, channel_code, COALESCE(payment_reference_id, ''), status, COALESCE(failure_code, ''), created_at, updated_at
+				FROM wallet_topups WHERE id = ? AND user_id = ?`
+
+	var topup WalletTopup
+	err = o.DB.QueryRowContext(c.Context(), query, c.Params("id"), userId).Scan(&topup.Id, &topup.UserId, &topup.Amount, &topup.ServiceCharge,
+		&topup.TotalAmount, &topup.ChannelCode, &topup.PaymentReferenceId, &topup.Status, &topup.FailureCode, &topup.CreatedAt, &topup.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fiber.NewError(fiber.StatusNotFound, "Top-up not found")
+		}
+		slog.Error("Error occurred while querying wallet top-up", "err", err)
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Top-up retrieved successfully",
+		"data":    topup,
+		"errors":  nil,
+	})
+}
+
+// handleTopupWebhook credits the wallet once the top-up payment succeeded.
+// Xendit may deliver a webhook more than once, the top-up row lock and the
+// unique journal make the credit happen exactly once.
+func (o *OrderService) handleTopupWebhook(c *fiber.Ctx, webhookRequest *XenditPaymentRequest) error {
+	err := o.applyTopupResult(c.Context(), webhookRequest)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fiber.NewError(fiber.StatusNotFound, "Top-up not found")
+		}
+		slog.Error("Error occurred while applying top-up result", "id", webhookRequest.ReferenceId, "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+func (o *OrderService) applyTopupResult(ctx context.Context, webhookRequest *XenditPaymentRequest) (err error) {
+	tx, err := o.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	var userId, amount int
+	var currentStatus string
+	err = tx.QueryRowContext(ctx, "SELECT user_id, amount, status FROM wallet_topups WHERE id = ? FOR UPDATE", webhookRequest.ReferenceId).
+		Scan(&userId, &amount, &currentStatus)
+	if err != nil {
+		return err
+	}
+
+	if currentStatus == PaymentStatusSucceeded {
+		slog.Info("Top-up already credited", "id", webhookRequest.ReferenceId)
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE wallet_topups SET status = ?, failure_code = ? WHERE id = ?",
+		webhookRequest.Status, webhookRequest.FailureCode, webhookRequest.ReferenceId)
+	if err != nil {
+		return err
+	}
+
+	if webhookRequest.Status != PaymentStatusSucceeded {
+		return nil
+	}
+
+	_, err = postWalletJournal(ctx, tx, userId, DirectionCredit, int64(amount), AccountPaymentGateway,
+		ReferenceTopup, webhookRequest.ReferenceId, "Balance top-up")
+	if errors.Is(err, errJournalPosted) {
+		return nil
+	}
+
+	return err
+}
+
+// createBalanceOrder debits the wallet and stores the order in one
+// transaction, the order is paid as soon as it exists.
+func (o *OrderService) createBalanceOrder(c *fiber.Ctx, user *upb.User, orderData *Order) error {
+	if user == nil {
+		o.releaseStock(o.Ctx, orderData.Id)
+		return fiber.NewError(fiber.StatusUnauthorized, "Please log in to pay with your balance")
+	}
+
+	orderData.Status = PaymentStatusSucceeded
+	orderData.PaymentReferenceId = journalId(ReferenceOrder, orderData.Id)
+	orderData.CreatedAt = time.Now()
+
+	err := o.payOrderWithBalance(c.Context(), user, orderData)
+	if err != nil {
+		o.restorePoints(o.Ctx, orderData)
+		o.releaseStock(o.Ctx, orderData.Id)
+		if errors.Is(err, errInsufficientBalance) {
+			return fiber.NewError(fiber.StatusPaymentRequired, "Insufficient balance")
+		}
+		return err
+	}
+
+	for _, eventType := range []string{NewOrder, SuccessOrder} {
+		if err := o.publishOrderEvent(eventType, orderData); err != nil {
+			slog.Error("Error occurred while sending order event", "event", eventType, "err", err)
+		}
+	}
+
+	go o.fulfillOrder(o.Ctx, orderData)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Order created successfully",
+		"data":    orderData,
+		"errors":  nil,
+	})
+}
+
+func (o *OrderService) payOrderWithBalance(ctx context.Context, user *upb.User, orderData *Order) (err error) {
+	tx, err := o.DB.Begin()
+	if err != nil {
+		slog.Error("Error occurred while starting transaction", "err", err)
+		return err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	_, err = postWalletJournal(ctx, tx, int(user.GetId()), DirectionDebit, int64(orderData.TotalAmount), AccountSales,
+		ReferenceOrder, orderData.Id, "Payment for "+orderData.ProductName)
+	if err != nil {
+		return err
+	}
+
+	return insertOrder(ctx, tx, orderData)
+}
+
+// handleRefundOrder credits the total of a paid order whose fulfillment failed
+// back to the buyer's wallet, whatever channel the order was paid with. The
+// stock and the redeemed points of the order are given back as well.
+func (o *OrderService) handleRefundOrder(c *fiber.Ctx) error {
+	orderId := c.Params("id")
+
+	pointsRedeemed, err := o.refundOrder(c.Context(), orderId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fiber.NewError(fiber.StatusNotFound, "Order not found")
+		}
+		if errors.Is(err, errJournalPosted) {
+			return fiber.NewError(fiber.StatusConflict, "Order already refunded")
+		}
+		return err
+	}
+
+	slog.Info("Order refunded to wallet", "id", orderId, "by", shared.GetUserClaims(c).Subject)
+
+	o.releaseStock(o.Ctx, orderId)
+	o.restorePoints(o.Ctx, &Order{Id: orderId, PointsRedeemed: pointsRedeemed})
+	go o.clawbackReferral(o.Ctx, orderId)
+
+	return c.JSON(fiber.Map{
+		"message": "Order refunded to the buyer's balance",
+		"data":    nil,
+		"errors":  nil,
+	})
+}
+
+// refundOrder returns the points the order redeemed, they go back to the
+// buyer once the refund is committed.
+func (o *OrderService) refundOrder(ctx context.Context, orderId string) (pointsRedeemed int, err error) {
+	tx, err := o.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	var buyerId sql.NullInt64
+	var totalAmount int
+	var orderStatus, fulfillmentStatus string
+	err = tx.QueryRowContext(ctx, "SELECT buyer_id, total_amount, points_redeemed, status, fulfillment_status FROM orders WHERE id = ? FOR UPDATE", orderId).
+		Scan(&buyerId, &totalAmount, &pointsRedeemed, &orderStatus, &fulfillmentStatus)
+	if err != nil {
+		return 0, err
+	}
+
+	if !buyerId.Valid || buyerId.Int64 == 0 {
+		return 0, fiber.NewError(fiber.StatusUnprocessableEntity, "Guest orders cannot be refunded to a balance")
+	}
+	if orderStatus == OrderStatusRefunded {
+		return 0, errJournalPosted
+	}
+	if orderStatus != PaymentStatusSucceeded {
+		return 0, fiber.NewError(fiber.StatusConflict, "Only paid orders can be refunded")
+	}
+	// a processing or unknown order may still be delivered, retry it instead
+	if fulfillmentStatus != FulfillmentFailed {
+		return 0, fiber.NewError(fiber.StatusConflict, "Only orders whose fulfillment failed can be refunded")
+	}
+
+	_, err = postWalletJournal(ctx, tx, int(buyerId.Int64), DirectionCredit, int64(totalAmount), AccountSales,
+		ReferenceRefund, orderId, "Refund for order "+orderId)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE orders SET status = ? WHERE id = ?", OrderStatusRefunded, orderId)
+	return pointsRedeemed, err
+}
+
+func (o *OrderService) publishOrderEvent(eventType string, order *Order) error {
+	baseMsg := &OrderEvent{
+		EventTye: eventType,
+		Data: &OrderMsg{
+			Id:                 order.Id,
+			Status:             order.Status,
+			FailureCode:        order.FailureCode,
+			ProductId:          order.ProductId,
+			ProductName:        order.ProductName,
+			ProductPrice:       order.TotalProductAmount,
+			Destination:        order.Destination,
+			ServerId:           order.ServerId,
+			ChannelCode:        order.ChannelCode,
+			BuyerId:            order.BuyerId,
+			BuyerEmail:         order.BuyerEmail,
+			ServiceCharge:      order.ServiceCharge,
+			TotalProductAmount: order.TotalProductAmount,
+			TotalAmount:        order.TotalAmount,
+			PointsDiscount:     order.PointsDiscount,
+			PointsEarned:       order.PointsEarned,
+			CreatedAt:          order.CreatedAt,
+		},
+	}
+
+	orderMsgJson, err := json.Marshal(baseMsg)
+	if err != nil {
+		return err
+	}
+
+	return o.Producer.Write(o.Ctx, OrderTopic, [2]string{order.Id, string(orderMsgJson)})
+}
@@ -14,12 +14,19 @@ import (
 	"time"
 
 	ppb "github.com/akmmp241/topupstore-microservice/payment-proto/v1"
+	"github.com/akmmp241/topupstore-microservice/shared"
 	"github.com/gofiber/fiber/v2"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// grpcPermissions lists the methods only order service may call.
+var grpcPermissions = map[string]string{
+	ppb.PaymentService_CreatePayment_FullMethodName:  shared.PermServiceCall,
+	ppb.PaymentService_GetPaymentById_FullMethodName: shared.PermServiceCall,
+}
+
 type GrpcServer struct {
 	ListenAddr  string
 	DB          *sql.DB
@@ -244,7 +251,7 @@ func NewGrpcServer(listenAddr string, DB *sql.DB) *GrpcServer {
 	return &GrpcServer{
 		ListenAddr:  listenAddr,
 		DB:          DB,
-		Server:      grpc.NewServer(),
+		Server:      grpc.NewServer(grpc.UnaryInterceptor(shared.PermissionUnaryInterceptor(grpcPermissions))),
 		NetListener: listener,
 	}
 }
@@ -18,7 +18,10 @@ type AppServer struct {
 
 func NewAppServer(db *sql.DB) *AppServer {
 	server := fiber.New(fiber.Config{
-		ErrorHandler: shared.ErrorHandler,
+		ErrorHandler:            shared.ErrorHandler,
+		ProxyHeader:             shared.ClientIPHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          shared.TrustedProxies(),
 	})
 	validate := validator.New()
 
@@ -207,16 +207,17 @@ func (x *Operator) GetUpdatedAt() *timestamppb.Timestamp {
 }
 
 type ProductType struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	RefId         string                 `protobuf:"bytes,2,opt,name=ref_id,json=refId,proto3" json:"ref_id,omitempty"`
-	OperatorId    int32                  `protobuf:"varint,3,opt,name=operator_id,json=operatorId,proto3" json:"operator_id,omitempty"`
-	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
-	FormatForm    string                 `protobuf:"bytes,5,opt,name=format_form,json=formatForm,proto3" json:"format_form,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	RefId          string                 `protobuf:"bytes,2,opt,name=ref_id,json=refId,proto3" json:"ref_id,omitempty"`
+	OperatorId     int32                  `protobuf:"varint,3,opt,name=operator_id,json=operatorId,proto3" json:"operator_id,omitempty"`
+	Name           string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	FormatForm     string                 `protobuf:"bytes,5,opt,name=format_form,json=formatForm,proto3" json:"format_form,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt      *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	InquiryEnabled bool                   `protobuf:"varint,8,opt,name=inquiry_enabled,json=inquiryEnabled,proto3" json:"inquiry_enabled,omitempty"` // the operator resolves account nicknames
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *ProductType) Reset() {
@@ -298,19 +299,34 @@ func (x *ProductType) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *ProductType) GetInquiryEnabled() bool {
+	if x != nil {
+		return x.InquiryEnabled
+	}
+	return false
+}
+
 type Product struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
-	RefId         string                 `protobuf:"bytes,2,opt,name=ref_id,json=refId,proto3" json:"ref_id,omitempty"`
-	ProductTypeId int32                  `protobuf:"varint,3,opt,name=product_type_id,json=productTypeId,proto3" json:"product_type_id,omitempty"`
-	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
-	Price         int32                  `protobuf:"varint,5,opt,name=price,proto3" json:"price,omitempty"`
-	ImageUrl      string                 `protobuf:"bytes,6,opt,name=image_url,json=imageUrl,proto3" json:"image_url,omitempty"`
-	Description   string                 `protobuf:"bytes,7,opt,name=description,proto3" json:"description,omitempty"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state                    protoimpl.MessageState `protogen:"open.v1"`
+	Id                       int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	RefId                    string                 `protobuf:"bytes,2,opt,name=ref_id,json=refId,proto3" json:"ref_id,omitempty"`
+	ProductTypeId            int32                  `protobuf:"varint,3,opt,name=product_type_id,json=productTypeId,proto3" json:"product_type_id,omitempty"`
+	Name                     string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Price                    int32                  `protobuf:"varint,5,opt,name=price,proto3" json:"price,omitempty"`
+	ImageUrl                 string                 `protobuf:"bytes,6,opt,name=image_url,json=imageUrl,proto3" json:"image_url,omitempty"`
+	Description              string                 `protobuf:"bytes,7,opt,name=description,proto3" json:"description,omitempty"`
+	CreatedAt                *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt                *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	OperatorId               int32                  `protobuf:"varint,10,opt,name=operator_id,json=operatorId,proto3" json:"operator_id,omitempty"`                                               // of the product type
+	BasePrice                int32                  `protobuf:"varint,11,opt,name=base_price,json=basePrice,proto3" json:"base_price,omitempty"`                                                  // price is the tier price when a tier was requested
+	LoyaltyPointsPerThousand int32                  `protobuf:"varint,12,opt,name=loyalty_points_per_thousand,json=loyaltyPointsPerThousand,proto3" json:"loyalty_points_per_thousand,omitempty"` // points earned per 1000 spent, product rule before category rule
+	Available                bool                   `protobuf:"varint,13,opt,name=available,proto3" json:"available,omitempty"`
+	AvailabilityStatus       string                 `protobuf:"bytes,14,opt,name=availability_status,json=availabilityStatus,proto3" json:"availability_status,omitempty"` // active, inactive, out_of_stock or maintenance
+	StockTracked             bool                   `protobuf:"varint,15,opt,name=stock_tracked,json=stockTracked,proto3" json:"stock_tracked,omitempty"`
+	Stock                    int32                  `protobuf:"varint,16,opt,name=stock,proto3" json:"stock,omitempty"`
+	MaintenanceEnd           *timestamppb.Timestamp `protobuf:"bytes,17,opt,name=maintenance_end,json=maintenanceEnd,proto3" json:"maintenance_end,omitempty"`
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
 }
 
 func (x *Product) Reset() {
@@ -406,9 +422,66 @@ func (x *Product) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Product) GetOperatorId() int32 {
+	if x != nil {
+		return x.OperatorId
+	}
+	return 0
+}
+
+func (x *Product) GetBasePrice() int32 {
+	if x != nil {
+		return x.BasePrice
+	}
+	return 0
+}
+
+func (x *Product) GetLoyaltyPointsPerThousand() int32 {
+	if x != nil {
+		return x.LoyaltyPointsPerThousand
+	}
+	return 0
+}
+
+func (x *Product) GetAvailable() bool {
+	if x != nil {
+		return x.Available
+	}
+	return false
+}
+
+func (x *Product) GetAvailabilityStatus() string {
+	if x != nil {
+		return x.AvailabilityStatus
+	}
+	return ""
+}
+
+func (x *Product) GetStockTracked() bool {
+	if x != nil {
+		return x.StockTracked
+	}
+	return false
+}
+
+func (x *Product) GetStock() int32 {
+	if x != nil {
+		return x.Stock
+	}
+	return 0
+}
+
+func (x *Product) GetMaintenanceEnd() *timestamppb.Timestamp {
+	if x != nil {
+		return x.MaintenanceEnd
+	}
+	return nil
+}
+
 type GetProductByIdReq struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ProductId     int32                  `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Tier          string                 `protobuf:"bytes,2,opt,name=tier,proto3" json:"tier,omitempty"` // resolves price for this tier, empty for the retail price
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -450,6 +523,13 @@ func (x *GetProductByIdReq) GetProductId() int32 {
 	return 0
 }
 
+func (x *GetProductByIdReq) GetTier() string {
+	if x != nil {
+		return x.Tier
+	}
+	return ""
+}
+
 type GetProductByIdRes struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Product       *Product               `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
@@ -494,6 +574,634 @@ func (x *GetProductByIdRes) GetProduct() *Product {
 	return nil
 }
 
+type GetProductTypeByIdReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductTypeId int32                  `protobuf:"varint,1,opt,name=product_type_id,json=productTypeId,proto3" json:"product_type_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProductTypeByIdReq) Reset() {
+	*x = GetProductTypeByIdReq{}
+	mi := &file_product_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProductTypeByIdReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProductTypeByIdReq) ProtoMessage() {}
+
+func (x *GetProductTypeByIdReq) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProductTypeByIdReq.ProtoReflect.Descriptor instead.
+func (*GetProductTypeByIdReq) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetProductTypeByIdReq) GetProductTypeId() int32 {
+	if x != nil {
+		return x.ProductTypeId
+	}
+	return 0
+}
+
+type GetProductTypeByIdRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductType   *ProductType           `protobuf:"bytes,1,opt,name=product_type,json=productType,proto3" json:"product_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProductTypeByIdRes) Reset() {
+	*x = GetProductTypeByIdRes{}
+	mi := &file_product_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProductTypeByIdRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProductTypeByIdRes) ProtoMessage() {}
+
+func (x *GetProductTypeByIdRes) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProductTypeByIdRes.ProtoReflect.Descriptor instead.
+func (*GetProductTypeByIdRes) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetProductTypeByIdRes) GetProductType() *ProductType {
+	if x != nil {
+		return x.ProductType
+	}
+	return nil
+}
+
+type MatchOperatorPrefixReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OperatorId    int32                  `protobuf:"varint,1,opt,name=operator_id,json=operatorId,proto3" json:"operator_id,omitempty"`
+	Msisdn        string                 `protobuf:"bytes,2,opt,name=msisdn,proto3" json:"msisdn,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MatchOperatorPrefixReq) Reset() {
+	*x = MatchOperatorPrefixReq{}
+	mi := &file_product_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MatchOperatorPrefixReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchOperatorPrefixReq) ProtoMessage() {}
+
+func (x *MatchOperatorPrefixReq) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchOperatorPrefixReq.ProtoReflect.Descriptor instead.
+func (*MatchOperatorPrefixReq) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *MatchOperatorPrefixReq) GetOperatorId() int32 {
+	if x != nil {
+		return x.OperatorId
+	}
+	return 0
+}
+
+func (x *MatchOperatorPrefixReq) GetMsisdn() string {
+	if x != nil {
+		return x.Msisdn
+	}
+	return ""
+}
+
+type MatchOperatorPrefixRes struct {
+	state                protoimpl.MessageState `protogen:"open.v1"`
+	Applies              bool                   `protobuf:"varint,1,opt,name=applies,proto3" json:"applies,omitempty"` // false when the operator has no prefixes
+	Matches              bool                   `protobuf:"varint,2,opt,name=matches,proto3" json:"matches,omitempty"`
+	DetectedOperatorId   int32                  `protobuf:"varint,3,opt,name=detected_operator_id,json=detectedOperatorId,proto3" json:"detected_operator_id,omitempty"` // 0 when no operator owns the prefix
+	DetectedOperatorName string                 `protobuf:"bytes,4,opt,name=detected_operator_name,json=detectedOperatorName,proto3" json:"detected_operator_name,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *MatchOperatorPrefixRes) Reset() {
+	*x = MatchOperatorPrefixRes{}
+	mi := &file_product_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MatchOperatorPrefixRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchOperatorPrefixRes) ProtoMessage() {}
+
+func (x *MatchOperatorPrefixRes) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchOperatorPrefixRes.ProtoReflect.Descriptor instead.
+func (*MatchOperatorPrefixRes) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MatchOperatorPrefixRes) GetApplies() bool {
+	if x != nil {
+		return x.Applies
+	}
+	return false
+}
+
+func (x *MatchOperatorPrefixRes) GetMatches() bool {
+	if x != nil {
+		return x.Matches
+	}
+	return false
+}
+
+func (x *MatchOperatorPrefixRes) GetDetectedOperatorId() int32 {
+	if x != nil {
+		return x.DetectedOperatorId
+	}
+	return 0
+}
+
+func (x *MatchOperatorPrefixRes) GetDetectedOperatorName() string {
+	if x != nil {
+		return x.DetectedOperatorName
+	}
+	return ""
+}
+
+type ReserveStockReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ProductId     int32                  `protobuf:"varint,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReserveStockReq) Reset() {
+	*x = ReserveStockReq{}
+	mi := &file_product_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReserveStockReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReserveStockReq) ProtoMessage() {}
+
+func (x *ReserveStockReq) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReserveStockReq.ProtoReflect.Descriptor instead.
+func (*ReserveStockReq) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ReserveStockReq) GetProductId() int32 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *ReserveStockReq) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type ReserveStockRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StockTracked  bool                   `protobuf:"varint,1,opt,name=stock_tracked,json=stockTracked,proto3" json:"stock_tracked,omitempty"`
+	Remaining     int32                  `protobuf:"varint,2,opt,name=remaining,proto3" json:"remaining,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReserveStockRes) Reset() {
+	*x = ReserveStockRes{}
+	mi := &file_product_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReserveStockRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReserveStockRes) ProtoMessage() {}
+
+func (x *ReserveStockRes) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReserveStockRes.ProtoReflect.Descriptor instead.
+func (*ReserveStockRes) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ReserveStockRes) GetStockTracked() bool {
+	if x != nil {
+		return x.StockTracked
+	}
+	return false
+}
+
+func (x *ReserveStockRes) GetRemaining() int32 {
+	if x != nil {
+		return x.Remaining
+	}
+	return 0
+}
+
+type ReleaseStockReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseStockReq) Reset() {
+	*x = ReleaseStockReq{}
+	mi := &file_product_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseStockReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseStockReq) ProtoMessage() {}
+
+func (x *ReleaseStockReq) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseStockReq.ProtoReflect.Descriptor instead.
+func (*ReleaseStockReq) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ReleaseStockReq) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type ReleaseStockRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Released      bool                   `protobuf:"varint,1,opt,name=released,proto3" json:"released,omitempty"` // false when nothing was reserved or it was released before
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseStockRes) Reset() {
+	*x = ReleaseStockRes{}
+	mi := &file_product_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseStockRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseStockRes) ProtoMessage() {}
+
+func (x *ReleaseStockRes) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseStockRes.ProtoReflect.Descriptor instead.
+func (*ReleaseStockRes) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ReleaseStockRes) GetReleased() bool {
+	if x != nil {
+		return x.Released
+	}
+	return false
+}
+
+type FulfillOrderReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"` // sent to suppliers as the reference, they dedupe on it
+	ProductId     int32                  `protobuf:"varint,2,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	Destination   string                 `protobuf:"bytes,3,opt,name=destination,proto3" json:"destination,omitempty"`
+	ServerId      string                 `protobuf:"bytes,4,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FulfillOrderReq) Reset() {
+	*x = FulfillOrderReq{}
+	mi := &file_product_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FulfillOrderReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FulfillOrderReq) ProtoMessage() {}
+
+func (x *FulfillOrderReq) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FulfillOrderReq.ProtoReflect.Descriptor instead.
+func (*FulfillOrderReq) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *FulfillOrderReq) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *FulfillOrderReq) GetProductId() int32 {
+	if x != nil {
+		return x.ProductId
+	}
+	return 0
+}
+
+func (x *FulfillOrderReq) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *FulfillOrderReq) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+type FulfillmentAttempt struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SupplierId    int32                  `protobuf:"varint,1,opt,name=supplier_id,json=supplierId,proto3" json:"supplier_id,omitempty"`
+	SupplierCode  string                 `protobuf:"bytes,2,opt,name=supplier_code,json=supplierCode,proto3" json:"supplier_code,omitempty"`
+	SupplierSku   string                 `protobuf:"bytes,3,opt,name=supplier_sku,json=supplierSku,proto3" json:"supplier_sku,omitempty"`
+	Cost          int32                  `protobuf:"varint,4,opt,name=cost,proto3" json:"cost,omitempty"`
+	Outcome       string                 `protobuf:"bytes,5,opt,name=outcome,proto3" json:"outcome,omitempty"` // succeeded, rejected, error or skipped
+	Message       string                 `protobuf:"bytes,6,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FulfillmentAttempt) Reset() {
+	*x = FulfillmentAttempt{}
+	mi := &file_product_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FulfillmentAttempt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FulfillmentAttempt) ProtoMessage() {}
+
+func (x *FulfillmentAttempt) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FulfillmentAttempt.ProtoReflect.Descriptor instead.
+func (*FulfillmentAttempt) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *FulfillmentAttempt) GetSupplierId() int32 {
+	if x != nil {
+		return x.SupplierId
+	}
+	return 0
+}
+
+func (x *FulfillmentAttempt) GetSupplierCode() string {
+	if x != nil {
+		return x.SupplierCode
+	}
+	return ""
+}
+
+func (x *FulfillmentAttempt) GetSupplierSku() string {
+	if x != nil {
+		return x.SupplierSku
+	}
+	return ""
+}
+
+func (x *FulfillmentAttempt) GetCost() int32 {
+	if x != nil {
+		return x.Cost
+	}
+	return 0
+}
+
+func (x *FulfillmentAttempt) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+func (x *FulfillmentAttempt) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type FulfillOrderRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // succeeded, failed, or unknown when a supplier did not answer clearly
+	Policy        string                 `protobuf:"bytes,2,opt,name=policy,proto3" json:"policy,omitempty"`
+	SupplierCode  string                 `protobuf:"bytes,3,opt,name=supplier_code,json=supplierCode,proto3" json:"supplier_code,omitempty"`
+	SerialNumber  string                 `protobuf:"bytes,4,opt,name=serial_number,json=serialNumber,proto3" json:"serial_number,omitempty"`
+	Attempts      []*FulfillmentAttempt  `protobuf:"bytes,5,rep,name=attempts,proto3" json:"attempts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FulfillOrderRes) Reset() {
+	*x = FulfillOrderRes{}
+	mi := &file_product_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FulfillOrderRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FulfillOrderRes) ProtoMessage() {}
+
+func (x *FulfillOrderRes) ProtoReflect() protoreflect.Message {
+	mi := &file_product_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FulfillOrderRes.ProtoReflect.Descriptor instead.
+func (*FulfillOrderRes) Descriptor() ([]byte, []int) {
+	return file_product_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *FulfillOrderRes) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *FulfillOrderRes) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+func (x *FulfillOrderRes) GetSupplierCode() string {
+	if x != nil {
+		return x.SupplierCode
+	}
+	return ""
+}
+
+func (x *FulfillOrderRes) GetSerialNumber() string {
+	if x != nil {
+		return x.SerialNumber
+	}
+	return ""
+}
+
+func (x *FulfillOrderRes) GetAttempts() []*FulfillmentAttempt {
+	if x != nil {
+		return x.Attempts
+	}
+	return nil
+}
+
 var File_product_proto protoreflect.FileDescriptor
 
 const file_product_proto_rawDesc = "" +
@@ -520,7 +1228,7 @@ const file_product_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\x80\x02\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xa9\x02\n" +
 	"\vProductType\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x15\n" +
 	"\x06ref_id\x18\x02 \x01(\tR\x05refId\x12\x1f\n" +
@@ -532,7 +1240,8 @@ const file_product_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xb7\x02\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12'\n" +
+	"\x0finquiry_enabled\x18\b \x01(\bR\x0einquiryEnabled\"\x85\x05\n" +
 	"\aProduct\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x15\n" +
 	"\x06ref_id\x18\x02 \x01(\tR\x05refId\x12&\n" +
@@ -544,14 +1253,75 @@ const file_product_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"2\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1f\n" +
+	"\voperator_id\x18\n" +
+	" \x01(\x05R\n" +
+	"operatorId\x12\x1d\n" +
+	"\n" +
+	"base_price\x18\v \x01(\x05R\tbasePrice\x12=\n" +
+	"\x1bloyalty_points_per_thousand\x18\f \x01(\x05R\x18loyaltyPointsPerThousand\x12\x1c\n" +
+	"\tavailable\x18\r \x01(\bR\tavailable\x12/\n" +
+	"\x13availability_status\x18\x0e \x01(\tR\x12availabilityStatus\x12#\n" +
+	"\rstock_tracked\x18\x0f \x01(\bR\fstockTracked\x12\x14\n" +
+	"\x05stock\x18\x10 \x01(\x05R\x05stock\x12C\n" +
+	"\x0fmaintenance_end\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\x0emaintenanceEnd\"F\n" +
 	"\x11GetProductByIdReq\x12\x1d\n" +
 	"\n" +
-	"product_id\x18\x01 \x01(\x05R\tproductId\"B\n" +
+	"product_id\x18\x01 \x01(\x05R\tproductId\x12\x12\n" +
+	"\x04tier\x18\x02 \x01(\tR\x04tier\"B\n" +
 	"\x11GetProductByIdRes\x12-\n" +
-	"\aproduct\x18\x01 \x01(\v2\x13.product.v1.ProductR\aproduct2`\n" +
+	"\aproduct\x18\x01 \x01(\v2\x13.product.v1.ProductR\aproduct\"?\n" +
+	"\x15GetProductTypeByIdReq\x12&\n" +
+	"\x0fproduct_type_id\x18\x01 \x01(\x05R\rproductTypeId\"S\n" +
+	"\x15GetProductTypeByIdRes\x12:\n" +
+	"\fproduct_type\x18\x01 \x01(\v2\x17.product.v1.ProductTypeR\vproductType\"Q\n" +
+	"\x16MatchOperatorPrefixReq\x12\x1f\n" +
+	"\voperator_id\x18\x01 \x01(\x05R\n" +
+	"operatorId\x12\x16\n" +
+	"\x06msisdn\x18\x02 \x01(\tR\x06msisdn\"\xb4\x01\n" +
+	"\x16MatchOperatorPrefixRes\x12\x18\n" +
+	"\aapplies\x18\x01 \x01(\bR\aapplies\x12\x18\n" +
+	"\amatches\x18\x02 \x01(\bR\amatches\x120\n" +
+	"\x14detected_operator_id\x18\x03 \x01(\x05R\x12detectedOperatorId\x124\n" +
+	"\x16detected_operator_name\x18\x04 \x01(\tR\x14detectedOperatorName\"K\n" +
+	"\x0fReserveStockReq\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\x05R\tproductId\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\"T\n" +
+	"\x0fReserveStockRes\x12#\n" +
+	"\rstock_tracked\x18\x01 \x01(\bR\fstockTracked\x12\x1c\n" +
+	"\tremaining\x18\x02 \x01(\x05R\tremaining\",\n" +
+	"\x0fReleaseStockReq\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\"-\n" +
+	"\x0fReleaseStockRes\x12\x1a\n" +
+	"\breleased\x18\x01 \x01(\bR\breleased\"\x8a\x01\n" +
+	"\x0fFulfillOrderReq\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x02 \x01(\x05R\tproductId\x12 \n" +
+	"\vdestination\x18\x03 \x01(\tR\vdestination\x12\x1b\n" +
+	"\tserver_id\x18\x04 \x01(\tR\bserverId\"\xc5\x01\n" +
+	"\x12FulfillmentAttempt\x12\x1f\n" +
+	"\vsupplier_id\x18\x01 \x01(\x05R\n" +
+	"supplierId\x12#\n" +
+	"\rsupplier_code\x18\x02 \x01(\tR\fsupplierCode\x12!\n" +
+	"\fsupplier_sku\x18\x03 \x01(\tR\vsupplierSku\x12\x12\n" +
+	"\x04cost\x18\x04 \x01(\x05R\x04cost\x12\x18\n" +
+	"\aoutcome\x18\x05 \x01(\tR\aoutcome\x12\x18\n" +
+	"\amessage\x18\x06 \x01(\tR\amessage\"\xc7\x01\n" +
+	"\x0fFulfillOrderRes\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x16\n" +
+	"\x06policy\x18\x02 \x01(\tR\x06policy\x12#\n" +
+	"\rsupplier_code\x18\x03 \x01(\tR\fsupplierCode\x12#\n" +
+	"\rserial_number\x18\x04 \x01(\tR\fserialNumber\x12:\n" +
+	"\battempts\x18\x05 \x03(\v2\x1e.product.v1.FulfillmentAttemptR\battempts2\xf9\x03\n" +
 	"\x0eProductService\x12N\n" +
-	"\x0eGetProductById\x12\x1d.product.v1.GetProductByIdReq\x1a\x1d.product.v1.GetProductByIdResBCZAgithub.com/akmmp241/topupstore-microservice/product-proto/v1;prpbb\x06proto3"
+	"\x0eGetProductById\x12\x1d.product.v1.GetProductByIdReq\x1a\x1d.product.v1.GetProductByIdRes\x12Z\n" +
+	"\x12GetProductTypeById\x12!.product.v1.GetProductTypeByIdReq\x1a!.product.v1.GetProductTypeByIdRes\x12]\n" +
+	"\x13MatchOperatorPrefix\x12\".product.v1.MatchOperatorPrefixReq\x1a\".product.v1.MatchOperatorPrefixRes\x12H\n" +
+	"\fReserveStock\x12\x1b.product.v1.ReserveStockReq\x1a\x1b.product.v1.ReserveStockRes\x12H\n" +
+	"\fReleaseStock\x12\x1b.product.v1.ReleaseStockReq\x1a\x1b.product.v1.ReleaseStockRes\x12H\n" +
+	"\fFulfillOrder\x12\x1b.product.v1.FulfillOrderReq\x1a\x1b.product.v1.FulfillOrderResBCZAgithub.com/akmmp241/topupstore-microservice/product-proto/v1;prpbb\x06proto3"
 
 var (
 	file_product_proto_rawDescOnce sync.Once
@@ -565,33 +1335,57 @@ func file_product_proto_rawDescGZIP() []byte {
 	return file_product_proto_rawDescData
 }
 
-var file_product_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_product_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_product_proto_goTypes = []any{
-	(*Category)(nil),              // 0: product.v1.Category
-	(*Operator)(nil),              // 1: product.v1.Operator
-	(*ProductType)(nil),           // 2: product.v1.ProductType
-	(*Product)(nil),               // 3: product.v1.Product
-	(*GetProductByIdReq)(nil),     // 4: product.v1.GetProductByIdReq
-	(*GetProductByIdRes)(nil),     // 5: product.v1.GetProductByIdRes
-	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+	(*Category)(nil),               // 0: product.v1.Category
+	(*Operator)(nil),               // 1: product.v1.Operator
+	(*ProductType)(nil),            // 2: product.v1.ProductType
+	(*Product)(nil),                // 3: product.v1.Product
+	(*GetProductByIdReq)(nil),      // 4: product.v1.GetProductByIdReq
+	(*GetProductByIdRes)(nil),      // 5: product.v1.GetProductByIdRes
+	(*GetProductTypeByIdReq)(nil),  // 6: product.v1.GetProductTypeByIdReq
+	(*GetProductTypeByIdRes)(nil),  // 7: product.v1.GetProductTypeByIdRes
+	(*MatchOperatorPrefixReq)(nil), // 8: product.v1.MatchOperatorPrefixReq
+	(*MatchOperatorPrefixRes)(nil), // 9: product.v1.MatchOperatorPrefixRes
+	(*ReserveStockReq)(nil),        // 10: product.v1.ReserveStockReq
+	(*ReserveStockRes)(nil),        // 11: product.v1.ReserveStockRes
+	(*ReleaseStockReq)(nil),        // 12: product.v1.ReleaseStockReq
+	(*ReleaseStockRes)(nil),        // 13: product.v1.ReleaseStockRes
+	(*FulfillOrderReq)(nil),        // 14: product.v1.FulfillOrderReq
+	(*FulfillmentAttempt)(nil),     // 15: product.v1.FulfillmentAttempt
+	(*FulfillOrderRes)(nil),        // 16: product.v1.FulfillOrderRes
+	(*timestamppb.Timestamp)(nil),  // 17: google.protobuf.Timestamp
 }
 var file_product_proto_depIdxs = []int32{
-	6,  // 0: product.v1.Category.created_at:type_name -> google.protobuf.Timestamp
-	6,  // 1: product.v1.Category.updated_at:type_name -> google.protobuf.Timestamp
-	6,  // 2: product.v1.Operator.created_at:type_name -> google.protobuf.Timestamp
-	6,  // 3: product.v1.Operator.updated_at:type_name -> google.protobuf.Timestamp
-	6,  // 4: product.v1.ProductType.created_at:type_name -> google.protobuf.Timestamp
-	6,  // 5: product.v1.ProductType.updated_at:type_name -> google.protobuf.Timestamp
-	6,  // 6: product.v1.Product.created_at:type_name -> google.protobuf.Timestamp
-	6,  // 7: product.v1.Product.updated_at:type_name -> google.protobuf.Timestamp
-	3,  // 8: product.v1.GetProductByIdRes.product:type_name -> product.v1.Product
-	4,  // 9: product.v1.ProductService.GetProductById:input_type -> product.v1.GetProductByIdReq
-	5,  // 10: product.v1.ProductService.GetProductById:output_type -> product.v1.GetProductByIdRes
-	10, // [10:11] is the sub-list for method output_type
-	9,  // [9:10] is the sub-list for method input_type
-	9,  // [9:9] is the sub-list for extension type_name
-	9,  // [9:9] is the sub-list for extension extendee
-	0,  // [0:9] is the sub-list for field type_name
+	17, // 0: product.v1.Category.created_at:type_name -> google.protobuf.Timestamp
+	17, // 1: product.v1.Category.updated_at:type_name -> google.protobuf.Timestamp
+	17, // 2: product.v1.Operator.created_at:type_name -> google.protobuf.Timestamp
+	17, // 3: product.v1.Operator.updated_at:type_name -> google.protobuf.Timestamp
+	17, // 4: product.v1.ProductType.created_at:type_name -> google.protobuf.Timestamp
+	17, // 5: product.v1.ProductType.updated_at:type_name -> google.protobuf.Timestamp
+	17, // 6: product.v1.Product.created_at:type_name -> google.protobuf.Timestamp
+	17, // 7: product.v1.Product.updated_at:type_name -> google.protobuf.Timestamp
+	17, // 8: product.v1.Product.maintenance_end:type_name -> google.protobuf.Timestamp
+	3,  // 9: product.v1.GetProductByIdRes.product:type_name -> product.v1.Product
+	2,  // 10: product.v1.GetProductTypeByIdRes.product_type:type_name -> product.v1.ProductType
+	15, // 11: product.v1.FulfillOrderRes.attempts:type_name -> product.v1.FulfillmentAttempt
+	4,  // 12: product.v1.ProductService.GetProductById:input_type -> product.v1.GetProductByIdReq
+	6,  // 13: product.v1.ProductService.GetProductTypeById:input_type -> product.v1.GetProductTypeByIdReq
+	8,  // 14: product.v1.ProductService.MatchOperatorPrefix:input_type -> product.v1.MatchOperatorPrefixReq
+	10, // 15: product.v1.ProductService.ReserveStock:input_type -> product.v1.ReserveStockReq
+	12, // 16: product.v1.ProductService.ReleaseStock:input_type -> product.v1.ReleaseStockReq
+	14, // 17: product.v1.ProductService.FulfillOrder:input_type -> product.v1.FulfillOrderReq
+	5,  // 18: product.v1.ProductService.GetProductById:output_type -> product.v1.GetProductByIdRes
+	7,  // 19: product.v1.ProductService.GetProductTypeById:output_type -> product.v1.GetProductTypeByIdRes
+	9,  // 20: product.v1.ProductService.MatchOperatorPrefix:output_type -> product.v1.MatchOperatorPrefixRes
+	11, // 21: product.v1.ProductService.ReserveStock:output_type -> product.v1.ReserveStockRes
+	13, // 22: product.v1.ProductService.ReleaseStock:output_type -> product.v1.ReleaseStockRes
+	16, // 23: product.v1.ProductService.FulfillOrder:output_type -> product.v1.FulfillOrderRes
+	18, // [18:24] is the sub-list for method output_type
+	12, // [12:18] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
 }
 
 func init() { file_product_proto_init() }
@@ -605,7 +1399,7 @@ func file_product_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_product_proto_rawDesc), len(file_product_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   17,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,7 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ProductService_GetProductById_FullMethodName = "/product.v1.ProductService/GetProductById"
+	ProductService_GetProductById_FullMethodName      = "/product.v1.ProductService/GetProductById"
+	ProductService_GetProductTypeById_FullMethodName  = "/product.v1.ProductService/GetProductTypeById"
+	ProductService_MatchOperatorPrefix_FullMethodName = "/product.v1.ProductService/MatchOperatorPrefix"
+	ProductService_ReserveStock_FullMethodName        = "/product.v1.ProductService/ReserveStock"
+	ProductService_ReleaseStock_FullMethodName        = "/product.v1.ProductService/ReleaseStock"
+	ProductService_FulfillOrder_FullMethodName        = "/product.v1.ProductService/FulfillOrder"
 )
 
 // ProductServiceClient is the client API for ProductService service.
@@ -27,6 +32,11 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type ProductServiceClient interface {
 	GetProductById(ctx context.Context, in *GetProductByIdReq, opts ...grpc.CallOption) (*GetProductByIdRes, error)
+	GetProductTypeById(ctx context.Context, in *GetProductTypeByIdReq, opts ...grpc.CallOption) (*GetProductTypeByIdRes, error)
+	MatchOperatorPrefix(ctx context.Context, in *MatchOperatorPrefixReq, opts ...grpc.CallOption) (*MatchOperatorPrefixRes, error)
+	ReserveStock(ctx context.Context, in *ReserveStockReq, opts ...grpc.CallOption) (*ReserveStockRes, error)
+	ReleaseStock(ctx context.Context, in *ReleaseStockReq, opts ...grpc.CallOption) (*ReleaseStockRes, error)
+	FulfillOrder(ctx context.Context, in *FulfillOrderReq, opts ...grpc.CallOption) (*FulfillOrderRes, error)
 }
 
 type productServiceClient struct {
@@ -47,11 +57,66 @@ func (c *productServiceClient) GetProductById(ctx context.Context, in *GetProduc
 	return out, nil
 }
 
+func (c *productServiceClient) GetProductTypeById(ctx context.Context, in *GetProductTypeByIdReq, opts ...grpc.CallOption) (*GetProductTypeByIdRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetProductTypeByIdRes)
+	err := c.cc.Invoke(ctx, ProductService_GetProductTypeById_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productServiceClient) MatchOperatorPrefix(ctx context.Context, in *MatchOperatorPrefixReq, opts ...grpc.CallOption) (*MatchOperatorPrefixRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MatchOperatorPrefixRes)
+	err := c.cc.Invoke(ctx, ProductService_MatchOperatorPrefix_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productServiceClient) ReserveStock(ctx context.Context, in *ReserveStockReq, opts ...grpc.CallOption) (*ReserveStockRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReserveStockRes)
+	err := c.cc.Invoke(ctx, ProductService_ReserveStock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productServiceClient) ReleaseStock(ctx context.Context, in *ReleaseStockReq, opts ...grpc.CallOption) (*ReleaseStockRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReleaseStockRes)
+	err := c.cc.Invoke(ctx, ProductService_ReleaseStock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *productServiceClient) FulfillOrder(ctx context.Context, in *FulfillOrderReq, opts ...grpc.CallOption) (*FulfillOrderRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FulfillOrderRes)
+	err := c.cc.Invoke(ctx, ProductService_FulfillOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ProductServiceServer is the server API for ProductService service.
 // All implementations must embed UnimplementedProductServiceServer
 // for forward compatibility.
 type ProductServiceServer interface {
 	GetProductById(context.Context, *GetProductByIdReq) (*GetProductByIdRes, error)
+	GetProductTypeById(context.Context, *GetProductTypeByIdReq) (*GetProductTypeByIdRes, error)
+	MatchOperatorPrefix(context.Context, *MatchOperatorPrefixReq) (*MatchOperatorPrefixRes, error)
+	ReserveStock(context.Context, *ReserveStockReq) (*ReserveStockRes, error)
+	ReleaseStock(context.Context, *ReleaseStockReq) (*ReleaseStockRes, error)
+	FulfillOrder(context.Context, *FulfillOrderReq) (*FulfillOrderRes, error)
 	mustEmbedUnimplementedProductServiceServer()
 }
 
@@ -65,6 +130,21 @@ type UnimplementedProductServiceServer struct{}
 func (UnimplementedProductServiceServer) GetProductById(context.Context, *GetProductByIdReq) (*GetProductByIdRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetProductById not implemented")
 }
+func (UnimplementedProductServiceServer) GetProductTypeById(context.Context, *GetProductTypeByIdReq) (*GetProductTypeByIdRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProductTypeById not implemented")
+}
+func (UnimplementedProductServiceServer) MatchOperatorPrefix(context.Context, *MatchOperatorPrefixReq) (*MatchOperatorPrefixRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MatchOperatorPrefix not implemented")
+}
+func (UnimplementedProductServiceServer) ReserveStock(context.Context, *ReserveStockReq) (*ReserveStockRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReserveStock not implemented")
+}
+func (UnimplementedProductServiceServer) ReleaseStock(context.Context, *ReleaseStockReq) (*ReleaseStockRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseStock not implemented")
+}
+func (UnimplementedProductServiceServer) FulfillOrder(context.Context, *FulfillOrderReq) (*FulfillOrderRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FulfillOrder not implemented")
+}
 func (UnimplementedProductServiceServer) mustEmbedUnimplementedProductServiceServer() {}
 func (UnimplementedProductServiceServer) testEmbeddedByValue()                        {}
 
@@ -104,6 +184,96 @@ func _ProductService_GetProductById_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ProductService_GetProductTypeById_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProductTypeByIdReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServiceServer).GetProductTypeById(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductService_GetProductTypeById_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServiceServer).GetProductTypeById(ctx, req.(*GetProductTypeByIdReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductService_MatchOperatorPrefix_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MatchOperatorPrefixReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServiceServer).MatchOperatorPrefix(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductService_MatchOperatorPrefix_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServiceServer).MatchOperatorPrefix(ctx, req.(*MatchOperatorPrefixReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductService_ReserveStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReserveStockReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServiceServer).ReserveStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductService_ReserveStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServiceServer).ReserveStock(ctx, req.(*ReserveStockReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductService_ReleaseStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseStockReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServiceServer).ReleaseStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductService_ReleaseStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServiceServer).ReleaseStock(ctx, req.(*ReleaseStockReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ProductService_FulfillOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FulfillOrderReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProductServiceServer).FulfillOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ProductService_FulfillOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProductServiceServer).FulfillOrder(ctx, req.(*FulfillOrderReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ProductService_ServiceDesc is the grpc.ServiceDesc for ProductService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -115,6 +285,26 @@ var ProductService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetProductById",
 			Handler:    _ProductService_GetProductById_Handler,
 		},
+		{
+			MethodName: "GetProductTypeById",
+			Handler:    _ProductService_GetProductTypeById_Handler,
+		},
+		{
+			MethodName: "MatchOperatorPrefix",
+			Handler:    _ProductService_MatchOperatorPrefix_Handler,
+		},
+		{
+			MethodName: "ReserveStock",
+			Handler:    _ProductService_ReserveStock_Handler,
+		},
+		{
+			MethodName: "ReleaseStock",
+			Handler:    _ProductService_ReleaseStock_Handler,
+		},
+		{
+			MethodName: "FulfillOrder",
+			Handler:    _ProductService_FulfillOrder_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "product.proto",
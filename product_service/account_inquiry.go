@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	InquiryProviderFake = "fake"
+	InquiryProviderHttp = "http"
+)
+
+const (
+	inquiryCacheExpiry    = 10 * time.Minute
+	inquiriesPerClient    = 10
+	inquiryLimitWindow    = time.Minute
+	inquiryRequestTimeout = 10 * time.Second
+)
+
+var errAccountNotFound = errors.New("account not found")
+
+// AccountInquirer resolves a game account to its nickname. code is how the
+// provider knows the operator, operators.inquiry_code.
+type AccountInquirer interface {
+	Inquire(ctx context.Context, code string, destination string, serverId string) (string, error)
+}
+
+// NewAccountInquirers builds one inquirer per provider name an operator can
+// have in operators.inquiry_provider.
+func NewAccountInquirers() map[string]AccountInquirer {
+	return map[string]AccountInquirer{
+		InquiryProviderFake: &FakeAccountInquirer{},
+		InquiryProviderHttp: &HttpAccountInquirer{
+			Url:   os.Getenv("ACCOUNT_INQUIRY_URL"),
+			Token: os.Getenv("ACCOUNT_INQUIRY_TOKEN"),
+		},
+	}
+}
+
+// FakeAccountInquirer is the local fake. Every account exists except ids
+// starting with 0000, so both outcomes can be tried without a provider.
+type FakeAccountInquirer struct{}
+
+func (f *FakeAccountInquirer) Inquire(_ context.Context, code string, destination string, serverId string) (string, error) {
+	if strings.HasPrefix(destination, "0000") {
+		return "", errAccountNotFound
+	}
+
+	suffix := destination
+	if len(suffix) > 4 {
+		suffix = suffix[len(suffix)-4:]
+	}
+
+	return fmt.Sprintf("%s-player-%s", code, suffix), nil
+}
+
+// HttpAccountInquirer asks an inquiry gateway, which answers 404 for unknown
+// accounts and {"nickname": "..."} otherwise.
+type HttpAccountInquirer struct {
+	Url   string
+	Token string
+}
+
+func (h *HttpAccountInquirer) Inquire(ctx context.Context, code string, destination string, serverId string) (string, error) {
+	reqBody, err := json.Marshal(fiber.Map{
+		"code":        code,
+		"destination": destination,
+		"server_id":   serverId,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, inquiryRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.Url, bytes.NewReader(reqBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if h.Token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+h.Token)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	statusCode := res.StatusCode
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if statusCode == http.StatusNotFound {
+		return "", errAccountNotFound
+	}
+	if statusCode < 200 || statusCode >= 300 {
+		return "", fmt.Errorf("inquiry gateway returned status %d: %s", statusCode, body)
+	}
+
+	var inquiryRes struct {
+		Nickname string `json:"nickname"`
+	}
+	if err := json.Unmarshal(body, &inquiryRes); err != nil {
+		return "", err
+	}
+	if inquiryRes.Nickname == "" {
+		return "", errAccountNotFound
+	}
+
+	return inquiryRes.Nickname, nil
+}
+
+func (p *ProductService) handleAccountInquiry(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product type ID")
+	}
+
+	inquiryRequest := &AccountInquiryRequest{}
+	if err := c.BodyParser(inquiryRequest); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err = p.validate.Struct(inquiryRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*inquiryRequest, err.(validator.ValidationErrors))
+	}
+
+	destination := strings.TrimSpace(inquiryRequest.Destination)
+	serverId := strings.TrimSpace(inquiryRequest.ServerId)
+
+	var provider, code sql.NullString
+	query := `SELECT o.inquiry_provider, o.inquiry_code FROM product_types pt JOIN operators o ON o.id = pt.operator_id
+				WHERE pt.id = ? AND pt.deleted_at IS NULL`
+	err = p.DB.QueryRowContext(c.Context(), query, id).Scan(&provider, &code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fiber.NewError(fiber.StatusNotFound, "Product type not found")
+		}
+		slog.Error("Failed to query product type", "error", err)
+		return err
+	}
+
+	inquirer, ok := p.Inquirers[provider.String]
+	if !ok {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "Account inquiry is not available for this product")
+	}
+
+	cacheKey := fmt.Sprintf("account-inquiry:%d:%s:%s", id, destination, serverId)
+	nickname, err := p.RedisClient.Get(c.Context(), cacheKey).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		slog.Error("Failed to read account inquiry cache", "error", err)
+	}
+
+	if nickname == "" {
+		// only calls that reach the provider count, cached answers are free
+		allowed, err := shared.AllowRequest(c.Context(), p.RedisClient, "account-inquiry:"+c.IP(), inquiriesPerClient, inquiryLimitWindow)
+		if err != nil {
+			slog.Error("Failed to check rate limit", "error", err)
+			return err
+		}
+		if !allowed {
+			return fiber.NewError(fiber.StatusTooManyRequests, "Too many inquiries, please try again later")
+		}
+
+		nickname, err = inquirer.Inquire(c.Context(), code.String, destination, serverId)
+		if errors.Is(err, errAccountNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, "Account not found, please check the ID and server")
+		}
+		if err != nil {
+			slog.Error("Failed to inquire account", "provider", provider.String, "error", err)
+			return fiber.NewError(fiber.StatusBadGateway, "Account inquiry is unavailable, please try again later")
+		}
+
+		if err := p.RedisClient.SetEx(c.Context(), cacheKey, nickname, inquiryCacheExpiry).Err(); err != nil {
+			slog.Error("Failed to write account inquiry cache", "error", err)
+		}
+	}
+
+	token, expiresAt, err := shared.NewInquiryToken(id, destination, serverId, nickname)
+	if err != nil {
+		slog.Error("Failed to sign inquiry token", "error", err)
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Account found",
+		"data": fiber.Map{
+			"nickname":      nickname,
+			"inquiry_token": token,
+			"expires_at":    expiresAt,
+		},
+		"errors": nil,
+	})
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	StatusActive      = "active"
+	StatusInactive    = "inactive"
+	StatusOutOfStock  = "out_of_stock"
+	StatusMaintenance = "maintenance"
+)
+
+// availabilityColumns are scanned by availabilityRow, the query has to join
+// the operator of the product as o.
+const availabilityColumns = "p.status, p.stock, p.maintenance_start, p.maintenance_end, o.status, o.maintenance_start, o.maintenance_end"
+
+type Availability struct {
+	Available bool   `json:"available"`
+	Status    string `json:"status"`
+	// Stock is left out for products without stock tracking
+	Stock          *int       `json:"stock,omitempty"`
+	MaintenanceEnd *time.Time `json:"maintenance_end,omitempty"`
+}
+
+type availabilityRow struct {
+	productStatus  string
+	stock          sql.NullInt64
+	productStart   sql.NullTime
+	productEnd     sql.NullTime
+	operatorStatus string
+	operatorStart  sql.NullTime
+	operatorEnd    sql.NullTime
+}
+
+func (a *availabilityRow) scanTargets() []any {
+	return []any{&a.productStatus, &a.stock, &a.productStart, &a.productEnd, &a.operatorStatus, &a.operatorStart, &a.operatorEnd}
+}
+
+// inMaintenance reports whether now is inside the window, an open start or
+// end means the window started already or has no planned end.
+func inMaintenance(now time.Time, start sql.NullTime, end sql.NullTime) bool {
+	return (!start.Valid || !now.Before(start.Time)) && (!end.Valid || now.Before(end.Time))
+}
+
+// resolve decides whether the product can be bought now. Operator status
+// goes before product status, stock is checked last.
+func (a *availabilityRow) resolve(now time.Time) *Availability {
+	availability := &Availability{Status: StatusActive}
+	if a.stock.Valid {
+		stock := int(a.stock.Int64)
+		availability.Stock = &stock
+	}
+
+	checks := []struct {
+		status string
+		start  sql.NullTime
+		end    sql.NullTime
+	}{
+		{a.operatorStatus, a.operatorStart, a.operatorEnd},
+		{a.productStatus, a.productStart, a.productEnd},
+	}
+
+	for _, check := range checks {
+		switch check.status {
+		case StatusInactive, StatusOutOfStock:
+			availability.Status = check.status
+			return availability
+		case StatusMaintenance:
+			if inMaintenance(now, check.start, check.end) {
+				availability.Status = StatusMaintenance
+				if check.end.Valid {
+					availability.MaintenanceEnd = &check.end.Time
+				}
+				return availability
+			}
+		}
+	}
+
+	if a.stock.Valid && a.stock.Int64 <= 0 {
+		availability.Status = StatusOutOfStock
+		return availability
+	}
+
+	availability.Available = true
+	return availability
+}
+
+func parseAvailabilityRequest(c *fiber.Ctx, validate *validator.Validate) (*AvailabilityRequest, error) {
+	availabilityRequest := &AvailabilityRequest{}
+	if err := c.BodyParser(availabilityRequest); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err := validate.Struct(availabilityRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return nil, shared.NewFailedValidationError(*availabilityRequest, err.(validator.ValidationErrors))
+	}
+
+	if availabilityRequest.Status != StatusMaintenance && (availabilityRequest.MaintenanceStart != nil || availabilityRequest.MaintenanceEnd != nil) {
+		return nil, fiber.NewError(fiber.StatusUnprocessableEntity, "Maintenance times are only for the maintenance status")
+	}
+	if availabilityRequest.MaintenanceStart != nil && availabilityRequest.MaintenanceEnd != nil &&
+		!availabilityRequest.MaintenanceEnd.After(*availabilityRequest.MaintenanceStart) {
+		return nil, fiber.NewError(fiber.StatusUnprocessableEntity, "Maintenance end must be after its start")
+	}
+
+	return availabilityRequest, nil
+}
+
+// handleUpdateProductAvailability sets the status of a product. Leaving the
+// stock out turns stock tracking off.
+func (p *ProductService) handleUpdateProductAvailability(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	availabilityRequest, err := parseAvailabilityRequest(c, p.validate)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE products SET status = ?, stock = ?, maintenance_start = ?, maintenance_end = ?, updated_by = ? WHERE id = ? AND deleted_at IS NULL"
+	result, err := p.DB.ExecContext(c.Context(), query, availabilityRequest.Status, availabilityRequest.Stock,
+		availabilityRequest.MaintenanceStart, availabilityRequest.MaintenanceEnd, shared.GetUserClaims(c).Subject, id)
+	if err != nil {
+		slog.Error("Failed to update product availability", "error", err)
+		return err
+	}
+
+	// no changed rows is also what an unchanged status gives
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		if err := p.requireActive(c.Context(), "products", id, fiber.StatusNotFound, "Product not found"); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Product availability updated", "product-id", id, "status", availabilityRequest.Status, "by", shared.GetUserClaims(c).Subject)
+
+	p.syncListedProducts(c.Context(), "p.id = ?", id)
+
+	return c.JSON(fiber.Map{
+		"message": "Product availability updated successfully",
+		"data":    availabilityRequest,
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleUpdateOperatorAvailability(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid operator ID")
+	}
+
+	availabilityRequest, err := parseAvailabilityRequest(c, p.validate)
+	if err != nil {
+		return err
+	}
+
+	if availabilityRequest.Stock != nil {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "Stock is counted per product")
+	}
+
+	query := "UPDATE operators SET status = ?, maintenance_start = ?, maintenance_end = ?, updated_by = ? WHERE id = ? AND deleted_at IS NULL"
+	result, err := p.DB.ExecContext(c.Context(), query, availabilityRequest.Status,
+		availabilityRequest.MaintenanceStart, availabilityRequest.MaintenanceEnd, shared.GetUserClaims(c).Subject, id)
+	if err != nil {
+		slog.Error("Failed to update operator availability", "error", err)
+		return err
+	}
+
+	// no changed rows is also what an unchanged status gives
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		if err := p.requireActive(c.Context(), "operators", id, fiber.StatusNotFound, "Operator not found"); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Operator availability updated", "operator-id", id, "status", availabilityRequest.Status, "by", shared.GetUserClaims(c).Subject)
+
+	p.syncListedProducts(c.Context(), "o.id = ?", id)
+
+	return c.JSON(fiber.Map{
+		"message": "Operator availability updated successfully",
+		"data":    availabilityRequest,
+		"errors":  nil,
+	})
+}
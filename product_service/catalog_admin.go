@@ -0,0 +1,518 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofiber/fiber/v2"
+)
+
+const ProductIndexerTopic = "product-indexer"
+
+const (
+	ProductCreated = "product-created"
+	ProductUpdated = "product-updated"
+	ProductDeleted = "product-deleted"
+)
+
+// ProductIndexerEvent carries a product in the shape of the search index,
+// indexer service writes Data as the document.
+type ProductIndexerEvent struct {
+	EventType string         `json:"event_type"`
+	Data      *ProductSearch `json:"data"`
+}
+
+// listedProductsCondition keeps products that are not active, or whose
+// operator is not, out of the listings and the search index.
+const listedProductsCondition = "p.status = 'active' AND o.status = 'active'"
+
+const indexedProductsQuery = `
+	SELECT
+		p.id, p.name, p.image_url, p.price, p.description, p.created_at,
+		pt.id, pt.name,
+		o.id, o.name, o.slug, o.image_url,
+		c.id, c.name
+	FROM products p
+		JOIN product_types pt ON p.product_type_id = pt.id
+		JOIN operators o ON pt.operator_id = o.id
+		JOIN categories c ON o.category_id = c.id
+	WHERE p.deleted_at IS NULL AND ` + listedProductsCondition + ` AND `
+
+func parseCatalogRequest[T any](c *fiber.Ctx, validate *validator.Validate) (*T, error) {
+	request := new(T)
+	if err := c.BodyParser(request); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err := validate.Struct(request)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return nil, shared.NewFailedValidationError(*request, err.(validator.ValidationErrors))
+	}
+
+	return request, nil
+}
+
+func catalogWriteError(err error, entity string) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1452 {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "Parent of the "+entity+" not found")
+	}
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+		return fiber.NewError(fiber.StatusConflict, "Another "+entity+" already uses this ref_id")
+	}
+
+	slog.Error("Failed to save "+entity, "error", err)
+	return err
+}
+
+// requireActive fails with status when the row is missing or soft deleted.
+func (p *ProductService) requireActive(ctx context.Context, table string, id int, status int, message string) error {
+	var exists int
+	err := p.DB.QueryRowContext(ctx, "SELECT 1 FROM "+table+" WHERE id = ? AND deleted_at IS NULL", id).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fiber.NewError(status, message)
+		}
+		slog.Error("Failed to query "+table, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// softDelete marks a row deleted once no active child row points at it, so
+// the index never keeps products whose parents are gone.
+func (p *ProductService) softDelete(c *fiber.Ctx, table string, childTable string, childColumn string, entity string) (int, error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "Invalid "+entity+" ID")
+	}
+
+	if childTable != "" {
+		var children int
+		err := p.DB.QueryRowContext(c.Context(), "SELECT COUNT(*) FROM "+childTable+" WHERE "+childColumn+" = ? AND deleted_at IS NULL", id).Scan(&children)
+		if err != nil {
+			slog.Error("Failed to count "+childTable, "error", err)
+			return 0, err
+		}
+		if children > 0 {
+			return 0, fiber.NewError(fiber.StatusConflict, "Delete the "+childTable+" of this "+entity+" first")
+		}
+	}
+
+	result, err := p.DB.ExecContext(c.Context(), "UPDATE "+table+" SET deleted_at = NOW(), updated_by = ? WHERE id = ? AND deleted_at IS NULL",
+		shared.GetUserClaims(c).Subject, id)
+	if err != nil {
+		slog.Error("Failed to delete "+entity, "error", err)
+		return 0, err
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return 0, fiber.NewError(fiber.StatusNotFound, entity+" not found")
+	}
+
+	slog.Info("Catalog entry deleted", "table", table, "id", id, "by", shared.GetUserClaims(c).Subject)
+
+	return id, nil
+}
+
+func (p *ProductService) getIndexedProducts(ctx context.Context, condition string, args ...any) ([]*ProductSearch, error) {
+	rows, err := p.DB.QueryContext(ctx, indexedProductsQuery+condition, args...)
+	if err != nil {
+		slog.Error("Failed to query products", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []*ProductSearch{}
+	for rows.Next() {
+		var product ProductSearch
+		var imageUrl, operatorImageUrl sql.NullString
+		err := rows.Scan(
+			&product.ID, &product.Name, &imageUrl, &product.Price, &product.Description, &product.CreatedAt,
+			&product.ProductType.ID, &product.ProductType.Name,
+			&product.Operator.ID, &product.Operator.Name, &product.Operator.Slug, &operatorImageUrl,
+			&product.Category.ID, &product.Category.Name,
+		)
+		if err != nil {
+			slog.Error("Failed to scan product row", "error", err)
+			return nil, err
+		}
+		product.ImageUrl = imageUrl.String
+		product.Operator.ImageUrl = operatorImageUrl.String
+
+		products = append(products, &product)
+	}
+
+	return products, rows.Err()
+}
+
+// publishProductEvents keeps the search index in sync. The database write
+// already happened, a failed publish is logged and fixed by a full reindex.
+func (p *ProductService) publishProductEvents(ctx context.Context, eventType string, products []*ProductSearch) {
+	if len(products) == 0 {
+		return
+	}
+
+	messages := make([][2]string, 0, len(products))
+	for _, product := range products {
+		eventBytes, err := json.Marshal(ProductIndexerEvent{EventType: eventType, Data: product})
+		if err != nil {
+			slog.Error("Error occurred while marshalling message", "err", err)
+			return
+		}
+		messages = append(messages, [2]string{strconv.Itoa(product.ID), string(eventBytes)})
+	}
+
+	if err := p.Producer.Write(ctx, ProductIndexerTopic, messages...); err != nil {
+		slog.Error("Error occurred while sending product indexer events", "event", eventType, "count", len(messages), "err", err)
+	}
+}
+
+// reindexProducts sends the products matching condition again, for changes
+// to the category, operator or product type copied into their documents.
+func (p *ProductService) reindexProducts(ctx context.Context, condition string, args ...any) {
+	products, err := p.getIndexedProducts(ctx, condition, args...)
+	if err != nil {
+		return
+	}
+
+	p.publishProductEvents(ctx, ProductUpdated, products)
+}
+
+// syncListedProducts follows an availability change of the products matching
+// condition, listed ones are indexed again and the others removed.
+func (p *ProductService) syncListedProducts(ctx context.Context, condition string, args ...any) {
+	query := `SELECT p.id, ` + listedProductsCondition + ` FROM products p
+				JOIN product_types pt ON p.product_type_id = pt.id
+				JOIN operators o ON pt.operator_id = o.id
+			WHERE p.deleted_at IS NULL AND ` + condition
+	rows, err := p.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.Error("Failed to query products", "error", err)
+		return
+	}
+	defer rows.Close()
+
+	unlisted := []*ProductSearch{}
+	for rows.Next() {
+		var id int
+		var listed bool
+		if err := rows.Scan(&id, &listed); err != nil {
+			slog.Error("Failed to scan product row", "error", err)
+			return
+		}
+		if !listed {
+			unlisted = append(unlisted, &ProductSearch{ID: id})
+		}
+	}
+
+	p.publishProductEvents(ctx, ProductDeleted, unlisted)
+	p.reindexProducts(ctx, condition, args...)
+}
+
+func (p *ProductService) handleCreateCategory(c *fiber.Ctx) error {
+	request, err := parseCatalogRequest[CategoryRequest](c, p.validate)
+	if err != nil {
+		return err
+	}
+
+	result, err := p.DB.ExecContext(c.Context(), "INSERT INTO categories (ref_id, name, updated_by) VALUES (?, ?, ?)",
+		request.RefId, request.Name, shared.GetUserClaims(c).Subject)
+	if err != nil {
+		return catalogWriteError(err, "category")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Category created successfully",
+		"data":    fiber.Map{"id": id},
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleUpdateCategory(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid category ID")
+	}
+
+	request, err := parseCatalogRequest[CategoryRequest](c, p.validate)
+	if err != nil {
+		return err
+	}
+
+	if err := p.requireActive(c.Context(), "categories", id, fiber.StatusNotFound, "Category not found"); err != nil {
+		return err
+	}
+
+	_, err = p.DB.ExecContext(c.Context(), "UPDATE categories SET ref_id = ?, name = ?, updated_by = ? WHERE id = ?",
+		request.RefId, request.Name, shared.GetUserClaims(c).Subject, id)
+	if err != nil {
+		return catalogWriteError(err, "category")
+	}
+
+	p.reindexProducts(c.Context(), "c.id = ?", id)
+
+	return c.JSON(fiber.Map{
+		"message": "Category updated successfully",
+		"data":    fiber.Map{"id": id},
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleDeleteCategory(c *fiber.Ctx) error {
+	if _, err := p.softDelete(c, "categories", "operators", "category_id", "Category"); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Category deleted successfully",
+		"data":    nil,
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleCreateOperator(c *fiber.Ctx) error {
+	request, err := parseCatalogRequest[OperatorRequest](c, p.validate)
+	if err != nil {
+		return err
+	}
+
+	if err := p.requireActive(c.Context(), "categories", request.CategoryId, fiber.StatusUnprocessableEntity, "Category not found"); err != nil {
+		return err
+	}
+
+	result, err := p.DB.ExecContext(c.Context(), "INSERT INTO operators (ref_id, category_id, name, slug, image_url, description, inquiry_provider, inquiry_code, updated_by) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		request.RefId, request.CategoryId, request.Name, request.Slug, request.ImageUrl, request.Description, request.InquiryProvider, request.InquiryCode, shared.GetUserClaims(c).Subject)
+	if err != nil {
+		return catalogWriteError(err, "operator")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Operator created successfully",
+		"data":    fiber.Map{"id": id},
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleUpdateOperator(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid operator ID")
+	}
+
+	request, err := parseCatalogRequest[OperatorRequest](c, p.validate)
+	if err != nil {
+		return err
+	}
+
+	if err := p.requireActive(c.Context(), "operators", id, fiber.StatusNotFound, "Operator not found"); err != nil {
+		return err
+	}
+	if err := p.requireActive(c.Context(), "categories", request.CategoryId, fiber.StatusUnprocessableEntity, "Category not found"); err != nil {
+		return err
+	}
+
+	_, err = p.DB.ExecContext(c.Context(), "UPDATE operators SET ref_id = ?, category_id = ?, name = ?, slug = ?, image_url = ?, description = ?, inquiry_provider = ?, inquiry_code = ?, updated_by = ? WHERE id = ?",
+		request.RefId, request.CategoryId, request.Name, request.Slug, request.ImageUrl, request.Description, request.InquiryProvider, request.InquiryCode, shared.GetUserClaims(c).Subject, id)
+	if err != nil {
+		return catalogWriteError(err, "operator")
+	}
+
+	p.reindexProducts(c.Context(), "o.id = ?", id)
+
+	return c.JSON(fiber.Map{
+		"message": "Operator updated successfully",
+		"data":    fiber.Map{"id": id},
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleDeleteOperator(c *fiber.Ctx) error {
+	if _, err := p.softDelete(c, "operators", "product_types", "operator_id", "Operator"); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Operator deleted successfully",
+		"data":    nil,
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleCreateProductType(c *fiber.Ctx) error {
+	request, err := parseCatalogRequest[ProductTypeRequest](c, p.validate)
+	if err != nil {
+		return err
+	}
+
+	if _, err := shared.ParseFormSchema(request.FormatForm); err != nil {
+		return shared.FailedValidationError{Errors: shared.Errors{"format_form": err.Error()}}
+	}
+	if err := p.requireActive(c.Context(), "operators", request.OperatorId, fiber.StatusUnprocessableEntity, "Operator not found"); err != nil {
+		return err
+	}
+
+	result, err := p.DB.ExecContext(c.Context(), "INSERT INTO product_types (ref_id, operator_id, name, format_form, updated_by) VALUES (?, ?, ?, ?, ?)",
+		request.RefId, request.OperatorId, request.Name, request.FormatForm, shared.GetUserClaims(c).Subject)
+	if err != nil {
+		return catalogWriteError(err, "product type")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Product type created successfully",
+		"data":    fiber.Map{"id": id},
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleUpdateProductType(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product type ID")
+	}
+
+	request, err := parseCatalogRequest[ProductTypeRequest](c, p.validate)
+	if err != nil {
+		return err
+	}
+
+	if err := p.requireActive(c.Context(), "product_types", id, fiber.StatusNotFound, "Product type not found"); err != nil {
+		return err
+	}
+	if _, err := shared.ParseFormSchema(request.FormatForm); err != nil {
+		return shared.FailedValidationError{Errors: shared.Errors{"format_form": err.Error()}}
+	}
+	if err := p.requireActive(c.Context(), "operators", request.OperatorId, fiber.StatusUnprocessableEntity, "Operator not found"); err != nil {
+		return err
+	}
+
+	_, err = p.DB.ExecContext(c.Context(), "UPDATE product_types SET ref_id = ?, operator_id = ?, name = ?, format_form = ?, updated_by = ? WHERE id = ?",
+		request.RefId, request.OperatorId, request.Name, request.FormatForm, shared.GetUserClaims(c).Subject, id)
+	if err != nil {
+		return catalogWriteError(err, "product type")
+	}
+
+	p.reindexProducts(c.Context(), "pt.id = ?", id)
+
+	return c.JSON(fiber.Map{
+		"message": "Product type updated successfully",
+		"data":    fiber.Map{"id": id},
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleDeleteProductType(c *fiber.Ctx) error {
+	if _, err := p.softDelete(c, "product_types", "products", "product_type_id", "Product type"); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Product type deleted successfully",
+		"data":    nil,
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleCreateProduct(c *fiber.Ctx) error {
+	request, err := parseCatalogRequest[ProductRequest](c, p.validate)
+	if err != nil {
+		return err
+	}
+
+	if err := p.requireActive(c.Context(), "product_types", request.ProductTypeId, fiber.StatusUnprocessableEntity, "Product type not found"); err != nil {
+		return err
+	}
+
+	result, err := p.DB.ExecContext(c.Context(), "INSERT INTO products (ref_id, product_type_id, name, description, image_url, price, updated_by) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		request.RefId, request.ProductTypeId, request.Name, request.Description, request.ImageUrl, request.Price, shared.GetUserClaims(c).Subject)
+	if err != nil {
+		return catalogWriteError(err, "product")
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	products, err := p.getIndexedProducts(c.Context(), "p.id = ?", id)
+	if err == nil {
+		p.publishProductEvents(c.Context(), ProductCreated, products)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Product created successfully",
+		"data":    fiber.Map{"id": id},
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleUpdateProduct(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	request, err := parseCatalogRequest[ProductRequest](c, p.validate)
+	if err != nil {
+		return err
+	}
+
+	if err := p.requireActive(c.Context(), "products", id, fiber.StatusNotFound, "Product not found"); err != nil {
+		return err
+	}
+	if err := p.requireActive(c.Context(), "product_types", request.ProductTypeId, fiber.StatusUnprocessableEntity, "Product type not found"); err != nil {
+		return err
+	}
+
+	_, err = p.DB.ExecContext(c.Context(), "UPDATE products SET ref_id = ?, product_type_id = ?, name = ?, description = ?, image_url = ?, price = ?, updated_by = ? WHERE id = ?",
+		request.RefId, request.ProductTypeId, request.Name, request.Description, request.ImageUrl, request.Price, shared.GetUserClaims(c).Subject, id)
+	if err != nil {
+		return catalogWriteError(err, "product")
+	}
+
+	p.reindexProducts(c.Context(), "p.id = ?", id)
+
+	return c.JSON(fiber.Map{
+		"message": "Product updated successfully",
+		"data":    fiber.Map{"id": id},
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleDeleteProduct(c *fiber.Ctx) error {
+	id, err := p.softDelete(c, "products", "", "", "Product")
+	if err != nil {
+		return err
+	}
+
+	p.publishProductEvents(c.Context(), ProductDeleted, []*ProductSearch{{ID: id}})
+
+	return c.JSON(fiber.Map{
+		"message": "Product deleted successfully",
+		"data":    nil,
+		"errors":  nil,
+	})
+}
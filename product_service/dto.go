@@ -1,5 +1,10 @@
 package main
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type CategorySearch struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
@@ -21,19 +26,116 @@ type ProductSearch struct {
 	ID          int               `json:"id"`
 	Name        string            `json:"name"`
 	Price       int               `json:"price"`
+	BasePrice   int               `json:"base_price,omitempty"`
 	ImageUrl    string            `json:"image_url"`
+	Description string            `json:"description,omitempty"`
 	Category    CategorySearch    `json:"category"`
 	Operator    OperatorSearch    `json:"operator"`
 	ProductType ProductTypeSearch `json:"product_type"`
+	CreatedAt   *time.Time        `json:"created_at,omitempty"`
+}
+
+type CategoryRequest struct {
+	RefId string `json:"ref_id" validate:"required,max=255"`
+	Name  string `json:"name" validate:"required,max=255"`
+}
+
+type OperatorRequest struct {
+	RefId       string  `json:"ref_id" validate:"required,max=255"`
+	CategoryId  int     `json:"category_id" validate:"required,gt=0"`
+	Name        string  `json:"name" validate:"required,max=255"`
+	Slug        string  `json:"slug" validate:"required,max=255"`
+	ImageUrl    *string `json:"image_url" validate:"omitempty,url,max=255"`
+	Description *string `json:"description" validate:"omitempty,max=255"`
+	// InquiryProvider turns on account inquiry for the operator
+	InquiryProvider *string `json:"inquiry_provider" validate:"omitempty,oneof=fake http"`
+	InquiryCode     *string `json:"inquiry_code" validate:"required_with=InquiryProvider,omitempty,max=64"`
+}
+
+type ProductTypeRequest struct {
+	RefId      string `json:"ref_id" validate:"required,max=255"`
+	OperatorId int    `json:"operator_id" validate:"required,gt=0"`
+	Name       string `json:"name" validate:"required,max=255"`
+	FormatForm string `json:"format_form" validate:"required"`
+}
+
+type ProductRequest struct {
+	RefId         string  `json:"ref_id" validate:"required,max=255"`
+	ProductTypeId int     `json:"product_type_id" validate:"required,gt=0"`
+	Name          string  `json:"name" validate:"required,max=255"`
+	Description   string  `json:"description" validate:"required"`
+	ImageUrl      *string `json:"image_url" validate:"omitempty,url,max=255"`
+	Price         int     `json:"price" validate:"required,gt=0"`
+}
+
+type AccountInquiryRequest struct {
+	Destination string `json:"destination" validate:"required,max=64"`
+	ServerId    string `json:"server_id" validate:"max=64"`
+}
+
+type SupplierRequest struct {
+	Code   string `json:"code" validate:"required,max=32"`
+	Name   string `json:"name" validate:"required,max=255"`
+	Status string `json:"status" validate:"required,oneof=active inactive"`
+}
+
+// SupplierCostRequest priority, higher first, orders suppliers with the same
+// cost or success rate when routing orders.
+type SupplierCostRequest struct {
+	SupplierSku string `json:"supplier_sku" validate:"required,max=64"`
+	Cost        int    `json:"cost" validate:"required,gt=0"`
+	Priority    int    `json:"priority" validate:"gte=0"`
+}
+
+// RoutingPolicyRequest with an empty policy goes back to the default policy.
+type RoutingPolicyRequest struct {
+	Policy string `json:"policy" validate:"omitempty,oneof=priority cheapest success_rate round_robin"`
+}
+
+// MarginRuleRequest value is a percentage of the cost or a fixed amount,
+// depending on type.
+type MarginRuleRequest struct {
+	Type      string  `json:"type" validate:"required,oneof=percentage fixed"`
+	Value     float64 `json:"value" validate:"gte=0"`
+	MinProfit int     `json:"min_profit" validate:"gte=0"`
+}
+
+type AvailabilityRequest struct {
+	Status           string     `json:"status" validate:"required,oneof=active inactive out_of_stock maintenance"`
+	Stock            *int       `json:"stock" validate:"omitempty,gte=0"`
+	MaintenanceStart *time.Time `json:"maintenance_start"`
+	MaintenanceEnd   *time.Time `json:"maintenance_end"`
+}
+
+type OperatorPrefixesRequest struct {
+	Prefixes []string `json:"prefixes" validate:"required,dive,numeric,min=3,max=8"`
+}
+
+type UpdateTierPricesRequest struct {
+	Prices map[string]int `json:"prices" validate:"dive,gt=0"`
+}
+
+// LoyaltyRuleRequest takes a pointer so a rate of zero, which turns earning
+// off, is told apart from a missing field.
+type LoyaltyRuleRequest struct {
+	PointsPerThousand *int `json:"points_per_thousand" validate:"required,gte=0,lte=1000"`
 }
 
 type EsResponse struct {
-	Hits struct {
+	// PitId is the point in time to continue from, it may change per page
+	PitId string `json:"pit_id"`
+	Hits  struct {
 		Total struct {
 			Value int `json:"value"`
 		} `json:"total"`
 		Hits []struct {
-			Source ProductSearch `json:"_source"`
+			Source ProductSearch     `json:"_source"`
+			Sort   []json.RawMessage `json:"sort"`
 		} `json:"hits"`
 	} `json:"hits"`
+	Aggregations struct {
+		Categories esTermsFacet     `json:"categories"`
+		Operators  esTermsFacet     `json:"operators"`
+		Prices     esHistogramFacet `json:"prices"`
+	} `json:"aggregations"`
 }
@@ -0,0 +1,480 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	prpb "github.com/akmmp241/topupstore-microservice/product-proto/v1"
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	RoutingPriority    = "priority"
+	RoutingCheapest    = "cheapest"
+	RoutingSuccessRate = "success_rate"
+	RoutingRoundRobin  = "round_robin"
+)
+
+const (
+	FulfillmentSucceeded = "succeeded"
+	FulfillmentFailed    = "failed"
+	FulfillmentUnknown   = "unknown"
+)
+
+// outcomes of a single supplier attempt
+const (
+	AttemptSucceeded = "succeeded"
+	AttemptRejected  = "rejected"
+	AttemptError     = "error"
+	AttemptSkipped   = "skipped"
+)
+
+const (
+	// breakerFailures failures within breakerWindow open the breaker of a
+	// supplier for breakerCooldown
+	breakerFailures = 5
+	breakerWindow   = 5 * time.Minute
+	breakerCooldown = time.Minute
+
+	supplierRequestTimeout = 30 * time.Second
+)
+
+// SupplierRejectedError is a hard failure, the supplier did not take the
+// order and the next supplier can be tried safely.
+type SupplierRejectedError struct {
+	Reason string
+}
+
+func (e *SupplierRejectedError) Error() string {
+	return "supplier rejected the order: " + e.Reason
+}
+
+// errGatewayUnauthorized means the gateway refused our credentials. The
+// order never reached a supplier, and every other supplier is behind the
+// same gateway, so there is nothing to fail over to.
+var errGatewayUnauthorized = errors.New("supplier gateway refused the credentials")
+
+// SupplierGateway places an order at a supplier. refId is the same for
+// every retry of an order, suppliers use it to drop duplicates.
+type SupplierGateway interface {
+	Purchase(ctx context.Context, supplierCode string, sku string, refId string, destination string, serverId string) (string, error)
+}
+
+// NewSupplierGateway picks the gateway from SUPPLIER_GATEWAY: "fake"
+// (default outside production) or "http". Production refuses to start
+// without an explicit gateway, the fake would mark paid orders delivered.
+func NewSupplierGateway() SupplierGateway {
+	switch os.Getenv("SUPPLIER_GATEWAY") {
+	case "", "fake":
+		if os.Getenv("APP_ENV") == "production" {
+			if os.Getenv("SUPPLIER_GATEWAY") == "" {
+				slog.Error("SUPPLIER_GATEWAY is not set in production")
+				panic("SUPPLIER_GATEWAY must be set in production")
+			}
+			slog.Warn("Orders are fulfilled by the fake supplier gateway, nothing is delivered")
+		}
+		failing := map[string]bool{}
+		for _, code := range strings.Split(os.Getenv("SUPPLIER_FAKE_FAILING"), ",") {
+			if code = strings.TrimSpace(code); code != "" {
+				failing[code] = true
+			}
+		}
+		return &FakeSupplierGateway{Failing: failing}
+	case "http":
+		return &HttpSupplierGateway{
+			Url:   os.Getenv("SUPPLIER_GATEWAY_URL"),
+			Token: os.Getenv("SUPPLIER_GATEWAY_TOKEN"),
+		}
+	default:
+		panic("unsupported SUPPLIER_GATEWAY " + os.Getenv("SUPPLIER_GATEWAY"))
+	}
+}
+
+// FakeSupplierGateway is the local fake. Suppliers listed in Failing reject
+// every order, so failover and the breaker can be tried without a supplier.
+type FakeSupplierGateway struct {
+	Failing map[string]bool
+}
+
+func (f *FakeSupplierGateway) Purchase(_ context.Context, supplierCode string, sku string, refId string, destination string, serverId string) (string, error) {
+	if f.Failing[supplierCode] {
+		return "", &SupplierRejectedError{Reason: "product unavailable at " + supplierCode}
+	}
+
+	return fmt.Sprintf("%s-%s-%s", strings.ToUpper(supplierCode), sku, refId), nil
+}
+
+// HttpSupplierGateway talks to a gateway in front of the suppliers. A 4xx or
+// a "failed" status is a rejection, anything else that is not a success may
+// have been processed and is not retried elsewhere. 401 and 403 are about
+// our token, not the order, and are no rejection.
+type HttpSupplierGateway struct {
+	Url   string
+	Token string
+}
+
+// the request carries ctx, so the fulfillment deadline of order service
+// cancels a purchase that is still in flight
+func (h *HttpSupplierGateway) Purchase(ctx context.Context, supplierCode string, sku string, refId string, destination string, serverId string) (string, error) {
+	reqBody, err := json.Marshal(fiber.Map{
+		"supplier":    supplierCode,
+		"sku":         sku,
+		"ref_id":      refId,
+		"destination": destination,
+		"server_id":   serverId,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, supplierRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.Url, bytes.NewReader(reqBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if h.Token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+h.Token)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// the order never left when the gateway could not be reached
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return "", &SupplierRejectedError{Reason: "gateway unreachable"}
+		}
+		return "", err
+	}
+	defer res.Body.Close()
+
+	statusCode := res.StatusCode
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
+		return "", errGatewayUnauthorized
+	}
+
+	var purchaseRes struct {
+		Status       string `json:"status"`
+		SerialNumber string `json:"serial_number"`
+		Message      string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &purchaseRes)
+
+	if statusCode >= 400 && statusCode < 500 {
+		return "", &SupplierRejectedError{Reason: purchaseRes.Message}
+	}
+	if statusCode < 200 || statusCode >= 300 {
+		return "", fmt.Errorf("supplier gateway returned status %d: %s", statusCode, body)
+	}
+
+	switch purchaseRes.Status {
+	case "success":
+		return purchaseRes.SerialNumber, nil
+	case "failed":
+		return "", &SupplierRejectedError{Reason: purchaseRes.Message}
+	default:
+		return "", fmt.Errorf("supplier gateway returned order status %q", purchaseRes.Status)
+	}
+}
+
+func getDefaultRoutingPolicy() string {
+	switch policy := os.Getenv("SUPPLIER_ROUTING_POLICY"); policy {
+	case RoutingPriority, RoutingSuccessRate, RoutingRoundRobin:
+		return policy
+	default:
+		return RoutingCheapest
+	}
+}
+
+type supplierRoute struct {
+	SupplierId   int
+	SupplierCode string
+	SupplierSku  string
+	Cost         int
+	Priority     int
+	Successes    int
+	Failures     int
+}
+
+// successRate starts new routes at one half instead of zero or one, so a
+// few orders do not decide it.
+func (r *supplierRoute) successRate() float64 {
+	return float64(r.Successes+1) / float64(r.Successes+r.Failures+2)
+}
+
+// orderRoutes sorts the routes in the order they are tried. Priority, higher
+// first, breaks every tie.
+func orderRoutes(routes []*supplierRoute, policy string, turn int64) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		a, b := routes[i], routes[j]
+		switch policy {
+		case RoutingCheapest:
+			if a.Cost != b.Cost {
+				return a.Cost < b.Cost
+			}
+		case RoutingSuccessRate:
+			if a.successRate() != b.successRate() {
+				return a.successRate() > b.successRate()
+			}
+		}
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return a.SupplierId < b.SupplierId
+	})
+
+	if policy == RoutingRoundRobin && len(routes) > 1 {
+		start := int(turn % int64(len(routes)))
+		rotated := make([]*supplierRoute, 0, len(routes))
+		rotated = append(rotated, routes[start:]...)
+		copy(routes, append(rotated, routes[:start]...))
+	}
+}
+
+func breakerOpenKey(supplierId int) string {
+	return "supplier-breaker:open:" + strconv.Itoa(supplierId)
+}
+
+func breakerFailuresKey(supplierId int) string {
+	return "supplier-breaker:failures:" + strconv.Itoa(supplierId)
+}
+
+// SupplierHealth is the circuit breaker state of a supplier.
+type SupplierHealth struct {
+	BreakerOpen    bool       `json:"breaker_open"`
+	OpenUntil      *time.Time `json:"open_until,omitempty"`
+	RecentFailures int        `json:"recent_failures"`
+}
+
+func getSupplierHealth(ctx context.Context, rdb *redis.Client, supplierId int) (*SupplierHealth, error) {
+	health := &SupplierHealth{}
+
+	ttl, err := rdb.PTTL(ctx, breakerOpenKey(supplierId)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		openUntil := time.Now().Add(ttl)
+		health.BreakerOpen = true
+		health.OpenUntil = &openUntil
+	}
+
+	failures, err := rdb.Get(ctx, breakerFailuresKey(supplierId)).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	health.RecentFailures = failures
+
+	return health, nil
+}
+
+// recordSupplierResult feeds the breaker. The failure count outlives the
+// open breaker, so the first failure after the cooldown opens it again.
+func recordSupplierResult(ctx context.Context, rdb *redis.Client, supplierId int, failed bool) {
+	if !failed {
+		if err := rdb.Del(ctx, breakerFailuresKey(supplierId)).Err(); err != nil {
+			slog.Error("Failed to reset supplier breaker", "supplier-id", supplierId, "error", err)
+		}
+		return
+	}
+
+	failures, err := rdb.Incr(ctx, breakerFailuresKey(supplierId)).Result()
+	if err != nil {
+		slog.Error("Failed to count supplier failure", "supplier-id", supplierId, "error", err)
+		return
+	}
+	rdb.Expire(ctx, breakerFailuresKey(supplierId), breakerWindow)
+
+	if failures >= breakerFailures {
+		if err := rdb.Set(ctx, breakerOpenKey(supplierId), failures, breakerCooldown).Err(); err != nil {
+			slog.Error("Failed to open supplier breaker", "supplier-id", supplierId, "error", err)
+			return
+		}
+		slog.Warn("Supplier breaker opened", "supplier-id", supplierId, "failures", failures)
+	}
+}
+
+func (g *GrpcServer) getSupplierRoutes(ctx context.Context, productId int32) (routes []*supplierRoute, policy string, err error) {
+	var routingPolicy sql.NullString
+	err = g.DB.QueryRowContext(ctx, "SELECT routing_policy FROM products WHERE id = ? AND deleted_at IS NULL", productId).Scan(&routingPolicy)
+	if err != nil {
+		return nil, "", err
+	}
+
+	policy = routingPolicy.String
+	if policy == "" {
+		policy = getDefaultRoutingPolicy()
+	}
+
+	rows, err := g.DB.QueryContext(ctx, `SELECT s.id, s.code, sp.supplier_sku, sp.cost, sp.priority, sp.success_count, sp.failure_count
+				FROM supplier_products sp JOIN suppliers s ON s.id = sp.supplier_id
+				WHERE sp.product_id = ? AND s.status = ?`, productId, StatusActive)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		route := &supplierRoute{}
+		if err := rows.Scan(&route.SupplierId, &route.SupplierCode, &route.SupplierSku, &route.Cost, &route.Priority, &route.Successes, &route.Failures); err != nil {
+			return nil, "", err
+		}
+		routes = append(routes, route)
+	}
+
+	return routes, policy, rows.Err()
+}
+
+// FulfillOrder buys the product of a paid order from its suppliers in
+// routing order. A rejection fails over to the next supplier, an unclear
+// answer stops, another supplier could deliver the order twice.
+func (g *GrpcServer) FulfillOrder(ctx context.Context, req *prpb.FulfillOrderReq) (*prpb.FulfillOrderRes, error) {
+	routes, policy, err := g.getSupplierRoutes(ctx, req.GetProductId())
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "Product not found")
+		}
+		slog.Error("Failed to query supplier routes", "error", err)
+		return nil, err
+	}
+	if len(routes) == 0 {
+		return nil, status.Error(codes.FailedPrecondition, "Product has no active supplier")
+	}
+
+	var turn int64
+	if policy == RoutingRoundRobin {
+		turn, err = g.RedisClient.Incr(ctx, fmt.Sprintf("supplier-routing:turn:%d", req.GetProductId())).Result()
+		if err != nil {
+			slog.Error("Failed to take round-robin turn", "error", err)
+		}
+	}
+	orderRoutes(routes, policy, turn)
+
+	fulfillOrderRes := &prpb.FulfillOrderRes{Status: FulfillmentFailed, Policy: policy}
+	for _, route := range routes {
+		attempt := &prpb.FulfillmentAttempt{
+			SupplierId:   int32(route.SupplierId),
+			SupplierCode: route.SupplierCode,
+			SupplierSku:  route.SupplierSku,
+			Cost:         int32(route.Cost),
+		}
+		fulfillOrderRes.Attempts = append(fulfillOrderRes.Attempts, attempt)
+
+		if open, err := g.RedisClient.Exists(ctx, breakerOpenKey(route.SupplierId)).Result(); err == nil && open > 0 {
+			attempt.Outcome = AttemptSkipped
+			attempt.Message = "circuit breaker open"
+			continue
+		}
+
+		serialNumber, err := g.Gateway.Purchase(ctx, route.SupplierCode, route.SupplierSku, req.GetOrderId(), req.GetDestination(), req.GetServerId())
+		if errors.Is(err, errGatewayUnauthorized) {
+			attempt.Outcome = AttemptError
+			attempt.Message = err.Error()
+			slog.Error("Supplier gateway refused the credentials, check SUPPLIER_GATEWAY_TOKEN", "order-id", req.GetOrderId())
+			return fulfillOrderRes, nil
+		}
+		g.recordRouteResult(ctx, req.GetProductId(), route, err != nil)
+
+		var rejectedErr *SupplierRejectedError
+		switch {
+		case err == nil:
+			attempt.Outcome = AttemptSucceeded
+			fulfillOrderRes.Status = FulfillmentSucceeded
+			fulfillOrderRes.SupplierCode = route.SupplierCode
+			fulfillOrderRes.SerialNumber = serialNumber
+			return fulfillOrderRes, nil
+		case errors.As(err, &rejectedErr):
+			attempt.Outcome = AttemptRejected
+			attempt.Message = rejectedErr.Reason
+			slog.Warn("Supplier rejected order, failing over", "order-id", req.GetOrderId(), "supplier", route.SupplierCode, "reason", rejectedErr.Reason)
+		default:
+			attempt.Outcome = AttemptError
+			attempt.Message = err.Error()
+			fulfillOrderRes.Status = FulfillmentUnknown
+			slog.Error("Failed to fulfill order at supplier", "order-id", req.GetOrderId(), "supplier", route.SupplierCode, "error", err)
+			return fulfillOrderRes, nil
+		}
+	}
+
+	return fulfillOrderRes, nil
+}
+
+func (g *GrpcServer) recordRouteResult(ctx context.Context, productId int32, route *supplierRoute, failed bool) {
+	recordSupplierResult(ctx, g.RedisClient, route.SupplierId, failed)
+
+	column := "success_count"
+	if failed {
+		column = "failure_count"
+	}
+	_, err := g.DB.ExecContext(ctx, "UPDATE supplier_products SET "+column+" = "+column+" + 1 WHERE supplier_id = ? AND product_id = ?", route.SupplierId, productId)
+	if err != nil {
+		slog.Error("Failed to count supplier result", "supplier-id", route.SupplierId, "error", err)
+	}
+}
+
+func (p *ProductService) handleUpdateRoutingPolicy(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	routingRequest := &RoutingPolicyRequest{}
+	if err := c.BodyParser(routingRequest); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err = p.validate.Struct(routingRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*routingRequest, err.(validator.ValidationErrors))
+	}
+
+	var policy sql.NullString
+	if routingRequest.Policy != "" {
+		policy = sql.NullString{String: routingRequest.Policy, Valid: true}
+	}
+
+	_, err = p.DB.ExecContext(c.Context(), "UPDATE products SET routing_policy = ?, updated_by = ? WHERE id = ? AND deleted_at IS NULL",
+		policy, shared.GetUserClaims(c).Subject, id)
+	if err != nil {
+		slog.Error("Failed to update routing policy", "error", err)
+		return err
+	}
+
+	if err := p.requireActive(c.Context(), "products", id, fiber.StatusNotFound, "Product not found"); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Routing policy updated successfully",
+		"data": fiber.Map{
+			"product_id": id,
+			"policy":     routingRequest.Policy,
+		},
+		"errors": nil,
+	})
+}
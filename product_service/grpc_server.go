@@ -10,17 +10,29 @@ import (
 
 	prpb "github.com/akmmp241/topupstore-microservice/product-proto/v1"
 	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// grpcPermissions lists the methods only order service may call, the
+// catalog reads are open to every service.
+var grpcPermissions = map[string]string{
+	prpb.ProductService_ReserveStock_FullMethodName: shared.PermServiceCall,
+	prpb.ProductService_ReleaseStock_FullMethodName: shared.PermServiceCall,
+	prpb.ProductService_FulfillOrder_FullMethodName: shared.PermServiceCall,
+}
+
 type GrpcServer struct {
 	ListenAddr string
 	DB         *sql.DB
 	Server     *grpc.Server
 	Listener   net.Listener
+	// RedisClient holds the supplier breakers and round-robin turns
+	RedisClient *redis.Client
+	Gateway     SupplierGateway
 	prpb.UnimplementedProductServiceServer
 }
 
@@ -32,13 +44,28 @@ func (g *GrpcServer) GetProductById(ctx context.Context, req *prpb.GetProductByI
 	}
 	defer shared.CommitOrRollback(tx, err)
 
-	query := "SELECT id, ref_id, product_type_id, name, description, image_url, price, created_at, updated_at FROM products WHERE id = ?"
-	row := g.DB.QueryRowContext(ctx, query, req.GetProductId())
+	tier := req.GetTier()
+	if !shared.IsTier(tier) {
+		tier = shared.TierRetail
+	}
+
+	// price is what the tier pays, base_price the retail price
+	query := `SELECT p.id, p.ref_id, p.product_type_id, pt.operator_id, p.name, p.description, p.image_url, COALESCE(tp.price, p.price), p.price,
+					COALESCE(pr.points_per_thousand, cr.points_per_thousand, ?), p.created_at, p.updated_at, ` + availabilityColumns + `
+				FROM products p JOIN product_types pt ON pt.id = p.product_type_id
+				JOIN operators o ON o.id = pt.operator_id
+				LEFT JOIN product_tier_prices tp ON tp.product_id = p.id AND tp.tier = ?
+				LEFT JOIN loyalty_point_rules pr ON pr.product_id = p.id
+				LEFT JOIN loyalty_point_rules cr ON cr.category_id = o.category_id
+				WHERE p.id = ? AND p.deleted_at IS NULL`
+	row := g.DB.QueryRowContext(ctx, query, getDefaultLoyaltyPointsPerThousand(), tier, req.GetProductId())
 
 	var product prpb.Product
 	var createdAt, updatedAt time.Time
+	var availabilityRow availabilityRow
 
-	if err := row.Scan(&product.Id, &product.RefId, &product.ProductTypeId, &product.Name, &product.Description, &product.ImageUrl, &product.Price, &createdAt, &updatedAt); err != nil {
+	dest := []any{&product.Id, &product.RefId, &product.ProductTypeId, &product.OperatorId, &product.Name, &product.Description, &product.ImageUrl, &product.Price, &product.BasePrice, &product.LoyaltyPointsPerThousand, &createdAt, &updatedAt}
+	if err := row.Scan(append(dest, availabilityRow.scanTargets()...)...); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, status.Error(codes.NotFound, "Product not found")
 		}
@@ -48,9 +75,158 @@ func (g *GrpcServer) GetProductById(ctx context.Context, req *prpb.GetProductByI
 	product.CreatedAt = timestamppb.New(createdAt)
 	product.UpdatedAt = timestamppb.New(updatedAt)
 
+	availability := availabilityRow.resolve(time.Now())
+	product.Available = availability.Available
+	product.AvailabilityStatus = availability.Status
+	if availability.Stock != nil {
+		product.StockTracked = true
+		product.Stock = int32(*availability.Stock)
+	}
+	if availability.MaintenanceEnd != nil {
+		product.MaintenanceEnd = timestamppb.New(*availability.MaintenanceEnd)
+	}
+
 	return &prpb.GetProductByIdRes{Product: &product}, nil
 }
 
+func (g *GrpcServer) GetProductTypeById(ctx context.Context, req *prpb.GetProductTypeByIdReq) (*prpb.GetProductTypeByIdRes, error) {
+	query := `SELECT pt.id, pt.ref_id, pt.operator_id, pt.name, pt.format_form, o.inquiry_provider IS NOT NULL, pt.created_at, pt.updated_at
+				FROM product_types pt JOIN operators o ON o.id = pt.operator_id
+				WHERE pt.id = ? AND pt.deleted_at IS NULL`
+	row := g.DB.QueryRowContext(ctx, query, req.GetProductTypeId())
+
+	var productType prpb.ProductType
+	var createdAt, updatedAt time.Time
+
+	if err := row.Scan(&productType.Id, &productType.RefId, &productType.OperatorId, &productType.Name, &productType.FormatForm, &productType.InquiryEnabled, &createdAt, &updatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "Product type not found")
+		}
+		slog.Error("Failed to scan product type row", "error", err)
+		return nil, err
+	}
+	productType.CreatedAt = timestamppb.New(createdAt)
+	productType.UpdatedAt = timestamppb.New(updatedAt)
+
+	return &prpb.GetProductTypeByIdRes{ProductType: &productType}, nil
+}
+
+// MatchOperatorPrefix checks that msisdn belongs to the operator. Operators
+// without prefixes, like internet or TV providers, are not checked.
+func (g *GrpcServer) MatchOperatorPrefix(ctx context.Context, req *prpb.MatchOperatorPrefixReq) (*prpb.MatchOperatorPrefixRes, error) {
+	var prefixes int
+	err := g.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM operator_prefixes WHERE operator_id = ?", req.GetOperatorId()).Scan(&prefixes)
+	if err != nil {
+		slog.Error("Failed to count operator prefixes", "error", err)
+		return nil, err
+	}
+	if prefixes == 0 {
+		return &prpb.MatchOperatorPrefixRes{Applies: false}, nil
+	}
+
+	msisdn, ok := shared.NormalizeMsisdn(req.GetMsisdn())
+	if !ok {
+		return &prpb.MatchOperatorPrefixRes{Applies: true, Matches: false}, nil
+	}
+
+	operator, err := detectOperator(ctx, g.DB, msisdn)
+	if err != nil {
+		return nil, err
+	}
+	if operator == nil {
+		return &prpb.MatchOperatorPrefixRes{Applies: true, Matches: false}, nil
+	}
+
+	return &prpb.MatchOperatorPrefixRes{
+		Applies:              true,
+		Matches:              operator.Id == int(req.GetOperatorId()),
+		DetectedOperatorId:   int32(operator.Id),
+		DetectedOperatorName: operator.Name,
+	}, nil
+}
+
+// ReserveStock takes one unit of a stock tracked product for an order.
+// Reserving for the same order again is a no-op.
+func (g *GrpcServer) ReserveStock(ctx context.Context, req *prpb.ReserveStockReq) (res *prpb.ReserveStockRes, err error) {
+	tx, err := g.DB.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("Failed to begin transaction", "error", err)
+		return nil, err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	var stock sql.NullInt64
+	err = tx.QueryRowContext(ctx, "SELECT stock FROM products WHERE id = ? AND deleted_at IS NULL FOR UPDATE", req.GetProductId()).Scan(&stock)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "Product not found")
+		}
+		slog.Error("Failed to query product stock", "error", err)
+		return nil, err
+	}
+	if !stock.Valid {
+		return &prpb.ReserveStockRes{StockTracked: false}, nil
+	}
+
+	result, err := tx.ExecContext(ctx, "INSERT IGNORE INTO product_stock_reservations (order_id, product_id, quantity) VALUES (?, ?, 1)", req.GetOrderId(), req.GetProductId())
+	if err != nil {
+		slog.Error("Failed to insert stock reservation", "error", err)
+		return nil, err
+	}
+	if inserted, _ := result.RowsAffected(); inserted == 0 {
+		return &prpb.ReserveStockRes{StockTracked: true, Remaining: int32(stock.Int64)}, nil
+	}
+
+	if stock.Int64 <= 0 {
+		err = status.Error(codes.FailedPrecondition, "Product is out of stock")
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE products SET stock = stock - 1 WHERE id = ?", req.GetProductId())
+	if err != nil {
+		slog.Error("Failed to decrement product stock", "error", err)
+		return nil, err
+	}
+
+	return &prpb.ReserveStockRes{StockTracked: true, Remaining: int32(stock.Int64 - 1)}, nil
+}
+
+// ReleaseStock puts the reservation of a failed order back into stock, once.
+func (g *GrpcServer) ReleaseStock(ctx context.Context, req *prpb.ReleaseStockReq) (res *prpb.ReleaseStockRes, err error) {
+	tx, err := g.DB.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("Failed to begin transaction", "error", err)
+		return nil, err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	var productId, quantity int
+	err = tx.QueryRowContext(ctx, "SELECT product_id, quantity FROM product_stock_reservations WHERE order_id = ? AND released_at IS NULL FOR UPDATE",
+		req.GetOrderId()).Scan(&productId, &quantity)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &prpb.ReleaseStockRes{Released: false}, nil
+		}
+		slog.Error("Failed to query stock reservation", "error", err)
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE product_stock_reservations SET released_at = NOW() WHERE order_id = ?", req.GetOrderId())
+	if err != nil {
+		slog.Error("Failed to release stock reservation", "error", err)
+		return nil, err
+	}
+
+	// products without tracking any more keep their null stock
+	_, err = tx.ExecContext(ctx, "UPDATE products SET stock = stock + ? WHERE id = ? AND stock IS NOT NULL", quantity, productId)
+	if err != nil {
+		slog.Error("Failed to restore product stock", "error", err)
+		return nil, err
+	}
+
+	return &prpb.ReleaseStockRes{Released: true}, nil
+}
+
 func (g *GrpcServer) Run() {
 	prpb.RegisterProductServiceServer(g.Server, g)
 
@@ -70,9 +246,11 @@ func NewGrpcServer(addr string, DB *sql.DB) *GrpcServer {
 	}
 
 	return &GrpcServer{
-		ListenAddr: addr,
-		DB:         DB,
-		Server:     grpc.NewServer(),
-		Listener:   listener,
+		ListenAddr:  addr,
+		DB:          DB,
+		Server:      grpc.NewServer(grpc.UnaryInterceptor(shared.PermissionUnaryInterceptor(grpcPermissions))),
+		Listener:    listener,
+		RedisClient: shared.NewRedis(),
+		Gateway:     NewSupplierGateway(),
 	}
 }
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofiber/fiber/v2"
+)
+
+const defaultLoyaltyPointsPerThousand = 1
+
+// getDefaultLoyaltyPointsPerThousand is the earn rate of products without a
+// product or category rule.
+func getDefaultLoyaltyPointsPerThousand() int {
+	rate, err := strconv.Atoi(os.Getenv("LOYALTY_POINTS_PER_THOUSAND"))
+	if err != nil || rate < 0 {
+		return defaultLoyaltyPointsPerThousand
+	}
+
+	return rate
+}
+
+func (p *ProductService) handleSetCategoryLoyaltyRule(c *fiber.Ctx) error {
+	return p.setLoyaltyRule(c, "category_id")
+}
+
+func (p *ProductService) handleSetProductLoyaltyRule(c *fiber.Ctx) error {
+	return p.setLoyaltyRule(c, "product_id")
+}
+
+func (p *ProductService) handleDeleteCategoryLoyaltyRule(c *fiber.Ctx) error {
+	return p.deleteLoyaltyRule(c, "category_id")
+}
+
+func (p *ProductService) handleDeleteProductLoyaltyRule(c *fiber.Ctx) error {
+	return p.deleteLoyaltyRule(c, "product_id")
+}
+
+// setLoyaltyRule stores the earn rate of a category or product, column names
+// which one the id in the path refers to.
+func (p *ProductService) setLoyaltyRule(c *fiber.Ctx, column string) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid ID")
+	}
+
+	ruleRequest := &LoyaltyRuleRequest{}
+	if err := c.BodyParser(ruleRequest); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err = p.validate.Struct(ruleRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*ruleRequest, err.(validator.ValidationErrors))
+	}
+
+	query := "INSERT INTO loyalty_point_rules (" + column + ", points_per_thousand) VALUES (?, ?) ON DUPLICATE KEY UPDATE points_per_thousand = VALUES(points_per_thousand)"
+	_, err = p.DB.ExecContext(c.Context(), query, id, *ruleRequest.PointsPerThousand)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1452 {
+			return fiber.NewError(fiber.StatusNotFound, "Category or product not found")
+		}
+		slog.Error("Failed to save loyalty rule", "error", err)
+		return err
+	}
+
+	slog.Info("Loyalty rule saved", column, id, "points-per-thousand", *ruleRequest.PointsPerThousand, "by", shared.GetUserClaims(c).Subject)
+
+	return c.JSON(fiber.Map{
+		"message": "Loyalty rule saved successfully",
+		"data": fiber.Map{
+			column:                id,
+			"points_per_thousand": *ruleRequest.PointsPerThousand,
+		},
+		"errors": nil,
+	})
+}
+
+func (p *ProductService) deleteLoyaltyRule(c *fiber.Ctx, column string) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid ID")
+	}
+
+	result, err := p.DB.ExecContext(c.Context(), "DELETE FROM loyalty_point_rules WHERE "+column+" = ?", id)
+	if err != nil {
+		slog.Error("Failed to delete loyalty rule", "error", err)
+		return err
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "Loyalty rule not found")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Loyalty rule deleted successfully",
+		"data":    nil,
+		"errors":  nil,
+	})
+}
@@ -13,6 +13,14 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import-price-list" {
+		if err := runImportPriceList(os.Args[2:]); err != nil {
+			slog.Error("Failed to import price list", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	port := os.Getenv("PRODUCT_SERVICE_PORT")
 	grpcPort := os.Getenv("PRODUCT_SERVICE_GRPC_PORT")
 
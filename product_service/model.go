@@ -1,6 +1,10 @@
 package main
 
-import "time"
+import (
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+)
 
 type Category struct {
 	Id        int       `json:"id"`
@@ -22,23 +26,48 @@ type Operator struct {
 }
 
 type ProductType struct {
-	Id         int       `json:"id"`
-	RefId      string    `json:"ref_id"`
-	OperatorId int       `json:"operator_id"`
-	Name       string    `json:"name"`
-	FormatForm string    `json:"format_form"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	Id         int    `json:"id"`
+	RefId      string `json:"ref_id"`
+	OperatorId int    `json:"operator_id"`
+	Name       string `json:"name"`
+	FormatForm string `json:"format_form"`
+	// Form is format_form parsed, left out when format_form is malformed
+	Form      *shared.FormSchema `json:"form,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
 }
 
 type Product struct {
-	Id            int       `json:"id"`
-	RefId         string    `json:"ref_id"`
-	ProductTypeId int       `json:"product_type_id"`
-	Name          string    `json:"name"`
-	Description   string    `json:"description"`
-	ImageUrl      string    `json:"image_url"`
-	Price         int       `json:"price"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	Id            int    `json:"id"`
+	RefId         string `json:"ref_id"`
+	ProductTypeId int    `json:"product_type_id"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	ImageUrl      string `json:"image_url"`
+	Price         int    `json:"price"`
+	BasePrice     int    `json:"base_price,omitempty"`
+	// Availability is only resolved for single product reads
+	Availability *Availability `json:"availability,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	UpdatedAt    time.Time     `json:"updated_at"`
+}
+
+type Supplier struct {
+	Id        int             `json:"id"`
+	Code      string          `json:"code"`
+	Name      string          `json:"name"`
+	Status    string          `json:"status"`
+	Health    *SupplierHealth `json:"health,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type PriceChange struct {
+	Id        int       `json:"id"`
+	ProductId int       `json:"product_id"`
+	OldPrice  int       `json:"old_price"`
+	NewPrice  int       `json:"new_price"`
+	Cost      int       `json:"cost"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofiber/fiber/v2"
+)
+
+// detectOperator finds the operator owning the longest prefix of a
+// normalised msisdn, nil when no prefix matches.
+func detectOperator(ctx context.Context, db *sql.DB, msisdn string) (*Operator, error) {
+	query := `SELECT o.id, o.ref_id, o.category_id, o.name, o.slug, o.image_url, o.description, o.created_at, o.updated_at
+				FROM operator_prefixes op JOIN operators o ON o.id = op.operator_id
+				WHERE ? LIKE CONCAT(op.prefix, '%') AND o.deleted_at IS NULL
+				ORDER BY LENGTH(op.prefix) DESC LIMIT 1`
+
+	var operator Operator
+	var imageUrl, description sql.NullString
+	err := db.QueryRowContext(ctx, query, msisdn).Scan(&operator.Id, &operator.RefId, &operator.CategoryId, &operator.Name, &operator.Slug,
+		&imageUrl, &description, &operator.CreatedAt, &operator.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		slog.Error("Failed to query operator prefix", "error", err)
+		return nil, err
+	}
+	operator.ImageUrl = imageUrl.String
+	operator.Description = description.String
+
+	return &operator, nil
+}
+
+func (p *ProductService) handleDetectOperator(c *fiber.Ctx) error {
+	msisdn, ok := shared.NormalizeMsisdn(c.Query("msisdn"))
+	if !ok {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid phone number")
+	}
+
+	operator, err := detectOperator(c.Context(), p.DB, msisdn)
+	if err != nil {
+		return err
+	}
+	if operator == nil {
+		return fiber.NewError(fiber.StatusNotFound, "No operator found for this number")
+	}
+
+	query := `SELECT p.id, p.ref_id, p.product_type_id, p.name, p.description, p.image_url, COALESCE(tp.price, p.price), p.price, p.created_at, p.updated_at
+				FROM products p JOIN product_types pt ON pt.id = p.product_type_id
+				LEFT JOIN product_tier_prices tp ON tp.product_id = p.id AND tp.tier = ?
+				WHERE pt.operator_id = ? AND p.deleted_at IS NULL AND pt.deleted_at IS NULL
+				ORDER BY p.price`
+	rows, err := p.DB.QueryContext(c.Context(), query, requestTier(c), operator.Id)
+	if err != nil {
+		slog.Error("Failed to query products", "error", err)
+		return err
+	}
+	defer rows.Close()
+
+	products := []Product{}
+	for rows.Next() {
+		var product Product
+		var imageUrl sql.NullString
+		if err := rows.Scan(&product.Id, &product.RefId, &product.ProductTypeId, &product.Name, &product.Description, &imageUrl,
+			&product.Price, &product.BasePrice, &product.CreatedAt, &product.UpdatedAt); err != nil {
+			slog.Error("Failed to scan product row", "error", err)
+			return err
+		}
+		product.ImageUrl = imageUrl.String
+		if product.BasePrice == product.Price {
+			product.BasePrice = 0
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("Failed to iterate product rows", "error", err)
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Operator detected successfully",
+		"data": fiber.Map{
+			"msisdn":   msisdn,
+			"operator": operator,
+			"products": products,
+		},
+		"errors": nil,
+	})
+}
+
+// handleUpdateOperatorPrefixes replaces the prefixes of an operator.
+func (p *ProductService) handleUpdateOperatorPrefixes(c *fiber.Ctx) (err error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid operator ID")
+	}
+
+	prefixRequest := &OperatorPrefixesRequest{}
+	if err := c.BodyParser(prefixRequest); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err = p.validate.Struct(prefixRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*prefixRequest, err.(validator.ValidationErrors))
+	}
+
+	for _, prefix := range prefixRequest.Prefixes {
+		if !strings.HasPrefix(prefix, "08") {
+			return fiber.NewError(fiber.StatusUnprocessableEntity, "Prefixes must start with 08: "+prefix)
+		}
+	}
+
+	if err := p.requireActive(c.Context(), "operators", id, fiber.StatusNotFound, "Operator not found"); err != nil {
+		return err
+	}
+
+	tx, err := p.DB.BeginTx(c.Context(), nil)
+	if err != nil {
+		slog.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	_, err = tx.ExecContext(c.Context(), "DELETE FROM operator_prefixes WHERE operator_id = ?", id)
+	if err != nil {
+		slog.Error("Failed to delete operator prefixes", "error", err)
+		return err
+	}
+
+	for _, prefix := range prefixRequest.Prefixes {
+		_, err = tx.ExecContext(c.Context(), "INSERT INTO operator_prefixes (operator_id, prefix) VALUES (?, ?)", id, prefix)
+		if err != nil {
+			var mysqlErr *mysql.MySQLError
+			if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+				return fiber.NewError(fiber.StatusConflict, "Prefix "+prefix+" already belongs to an operator")
+			}
+			slog.Error("Failed to insert operator prefix", "error", err)
+			return err
+		}
+	}
+
+	slog.Info("Operator prefixes updated", "operator-id", id, "by", shared.GetUserClaims(c).Subject)
+
+	return c.JSON(fiber.Map{
+		"message": "Operator prefixes updated successfully",
+		"data": fiber.Map{
+			"operator_id": id,
+			"prefixes":    prefixRequest.Prefixes,
+		},
+		"errors": nil,
+	})
+}
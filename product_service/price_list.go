@@ -0,0 +1,799 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	ImportNew     = "new"
+	ImportChanged = "changed"
+	ImportRemoved = "removed"
+)
+
+// PriceListRow is one product of a supplier price list. CSV headers are the
+// json names. Price is optional for products that exist already, new ones
+// need it so they never go on sale at their cost.
+type PriceListRow struct {
+	CategoryRef     string `json:"category_ref" validate:"required,max=255"`
+	CategoryName    string `json:"category_name" validate:"required,max=255"`
+	OperatorRef     string `json:"operator_ref" validate:"required,max=255"`
+	OperatorName    string `json:"operator_name" validate:"required,max=255"`
+	OperatorSlug    string `json:"operator_slug" validate:"required,max=255"`
+	ProductTypeRef  string `json:"product_type_ref" validate:"required,max=255"`
+	ProductTypeName string `json:"product_type_name" validate:"required,max=255"`
+	FormatForm      string `json:"format_form"`
+	ProductRef      string `json:"product_ref" validate:"required,max=255"`
+	ProductName     string `json:"product_name" validate:"required,max=255"`
+	Description     string `json:"description"`
+	Price           int    `json:"price" validate:"gte=0"`
+	SupplierSku     string `json:"supplier_sku" validate:"required,max=64"`
+	Cost            int    `json:"cost" validate:"required,gt=0"`
+}
+
+// ImportChange is one line of the import diff, Changes holds the old and new
+// value of every changed field.
+type ImportChange struct {
+	Action  string            `json:"action"`
+	Entity  string            `json:"entity"`
+	RefId   string            `json:"ref_id"`
+	Changes map[string][2]any `json:"changes,omitempty"`
+}
+
+type PriceListImport struct {
+	Applied bool           `json:"applied"`
+	Rows    int            `json:"rows"`
+	New     int            `json:"new"`
+	Changed int            `json:"changed"`
+	Removed int            `json:"removed"`
+	Diff    []ImportChange `json:"diff"`
+
+	productIds       []int
+	newProductIds    map[int]bool
+	changedParentIds map[string][]int
+}
+
+func (i *PriceListImport) record(action string, entity string, refId string, changes map[string][2]any) {
+	switch action {
+	case ImportNew:
+		i.New++
+	case ImportChanged:
+		i.Changed++
+	case ImportRemoved:
+		i.Removed++
+	}
+
+	i.Diff = append(i.Diff, ImportChange{Action: action, Entity: entity, RefId: refId, Changes: changes})
+}
+
+// parsePriceList reads a CSV price list with a header row or a JSON array.
+func parsePriceList(contentType string, body []byte) ([]PriceListRow, error) {
+	if strings.Contains(contentType, "csv") {
+		return parsePriceListCsv(body)
+	}
+
+	rows := []PriceListRow{}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, errors.New("price list must be a JSON array or CSV")
+	}
+
+	return rows, nil
+}
+
+func parsePriceListCsv(body []byte) ([]PriceListRow, error) {
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("price list CSV needs a header row")
+	}
+
+	rowType := reflect.TypeOf(PriceListRow{})
+	columns := make(map[int]int, len(header))
+	for column, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		for field := 0; field < rowType.NumField(); field++ {
+			if rowType.Field(field).Tag.Get("json") == name {
+				columns[column] = field
+			}
+		}
+	}
+
+	rows := []PriceListRow{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		var row PriceListRow
+		value := reflect.ValueOf(&row).Elem()
+		for column, field := range columns {
+			if column >= len(record) {
+				continue
+			}
+			cell := strings.TrimSpace(record[column])
+
+			switch value.Field(field).Kind() {
+			case reflect.Int:
+				if cell == "" {
+					continue
+				}
+				number, err := strconv.Atoi(cell)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %s is not a number", line, header[column])
+				}
+				value.Field(field).SetInt(int64(number))
+			default:
+				value.Field(field).SetString(cell)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// validatePriceList returns the errors of every invalid row, keyed by the row
+// number counting from 1.
+func validatePriceList(validate *validator.Validate, rows []PriceListRow) error {
+	if len(rows) == 0 {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "Price list is empty")
+	}
+
+	rowErrors := shared.Errors{}
+	seen := make(map[string]int, len(rows))
+	for i, row := range rows {
+		key := fmt.Sprintf("row %d", i+1)
+
+		err := validate.Struct(row)
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			field, _ := reflect.TypeOf(row).FieldByName(validationErrors[0].Field())
+			rowErrors[key] = fmt.Sprintf("The %s field is invalid (%s)", field.Tag.Get("json"), validationErrors[0].Tag())
+			continue
+		}
+
+		if first, ok := seen[row.ProductRef]; ok {
+			rowErrors[key] = fmt.Sprintf("Product %s is already on row %d", row.ProductRef, first)
+			continue
+		}
+		seen[row.ProductRef] = i + 1
+
+		if row.FormatForm != "" {
+			if _, err := shared.ParseFormSchema(row.FormatForm); err != nil {
+				rowErrors[key] = err.Error()
+			}
+		}
+	}
+
+	if len(rowErrors) > 0 {
+		return shared.FailedValidationError{Errors: rowErrors}
+	}
+
+	return nil
+}
+
+// priceListImporter upserts a price list inside one transaction. Parents
+// shared by many rows are written once, later rows must agree with them.
+type priceListImporter struct {
+	tx             *sql.Tx
+	supplierId     int
+	updatedBy      any
+	apply          bool
+	restoreDeleted bool
+	result         *PriceListImport
+
+	categories   map[string]int
+	operators    map[string]int
+	productTypes map[string]int
+	rowOf        map[string]*PriceListRow
+}
+
+// importPriceList applies the rows of a supplier, or only reports what would
+// change when apply is false. Deleted rows the list still names are restored
+// only with restoreDeleted, an admin may have deleted them on purpose.
+func importPriceList(ctx context.Context, db *sql.DB, supplierId int, rows []PriceListRow, apply bool, restoreDeleted bool, updatedBy any) (result *PriceListImport, err error) {
+	// a dry run only reads, it neither writes nor locks rows
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: !apply})
+	if err != nil {
+		slog.Error("Failed to begin transaction", "error", err)
+		return nil, err
+	}
+	defer func() {
+		if !apply {
+			_ = tx.Rollback()
+			return
+		}
+		shared.CommitOrRollback(tx, err)
+	}()
+
+	var supplierExists int
+	err = tx.QueryRowContext(ctx, "SELECT 1 FROM suppliers WHERE id = ?", supplierId).Scan(&supplierExists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			err = fiber.NewError(fiber.StatusNotFound, "Supplier not found")
+		}
+		return nil, err
+	}
+
+	importer := &priceListImporter{
+		tx:             tx,
+		supplierId:     supplierId,
+		updatedBy:      updatedBy,
+		apply:          apply,
+		restoreDeleted: restoreDeleted,
+		result: &PriceListImport{
+			Applied:          apply,
+			Rows:             len(rows),
+			Diff:             []ImportChange{},
+			newProductIds:    map[int]bool{},
+			changedParentIds: map[string][]int{},
+		},
+		categories:   map[string]int{},
+		operators:    map[string]int{},
+		productTypes: map[string]int{},
+		rowOf:        map[string]*PriceListRow{},
+	}
+
+	for i := range rows {
+		if err = importer.importRow(ctx, &rows[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = importer.removeMissing(ctx); err != nil {
+		return nil, err
+	}
+
+	return importer.result, nil
+}
+
+// lockClause locks the rows an applied import is going to write.
+func (i *priceListImporter) lockClause() string {
+	if i.apply {
+		return " FOR UPDATE"
+	}
+	return ""
+}
+
+// exec writes when the import is applied and returns the id of an inserted
+// row. A dry run skips the write, its new rows get id 0.
+func (i *priceListImporter) exec(ctx context.Context, query string, args ...any) (int, error) {
+	if !i.apply {
+		return 0, nil
+	}
+
+	result, err := i.tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	id, _ := result.LastInsertId()
+	return int(id), nil
+}
+
+// restoreChange records the restore of a deleted row, or refuses it when the
+// import was not asked to restore deleted rows.
+func (i *priceListImporter) restoreChange(changes map[string][2]any, deletedAt sql.NullTime, entity string, refId string) error {
+	if !deletedAt.Valid {
+		return nil
+	}
+	if !i.restoreDeleted {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, fmt.Sprintf("%s %s is deleted, import with restore_deleted to restore it", entity, refId))
+	}
+
+	changes["deleted"] = [2]any{true, false}
+	return nil
+}
+
+// parentConflict fails when two rows describe the same parent differently.
+func (i *priceListImporter) parentConflict(entity string, refId string, row *PriceListRow, differs func(first *PriceListRow) bool) error {
+	key := entity + ":" + refId
+	first, ok := i.rowOf[key]
+	if !ok {
+		i.rowOf[key] = row
+		return nil
+	}
+	if differs(first) {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, fmt.Sprintf("Rows of %s %s do not agree, product %s", entity, refId, row.ProductRef))
+	}
+
+	return nil
+}
+
+func (i *priceListImporter) importRow(ctx context.Context, row *PriceListRow) error {
+	categoryId, err := i.upsertCategory(ctx, row)
+	if err != nil {
+		return err
+	}
+
+	operatorId, err := i.upsertOperator(ctx, row, categoryId)
+	if err != nil {
+		return err
+	}
+
+	productTypeId, err := i.upsertProductType(ctx, row, operatorId)
+	if err != nil {
+		return err
+	}
+
+	productId, err := i.upsertProduct(ctx, row, productTypeId)
+	if err != nil {
+		return err
+	}
+
+	return i.upsertSupplierProduct(ctx, row, productId)
+}
+
+func (i *priceListImporter) upsertCategory(ctx context.Context, row *PriceListRow) (int, error) {
+	if err := i.parentConflict("category", row.CategoryRef, row, func(first *PriceListRow) bool {
+		return first.CategoryName != row.CategoryName
+	}); err != nil {
+		return 0, err
+	}
+	if id, ok := i.categories[row.CategoryRef]; ok {
+		return id, nil
+	}
+
+	var id int
+	var name string
+	var deletedAt sql.NullTime
+	err := i.tx.QueryRowContext(ctx, "SELECT id, name, deleted_at FROM categories WHERE ref_id = ?"+i.lockClause(), row.CategoryRef).Scan(&id, &name, &deletedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		id, err = i.exec(ctx, "INSERT INTO categories (ref_id, name, updated_by) VALUES (?, ?, ?)", row.CategoryRef, row.CategoryName, i.updatedBy)
+		if err != nil {
+			return 0, err
+		}
+		i.result.record(ImportNew, "category", row.CategoryRef, nil)
+	case err != nil:
+		return 0, err
+	default:
+		changes := map[string][2]any{}
+		diffField(changes, "name", name, row.CategoryName)
+		if err := i.restoreChange(changes, deletedAt, "Category", row.CategoryRef); err != nil {
+			return 0, err
+		}
+		if len(changes) > 0 {
+			_, err = i.exec(ctx, "UPDATE categories SET name = ?, deleted_at = NULL, updated_by = ? WHERE id = ?", row.CategoryName, i.updatedBy, id)
+			if err != nil {
+				return 0, err
+			}
+			i.result.record(ImportChanged, "category", row.CategoryRef, changes)
+			i.result.changedParentIds["c.id"] = append(i.result.changedParentIds["c.id"], id)
+		}
+	}
+
+	i.categories[row.CategoryRef] = id
+	return id, nil
+}
+
+func (i *priceListImporter) upsertOperator(ctx context.Context, row *PriceListRow, categoryId int) (int, error) {
+	if err := i.parentConflict("operator", row.OperatorRef, row, func(first *PriceListRow) bool {
+		return first.OperatorName != row.OperatorName || first.OperatorSlug != row.OperatorSlug || first.CategoryRef != row.CategoryRef
+	}); err != nil {
+		return 0, err
+	}
+	if id, ok := i.operators[row.OperatorRef]; ok {
+		return id, nil
+	}
+
+	var id, currentCategoryId int
+	var name, slug string
+	var deletedAt sql.NullTime
+	err := i.tx.QueryRowContext(ctx, "SELECT id, category_id, name, slug, deleted_at FROM operators WHERE ref_id = ?"+i.lockClause(), row.OperatorRef).
+		Scan(&id, &currentCategoryId, &name, &slug, &deletedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		id, err = i.exec(ctx, "INSERT INTO operators (ref_id, category_id, name, slug, updated_by) VALUES (?, ?, ?, ?, ?)",
+			row.OperatorRef, categoryId, row.OperatorName, row.OperatorSlug, i.updatedBy)
+		if err != nil {
+			return 0, err
+		}
+		i.result.record(ImportNew, "operator", row.OperatorRef, nil)
+	case err != nil:
+		return 0, err
+	default:
+		changes := map[string][2]any{}
+		diffField(changes, "category_id", currentCategoryId, categoryId)
+		diffField(changes, "name", name, row.OperatorName)
+		diffField(changes, "slug", slug, row.OperatorSlug)
+		if err := i.restoreChange(changes, deletedAt, "Operator", row.OperatorRef); err != nil {
+			return 0, err
+		}
+		if len(changes) > 0 {
+			_, err = i.exec(ctx, "UPDATE operators SET category_id = ?, name = ?, slug = ?, deleted_at = NULL, updated_by = ? WHERE id = ?",
+				categoryId, row.OperatorName, row.OperatorSlug, i.updatedBy, id)
+			if err != nil {
+				return 0, err
+			}
+			i.result.record(ImportChanged, "operator", row.OperatorRef, changes)
+			i.result.changedParentIds["o.id"] = append(i.result.changedParentIds["o.id"], id)
+		}
+	}
+
+	i.operators[row.OperatorRef] = id
+	return id, nil
+}
+
+func (i *priceListImporter) upsertProductType(ctx context.Context, row *PriceListRow, operatorId int) (int, error) {
+	if err := i.parentConflict("product type", row.ProductTypeRef, row, func(first *PriceListRow) bool {
+		return first.ProductTypeName != row.ProductTypeName || first.OperatorRef != row.OperatorRef ||
+			(row.FormatForm != "" && first.FormatForm != row.FormatForm)
+	}); err != nil {
+		return 0, err
+	}
+	if id, ok := i.productTypes[row.ProductTypeRef]; ok {
+		return id, nil
+	}
+
+	var id, currentOperatorId int
+	var name, formatForm string
+	var deletedAt sql.NullTime
+	err := i.tx.QueryRowContext(ctx, "SELECT id, operator_id, name, format_form, deleted_at FROM product_types WHERE ref_id = ?"+i.lockClause(), row.ProductTypeRef).
+		Scan(&id, &currentOperatorId, &name, &formatForm, &deletedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if row.FormatForm == "" {
+			return 0, fiber.NewError(fiber.StatusUnprocessableEntity, "Product type "+row.ProductTypeRef+" is new and needs a format_form")
+		}
+		id, err = i.exec(ctx, "INSERT INTO product_types (ref_id, operator_id, name, format_form, updated_by) VALUES (?, ?, ?, ?, ?)",
+			row.ProductTypeRef, operatorId, row.ProductTypeName, row.FormatForm, i.updatedBy)
+		if err != nil {
+			return 0, err
+		}
+		i.result.record(ImportNew, "product_type", row.ProductTypeRef, nil)
+	case err != nil:
+		return 0, err
+	default:
+		newFormatForm := formatForm
+		if row.FormatForm != "" {
+			newFormatForm = row.FormatForm
+		}
+
+		changes := map[string][2]any{}
+		diffField(changes, "operator_id", currentOperatorId, operatorId)
+		diffField(changes, "name", name, row.ProductTypeName)
+		diffField(changes, "format_form", formatForm, newFormatForm)
+		if err := i.restoreChange(changes, deletedAt, "Product type", row.ProductTypeRef); err != nil {
+			return 0, err
+		}
+		if len(changes) > 0 {
+			_, err = i.exec(ctx, "UPDATE product_types SET operator_id = ?, name = ?, format_form = ?, deleted_at = NULL, updated_by = ? WHERE id = ?",
+				operatorId, row.ProductTypeName, newFormatForm, i.updatedBy, id)
+			if err != nil {
+				return 0, err
+			}
+			i.result.record(ImportChanged, "product_type", row.ProductTypeRef, changes)
+			i.result.changedParentIds["pt.id"] = append(i.result.changedParentIds["pt.id"], id)
+		}
+	}
+
+	i.productTypes[row.ProductTypeRef] = id
+	return id, nil
+}
+
+func (i *priceListImporter) upsertProduct(ctx context.Context, row *PriceListRow, productTypeId int) (int, error) {
+	var id, currentProductTypeId, price int
+	var name, description string
+	var deletedAt sql.NullTime
+	err := i.tx.QueryRowContext(ctx, "SELECT id, product_type_id, name, description, price, deleted_at FROM products WHERE ref_id = ?"+i.lockClause(), row.ProductRef).
+		Scan(&id, &currentProductTypeId, &name, &description, &price, &deletedAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if row.Price == 0 {
+			return 0, fiber.NewError(fiber.StatusUnprocessableEntity, "Product "+row.ProductRef+" is new and needs a price")
+		}
+		id, err = i.exec(ctx, "INSERT INTO products (ref_id, product_type_id, name, description, price, updated_by) VALUES (?, ?, ?, ?, ?, ?)",
+			row.ProductRef, productTypeId, row.ProductName, row.Description, row.Price, i.updatedBy)
+		if err != nil {
+			return 0, err
+		}
+		i.result.record(ImportNew, "product", row.ProductRef, nil)
+		i.result.newProductIds[id] = true
+	case err != nil:
+		return 0, err
+	default:
+		newPrice, newDescription := price, description
+		if row.Price > 0 {
+			newPrice = row.Price
+		}
+		if row.Description != "" {
+			newDescription = row.Description
+		}
+
+		changes := map[string][2]any{}
+		diffField(changes, "product_type_id", currentProductTypeId, productTypeId)
+		diffField(changes, "name", name, row.ProductName)
+		diffField(changes, "description", description, newDescription)
+		diffField(changes, "price", price, newPrice)
+		if err := i.restoreChange(changes, deletedAt, "Product", row.ProductRef); err != nil {
+			return 0, err
+		}
+		if len(changes) > 0 {
+			_, err = i.exec(ctx, "UPDATE products SET product_type_id = ?, name = ?, description = ?, price = ?, deleted_at = NULL, updated_by = ? WHERE id = ?",
+				productTypeId, row.ProductName, newDescription, newPrice, i.updatedBy, id)
+			if err != nil {
+				return 0, err
+			}
+			i.result.record(ImportChanged, "product", row.ProductRef, changes)
+		}
+	}
+
+	i.result.productIds = append(i.result.productIds, id)
+	return id, nil
+}
+
+func (i *priceListImporter) upsertSupplierProduct(ctx context.Context, row *PriceListRow, productId int) error {
+	var sku string
+	var cost int
+	err := i.tx.QueryRowContext(ctx, "SELECT supplier_sku, cost FROM supplier_products WHERE supplier_id = ? AND product_id = ?"+i.lockClause(),
+		i.supplierId, productId).Scan(&sku, &cost)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		_, err = i.exec(ctx, "INSERT INTO supplier_products (supplier_id, product_id, supplier_sku, cost) VALUES (?, ?, ?, ?)",
+			i.supplierId, productId, row.SupplierSku, row.Cost)
+		if err != nil {
+			return err
+		}
+		i.result.record(ImportNew, "supplier_product", row.ProductRef, map[string][2]any{"cost": {nil, row.Cost}})
+	case err != nil:
+		return err
+	default:
+		changes := map[string][2]any{}
+		diffField(changes, "supplier_sku", sku, row.SupplierSku)
+		diffField(changes, "cost", cost, row.Cost)
+		if len(changes) > 0 {
+			_, err = i.exec(ctx, "UPDATE supplier_products SET supplier_sku = ?, cost = ? WHERE supplier_id = ? AND product_id = ?",
+				row.SupplierSku, row.Cost, i.supplierId, productId)
+			if err != nil {
+				return err
+			}
+			i.result.record(ImportChanged, "supplier_product", row.ProductRef, changes)
+		}
+	}
+
+	return nil
+}
+
+// removeMissing drops the supplier offers of products left out of the list.
+// The products stay, other suppliers may still sell them.
+func (i *priceListImporter) removeMissing(ctx context.Context) error {
+	listed := make(map[int]bool, len(i.result.productIds))
+	for _, id := range i.result.productIds {
+		listed[id] = true
+	}
+
+	rows, err := i.tx.QueryContext(ctx, `SELECT sp.product_id, p.ref_id, sp.cost FROM supplier_products sp JOIN products p ON p.id = sp.product_id
+				WHERE sp.supplier_id = ?`, i.supplierId)
+	if err != nil {
+		return err
+	}
+
+	type missingProduct struct {
+		id    int
+		refId string
+		cost  int
+	}
+	missing := []missingProduct{}
+	for rows.Next() {
+		var product missingProduct
+		if err := rows.Scan(&product.id, &product.refId, &product.cost); err != nil {
+			rows.Close()
+			return err
+		}
+		if !listed[product.id] {
+			missing = append(missing, product)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, product := range missing {
+		_, err := i.exec(ctx, "DELETE FROM supplier_products WHERE supplier_id = ? AND product_id = ?", i.supplierId, product.id)
+		if err != nil {
+			return err
+		}
+		i.result.record(ImportRemoved, "supplier_product", product.refId, map[string][2]any{"cost": {product.cost, nil}})
+		i.result.productIds = append(i.result.productIds, product.id)
+	}
+
+	return nil
+}
+
+func diffField[T comparable](changes map[string][2]any, field string, old T, new T) {
+	if old != new {
+		changes[field] = [2]any{old, new}
+	}
+}
+
+// publishImport reprices the imported products and sends every product the
+// import touched to the search index.
+func (p *ProductService) publishImport(ctx context.Context, result *PriceListImport) error {
+	if _, err := p.recomputePrices(ctx, PriceReasonSupplierCost, result.productIds...); err != nil {
+		return err
+	}
+
+	conditions := []string{}
+	args := []any{}
+	for column, ids := range result.changedParentIds {
+		for _, id := range ids {
+			conditions = append(conditions, column+" = ?")
+			args = append(args, id)
+		}
+	}
+	for _, id := range result.productIds {
+		conditions = append(conditions, "p.id = ?")
+		args = append(args, id)
+	}
+	if len(conditions) == 0 {
+		return nil
+	}
+
+	products, err := p.getIndexedProducts(ctx, "("+strings.Join(conditions, " OR ")+")", args...)
+	if err != nil {
+		return err
+	}
+
+	created := []*ProductSearch{}
+	updated := []*ProductSearch{}
+	for _, product := range products {
+		if result.newProductIds[product.ID] {
+			created = append(created, product)
+		} else {
+			updated = append(updated, product)
+		}
+	}
+
+	p.publishProductEvents(ctx, ProductCreated, created)
+	p.publishProductEvents(ctx, ProductUpdated, updated)
+
+	return nil
+}
+
+// handleImportPriceList takes a price list as text/csv or JSON. Nothing is
+// written unless apply=true, the response shows the diff either way. Deleted
+// entries are restored only with restore_deleted=true.
+func (p *ProductService) handleImportPriceList(c *fiber.Ctx) error {
+	supplierId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid supplier ID")
+	}
+
+	rows, err := parsePriceList(c.Get(fiber.HeaderContentType), c.Body())
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	if err := validatePriceList(p.validate, rows); err != nil {
+		return err
+	}
+
+	apply := c.QueryBool("apply", false)
+	result, err := importPriceList(c.Context(), p.DB, supplierId, rows, apply, c.QueryBool("restore_deleted", false), shared.GetUserClaims(c).Subject)
+	if err != nil {
+		var fiberErr *fiber.Error
+		if !errors.As(err, &fiberErr) {
+			slog.Error("Failed to import price list", "supplier-id", supplierId, "error", err)
+		}
+		return err
+	}
+
+	if apply {
+		slog.Info("Price list imported", "supplier-id", supplierId, "new", result.New, "changed", result.Changed, "removed", result.Removed,
+			"by", shared.GetUserClaims(c).Subject)
+		if err := p.publishImport(c.Context(), result); err != nil {
+			slog.Error("Failed to publish imported products", "error", err)
+		}
+	}
+
+	message := "Price list checked, nothing was written"
+	if apply {
+		message = "Price list imported successfully"
+	}
+
+	return c.JSON(fiber.Map{
+		"message": message,
+		"data":    result,
+		"errors":  nil,
+	})
+}
+
+// runImportPriceList is the import-price-list command, for price lists too
+// big for a request:
+//
+//	product-service import-price-list -supplier digiflazz -file list.csv [-apply] [-restore-deleted]
+func runImportPriceList(args []string) error {
+	flags := flag.NewFlagSet("import-price-list", flag.ExitOnError)
+	supplierCode := flags.String("supplier", "", "code of the supplier the price list belongs to")
+	file := flags.String("file", "", "price list, .csv or .json")
+	apply := flags.Bool("apply", false, "write the changes instead of only printing the diff")
+	restoreDeleted := flags.Bool("restore-deleted", false, "restore deleted entries the price list still names")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *supplierCode == "" || *file == "" {
+		flags.Usage()
+		return errors.New("-supplier and -file are required")
+	}
+
+	body, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+
+	contentType := fiber.MIMEApplicationJSON
+	if strings.EqualFold(filepath.Ext(*file), ".csv") {
+		contentType = "text/csv"
+	}
+
+	rows, err := parsePriceList(contentType, body)
+	if err != nil {
+		return err
+	}
+
+	if err := validatePriceList(validator.New(), rows); err != nil {
+		var failedValidationError shared.FailedValidationError
+		if errors.As(err, &failedValidationError) {
+			for row, message := range failedValidationError.Errors {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", row, message)
+			}
+		}
+		return err
+	}
+
+	ctx := context.Background()
+	p := &ProductService{DB: shared.GetConnection(), Ctx: ctx}
+	defer p.DB.Close()
+
+	var supplierId int
+	err = p.DB.QueryRowContext(ctx, "SELECT id FROM suppliers WHERE code = ?", *supplierCode).Scan(&supplierId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("supplier %s not found", *supplierCode)
+		}
+		return err
+	}
+
+	result, err := importPriceList(ctx, p.DB, supplierId, rows, *apply, *restoreDeleted, nil)
+	if err != nil {
+		return err
+	}
+
+	if *apply {
+		p.Producer = NewKafkaProducer(os.Getenv("KAFKA_HOST") + ":" + os.Getenv("KAFKA_PORT"))
+		defer p.Producer.Writer.Close()
+		if err := p.publishImport(ctx, result); err != nil {
+			slog.Error("Failed to publish imported products", "error", err)
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	MarginPercentage = "percentage"
+	MarginFixed      = "fixed"
+)
+
+// reasons kept in product_price_history
+const (
+	PriceReasonSupplierCost = "supplier-cost"
+	PriceReasonMarginRule   = "margin-rule"
+	PriceReasonScheduled    = "scheduled"
+)
+
+const (
+	defaultPricingInterval = 15 * time.Minute
+	// selling prices are rounded up to a multiple of this
+	priceRounding        = 100
+	defaultHistoryLimit  = 50
+	maxPriceHistoryLimit = 100
+)
+
+func getPricingInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("PRICING_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return defaultPricingInterval
+	}
+
+	return interval
+}
+
+type marginRule struct {
+	Type      string
+	Value     float64
+	MinProfit int
+}
+
+// sellingPrice puts the margin on top of the cost, never earning less than
+// the minimum profit.
+func sellingPrice(cost int, rule marginRule) int {
+	var profit float64
+	switch rule.Type {
+	case MarginPercentage:
+		profit = float64(cost) * rule.Value / 100
+	case MarginFixed:
+		profit = rule.Value
+	}
+
+	price := int(math.Ceil(float64(cost) + profit))
+	if price-cost < rule.MinProfit {
+		price = cost + rule.MinProfit
+	}
+
+	if remainder := price % priceRounding; remainder != 0 {
+		price += priceRounding - remainder
+	}
+
+	return price
+}
+
+// RunPricing recomputes all prices now and then, picking up supplier changes
+// that did not go through the cost endpoints. It blocks until ctx is done.
+func (p *ProductService) RunPricing(ctx context.Context) {
+	ticker := time.NewTicker(getPricingInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.repriceAndPublish(ctx, PriceReasonScheduled); err != nil {
+				slog.Error("Failed to recompute prices", "error", err)
+			}
+		}
+	}
+}
+
+// recomputePrices sets the selling price of products that have a supplier
+// cost and a margin rule, limited to productIds when given. The cheapest
+// active supplier is the cost. It returns the ids of the changed products.
+func (p *ProductService) recomputePrices(ctx context.Context, reason string, productIds ...int) (changed []int, err error) {
+	query := `SELECT p.id, p.price, MIN(sp.cost),
+					COALESCE(orl.type, crl.type), COALESCE(orl.value, crl.value), COALESCE(orl.min_profit, crl.min_profit)
+				FROM products p
+					JOIN product_types pt ON pt.id = p.product_type_id
+					JOIN operators o ON o.id = pt.operator_id
+					JOIN supplier_products sp ON sp.product_id = p.id
+					JOIN suppliers s ON s.id = sp.supplier_id AND s.status = 'active'
+					LEFT JOIN margin_rules orl ON orl.operator_id = o.id
+					LEFT JOIN margin_rules crl ON crl.category_id = o.category_id
+				WHERE p.deleted_at IS NULL AND (orl.id IS NOT NULL OR crl.id IS NOT NULL)`
+	args := []any{}
+	if len(productIds) > 0 {
+		placeholders := make([]string, 0, len(productIds))
+		for _, id := range productIds {
+			placeholders = append(placeholders, "?")
+			args = append(args, id)
+		}
+		query += " AND p.id IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+	query += " GROUP BY p.id, p.price, orl.type, orl.value, orl.min_profit, crl.type, crl.value, crl.min_profit"
+
+	type priceChange struct {
+		productId int
+		oldPrice  int
+		newPrice  int
+		cost      int
+	}
+
+	rows, err := p.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.Error("Failed to query product costs", "error", err)
+		return nil, err
+	}
+
+	changes := []priceChange{}
+	for rows.Next() {
+		var change priceChange
+		var rule marginRule
+		if err := rows.Scan(&change.productId, &change.oldPrice, &change.cost, &rule.Type, &rule.Value, &rule.MinProfit); err != nil {
+			rows.Close()
+			slog.Error("Failed to scan product cost row", "error", err)
+			return nil, err
+		}
+
+		change.newPrice = sellingPrice(change.cost, rule)
+		if change.newPrice != change.oldPrice {
+			changes = append(changes, change)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	tx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		slog.Error("Failed to begin transaction", "error", err)
+		return nil, err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	for _, change := range changes {
+		// the old price guards against a concurrent run or a manual edit
+		var result sql.Result
+		result, err = tx.ExecContext(ctx, "UPDATE products SET price = ? WHERE id = ? AND price = ?", change.newPrice, change.productId, change.oldPrice)
+		if err != nil {
+			slog.Error("Failed to update product price", "error", err)
+			return nil, err
+		}
+		if updated, _ := result.RowsAffected(); updated == 0 {
+			continue
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO product_price_history (product_id, old_price, new_price, cost, reason) VALUES (?, ?, ?, ?, ?)",
+			change.productId, change.oldPrice, change.newPrice, change.cost, reason)
+		if err != nil {
+			slog.Error("Failed to insert price history", "error", err)
+			return nil, err
+		}
+
+		changed = append(changed, change.productId)
+	}
+
+	slog.Info("Prices recomputed", "reason", reason, "changed", len(changed))
+
+	return changed, nil
+}
+
+// publishPriceChanges sends the repriced products to the search index. It
+// runs after recomputePrices committed.
+func (p *ProductService) publishPriceChanges(ctx context.Context, productIds []int) {
+	if len(productIds) == 0 {
+		return
+	}
+
+	placeholders := make([]string, 0, len(productIds))
+	args := make([]any, 0, len(productIds))
+	for _, id := range productIds {
+		placeholders = append(placeholders, "?")
+		args = append(args, id)
+	}
+
+	p.reindexProducts(ctx, "p.id IN ("+strings.Join(placeholders, ", ")+")", args...)
+}
+
+func (p *ProductService) repriceAndPublish(ctx context.Context, reason string, productIds ...int) error {
+	changed, err := p.recomputePrices(ctx, reason, productIds...)
+	if err != nil {
+		return err
+	}
+
+	p.publishPriceChanges(ctx, changed)
+	return nil
+}
+
+func (p *ProductService) handleGetSuppliers(c *fiber.Ctx) error {
+	rows, err := p.DB.QueryContext(c.Context(), "SELECT id, code, name, status, created_at, updated_at FROM suppliers ORDER BY id")
+	if err != nil {
+		slog.Error("Failed to query suppliers", "error", err)
+		return err
+	}
+	defer rows.Close()
+
+	suppliers := []Supplier{}
+	for rows.Next() {
+		var supplier Supplier
+		if err := rows.Scan(&supplier.Id, &supplier.Code, &supplier.Name, &supplier.Status, &supplier.CreatedAt, &supplier.UpdatedAt); err != nil {
+			slog.Error("Failed to scan supplier row", "error", err)
+			return err
+		}
+
+		health, err := getSupplierHealth(c.Context(), p.RedisClient, supplier.Id)
+		if err != nil {
+			slog.Error("Failed to read supplier health", "error", err)
+		}
+		supplier.Health = health
+		suppliers = append(suppliers, supplier)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Suppliers retrieved successfully",
+		"data":    suppliers,
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleCreateSupplier(c *fiber.Ctx) error {
+	request, err := parseCatalogRequest[SupplierRequest](c, p.validate)
+	if err != nil {
+		return err
+	}
+
+	result, err := p.DB.ExecContext(c.Context(), "INSERT INTO suppliers (code, name, status) VALUES (?, ?, ?)", request.Code, request.Name, request.Status)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return fiber.NewError(fiber.StatusConflict, "Supplier code is already taken")
+		}
+		slog.Error("Failed to insert supplier", "error", err)
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Supplier created successfully",
+		"data":    fiber.Map{"id": id},
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleUpdateSupplier(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid supplier ID")
+	}
+
+	request, err := parseCatalogRequest[SupplierRequest](c, p.validate)
+	if err != nil {
+		return err
+	}
+
+	result, err := p.DB.ExecContext(c.Context(), "UPDATE suppliers SET code = ?, name = ?, status = ? WHERE id = ?", request.Code, request.Name, request.Status, id)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return fiber.NewError(fiber.StatusConflict, "Supplier code is already taken")
+		}
+		slog.Error("Failed to update supplier", "error", err)
+		return err
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if updated == 0 {
+		// an unchanged row is not counted either, tell it from a missing one
+		var exists bool
+		err := p.DB.QueryRowContext(c.Context(), "SELECT EXISTS(SELECT 1 FROM suppliers WHERE id = ?)", id).Scan(&exists)
+		if err != nil {
+			slog.Error("Failed to query supplier", "error", err)
+			return err
+		}
+		if !exists {
+			return fiber.NewError(fiber.StatusNotFound, "Supplier not found")
+		}
+	} else {
+		// the cheapest supplier may have been switched off or back on
+		if err := p.repriceAndPublish(c.Context(), PriceReasonSupplierCost); err != nil {
+			return err
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Supplier updated successfully",
+		"data":    fiber.Map{"id": id},
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleSetSupplierCost(c *fiber.Ctx) error {
+	productId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+	supplierId, err := strconv.Atoi(c.Params("supplierId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid supplier ID")
+	}
+
+	request, err := parseCatalogRequest[SupplierCostRequest](c, p.validate)
+	if err != nil {
+		return err
+	}
+
+	if err := p.requireActive(c.Context(), "products", productId, fiber.StatusNotFound, "Product not found"); err != nil {
+		return err
+	}
+
+	_, err = p.DB.ExecContext(c.Context(), `INSERT INTO supplier_products (supplier_id, product_id, supplier_sku, cost, priority) VALUES (?, ?, ?, ?, ?)
+				ON DUPLICATE KEY UPDATE supplier_sku = VALUES(supplier_sku), cost = VALUES(cost), priority = VALUES(priority)`,
+		supplierId, productId, request.SupplierSku, request.Cost, request.Priority)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1452 {
+			return fiber.NewError(fiber.StatusNotFound, "Supplier not found")
+		}
+		slog.Error("Failed to save supplier cost", "error", err)
+		return err
+	}
+
+	if err := p.repriceAndPublish(c.Context(), PriceReasonSupplierCost, productId); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Supplier cost saved successfully",
+		"data": fiber.Map{
+			"product_id":   productId,
+			"supplier_id":  supplierId,
+			"supplier_sku": request.SupplierSku,
+			"cost":         request.Cost,
+			"priority":     request.Priority,
+		},
+		"errors": nil,
+	})
+}
+
+func (p *ProductService) handleDeleteSupplierCost(c *fiber.Ctx) error {
+	productId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+	supplierId, err := strconv.Atoi(c.Params("supplierId"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid supplier ID")
+	}
+
+	result, err := p.DB.ExecContext(c.Context(), "DELETE FROM supplier_products WHERE supplier_id = ? AND product_id = ?", supplierId, productId)
+	if err != nil {
+		slog.Error("Failed to delete supplier cost", "error", err)
+		return err
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "Supplier cost not found")
+	}
+
+	if err := p.repriceAndPublish(c.Context(), PriceReasonSupplierCost, productId); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Supplier cost deleted successfully",
+		"data":    nil,
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleSetCategoryMarginRule(c *fiber.Ctx) error {
+	return p.setMarginRule(c, "category_id")
+}
+
+func (p *ProductService) handleSetOperatorMarginRule(c *fiber.Ctx) error {
+	return p.setMarginRule(c, "operator_id")
+}
+
+func (p *ProductService) handleDeleteCategoryMarginRule(c *fiber.Ctx) error {
+	return p.deleteMarginRule(c, "category_id")
+}
+
+func (p *ProductService) handleDeleteOperatorMarginRule(c *fiber.Ctx) error {
+	return p.deleteMarginRule(c, "operator_id")
+}
+
+// setMarginRule stores the margin of a category or operator and reprices
+// right away, column names which one the id in the path refers to.
+func (p *ProductService) setMarginRule(c *fiber.Ctx, column string) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid ID")
+	}
+
+	ruleRequest := &MarginRuleRequest{}
+	if err := c.BodyParser(ruleRequest); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err = p.validate.Struct(ruleRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*ruleRequest, err.(validator.ValidationErrors))
+	}
+
+	if ruleRequest.Type == MarginPercentage && ruleRequest.Value > 100 {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "Percentage margin is at most 100")
+	}
+
+	query := "INSERT INTO margin_rules (" + column + `, type, value, min_profit) VALUES (?, ?, ?, ?)
+				ON DUPLICATE KEY UPDATE type = VALUES(type), value = VALUES(value), min_profit = VALUES(min_profit)`
+	_, err = p.DB.ExecContext(c.Context(), query, id, ruleRequest.Type, ruleRequest.Value, ruleRequest.MinProfit)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1452 {
+			return fiber.NewError(fiber.StatusNotFound, "Category or operator not found")
+		}
+		slog.Error("Failed to save margin rule", "error", err)
+		return err
+	}
+
+	slog.Info("Margin rule saved", column, id, "type", ruleRequest.Type, "value", ruleRequest.Value, "by", shared.GetUserClaims(c).Subject)
+
+	if err := p.repriceAndPublish(c.Context(), PriceReasonMarginRule); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Margin rule saved successfully",
+		"data": fiber.Map{
+			column:       id,
+			"type":       ruleRequest.Type,
+			"value":      ruleRequest.Value,
+			"min_profit": ruleRequest.MinProfit,
+		},
+		"errors": nil,
+	})
+}
+
+// deleteMarginRule stops automatic pricing, the current prices stay.
+func (p *ProductService) deleteMarginRule(c *fiber.Ctx, column string) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid ID")
+	}
+
+	result, err := p.DB.ExecContext(c.Context(), "DELETE FROM margin_rules WHERE "+column+" = ?", id)
+	if err != nil {
+		slog.Error("Failed to delete margin rule", "error", err)
+		return err
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "Margin rule not found")
+	}
+
+	// an operator rule may have hidden the category rule
+	if column == "operator_id" {
+		if err := p.repriceAndPublish(c.Context(), PriceReasonMarginRule); err != nil {
+			return err
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Margin rule deleted successfully",
+		"data":    nil,
+		"errors":  nil,
+	})
+}
+
+func (p *ProductService) handleGetPriceHistory(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	limit := c.QueryInt("limit", defaultHistoryLimit)
+	if limit < 1 || limit > maxPriceHistoryLimit {
+		limit = defaultHistoryLimit
+	}
+
+	rows, err := p.DB.QueryContext(c.Context(), `SELECT id, product_id, old_price, new_price, cost, reason, created_at FROM product_price_history
+				WHERE product_id = ? ORDER BY id DESC LIMIT ?`, id, limit)
+	if err != nil {
+		slog.Error("Failed to query price history", "error", err)
+		return err
+	}
+	defer rows.Close()
+
+	history := []PriceChange{}
+	for rows.Next() {
+		var change PriceChange
+		if err := rows.Scan(&change.Id, &change.ProductId, &change.OldPrice, &change.NewPrice, &change.Cost, &change.Reason, &change.CreatedAt); err != nil {
+			slog.Error("Failed to scan price history row", "error", err)
+			return err
+		}
+		history = append(history, change)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Price history retrieved successfully",
+		"data":    history,
+		"errors":  nil,
+	})
+}
@@ -1,22 +1,20 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
-	"encoding/json"
 	"errors"
-	"io"
 	"log/slog"
 	"math"
 	"strconv"
+	"time"
 
 	ipb "github.com/akmmp241/topupstore-microservice/indexer-proto/v1"
 	"github.com/akmmp241/topupstore-microservice/shared"
 	"github.com/elastic/go-elasticsearch/v8"
-	"github.com/elastic/go-elasticsearch/v8/esapi"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
 )
 
 type Map map[string]interface{}
@@ -27,10 +25,13 @@ type ProductService struct {
 	EsClient       *elasticsearch.Client
 	Ctx            context.Context
 	IndexerService *ipb.IndexerServiceClient
+	Producer       *KafkaProducer
+	RedisClient    *redis.Client
+	Inquirers      map[string]AccountInquirer
 }
 
-func NewProductService(validate *validator.Validate, DB *sql.DB, IndexService *ipb.IndexerServiceClient, esClient *elasticsearch.Client) *ProductService {
-	return &ProductService{validate: validate, DB: DB, Ctx: context.Background(), IndexerService: IndexService, EsClient: esClient}
+func NewProductService(validate *validator.Validate, DB *sql.DB, IndexService *ipb.IndexerServiceClient, esClient *elasticsearch.Client, producer *KafkaProducer, redisClient *redis.Client) *ProductService {
+	return &ProductService{validate: validate, DB: DB, Ctx: context.Background(), IndexerService: IndexService, EsClient: esClient, Producer: producer, RedisClient: redisClient, Inquirers: NewAccountInquirers()}
 }
 
 func (p *ProductService) RegisterRoutes(route fiber.Router) {
@@ -38,15 +39,51 @@ func (p *ProductService) RegisterRoutes(route fiber.Router) {
 	route.Get("/categories/:id", p.handleGetCategoryByID)
 	route.Get("/categories/:id/operators", p.handleGetOperatorsByCategoryID)
 	route.Get("/operators", p.handleGetOperators)
+	route.Get("/operators/detect", p.handleDetectOperator)
 	route.Get("/operators/:id", p.handleGetOperatorByID)
 	route.Get("/operators/:id/product-types", p.handleGetProductTypesByOperatorID)
 	route.Get("/product-types", p.handleGetProductTypes)
 	route.Get("/product-types/:id", p.handleGetProductTypeByID)
 	route.Get("/product-types/:id/products", p.handleGetProductsByProductTypeID)
+	route.Post("/product-types/:id/inquiry", p.handleAccountInquiry)
 	route.Get("/products", p.handleGetProducts)
+	route.Get("/products/suggest", p.handleSuggestProducts)
 	route.Get("/products/:id", p.handleGetProductByID)
 
-	route.Get("/products-index", shared.DevOnlyMiddleware, p.handleProductIndexingToES)
+	route.Post("/categories", shared.RequirePermission(shared.PermCatalogWrite), p.handleCreateCategory)
+	route.Put("/categories/:id", shared.RequirePermission(shared.PermCatalogWrite), p.handleUpdateCategory)
+	route.Delete("/categories/:id", shared.RequirePermission(shared.PermCatalogWrite), p.handleDeleteCategory)
+	route.Post("/operators", shared.RequirePermission(shared.PermCatalogWrite), p.handleCreateOperator)
+	route.Put("/operators/:id", shared.RequirePermission(shared.PermCatalogWrite), p.handleUpdateOperator)
+	route.Delete("/operators/:id", shared.RequirePermission(shared.PermCatalogWrite), p.handleDeleteOperator)
+	route.Post("/product-types", shared.RequirePermission(shared.PermCatalogWrite), p.handleCreateProductType)
+	route.Put("/product-types/:id", shared.RequirePermission(shared.PermCatalogWrite), p.handleUpdateProductType)
+	route.Delete("/product-types/:id", shared.RequirePermission(shared.PermCatalogWrite), p.handleDeleteProductType)
+	route.Post("/products", shared.RequirePermission(shared.PermCatalogWrite), p.handleCreateProduct)
+	route.Put("/products/:id", shared.RequirePermission(shared.PermCatalogWrite), p.handleUpdateProduct)
+	route.Delete("/products/:id", shared.RequirePermission(shared.PermCatalogWrite), p.handleDeleteProduct)
+
+	route.Get("/products-index", shared.RequirePermission(shared.PermCatalogWrite), p.handleProductIndexingToES)
+	route.Put("/operators/:id/prefixes", shared.RequirePermission(shared.PermCatalogWrite), p.handleUpdateOperatorPrefixes)
+	route.Put("/products/:id/availability", shared.RequirePermission(shared.PermCatalogWrite), p.handleUpdateProductAvailability)
+	route.Put("/operators/:id/availability", shared.RequirePermission(shared.PermCatalogWrite), p.handleUpdateOperatorAvailability)
+	route.Get("/suppliers", shared.RequirePermission(shared.PermCatalogWrite), p.handleGetSuppliers)
+	route.Post("/suppliers", shared.RequirePermission(shared.PermCatalogWrite), p.handleCreateSupplier)
+	route.Put("/suppliers/:id", shared.RequirePermission(shared.PermCatalogWrite), p.handleUpdateSupplier)
+	route.Post("/suppliers/:id/price-list", shared.RequirePermission(shared.PermCatalogWrite), p.handleImportPriceList)
+	route.Put("/products/:id/supplier-costs/:supplierId", shared.RequirePermission(shared.PermCatalogWrite), p.handleSetSupplierCost)
+	route.Delete("/products/:id/supplier-costs/:supplierId", shared.RequirePermission(shared.PermCatalogWrite), p.handleDeleteSupplierCost)
+	route.Put("/products/:id/routing-policy", shared.RequirePermission(shared.PermCatalogWrite), p.handleUpdateRoutingPolicy)
+	route.Get("/products/:id/price-history", shared.RequirePermission(shared.PermCatalogWrite), p.handleGetPriceHistory)
+	route.Put("/categories/:id/margin-rule", shared.RequirePermission(shared.PermCatalogWrite), p.handleSetCategoryMarginRule)
+	route.Delete("/categories/:id/margin-rule", shared.RequirePermission(shared.PermCatalogWrite), p.handleDeleteCategoryMarginRule)
+	route.Put("/operators/:id/margin-rule", shared.RequirePermission(shared.PermCatalogWrite), p.handleSetOperatorMarginRule)
+	route.Delete("/operators/:id/margin-rule", shared.RequirePermission(shared.PermCatalogWrite), p.handleDeleteOperatorMarginRule)
+	route.Put("/products/:id/tier-prices", shared.RequirePermission(shared.PermCatalogWrite), p.handleUpdateTierPrices)
+	route.Put("/categories/:id/loyalty-rule", shared.RequirePermission(shared.PermCatalogWrite), p.handleSetCategoryLoyaltyRule)
+	route.Delete("/categories/:id/loyalty-rule", shared.RequirePermission(shared.PermCatalogWrite), p.handleDeleteCategoryLoyaltyRule)
+	route.Put("/products/:id/loyalty-rule", shared.RequirePermission(shared.PermCatalogWrite), p.handleSetProductLoyaltyRule)
+	route.Delete("/products/:id/loyalty-rule", shared.RequirePermission(shared.PermCatalogWrite), p.handleDeleteProductLoyaltyRule)
 }
 
 func (p *ProductService) handleGetCategories(c *fiber.Ctx) error {
@@ -75,7 +112,7 @@ func (p *ProductService) handleGetCategories(c *fiber.Ctx) error {
 	}
 	defer shared.CommitOrRollback(tx, err)
 
-	query := "SELECT id, ref_id, name, created_at, updated_at FROM categories WHERE id > ? ORDER BY id LIMIT ?"
+	query := "SELECT id, ref_id, name, created_at, updated_at FROM categories WHERE id > ? AND deleted_at IS NULL ORDER BY id LIMIT ?"
 
 	rows, err := p.DB.QueryContext(p.Ctx, query, afterID, limit)
 	if err != nil {
@@ -131,7 +168,7 @@ func (p *ProductService) handleGetCategoryByID(c *fiber.Ctx) error {
 	}
 	defer shared.CommitOrRollback(tx, err)
 
-	query := "SELECT id, ref_id, name, created_at, updated_at FROM categories WHERE id = ?"
+	query := "SELECT id, ref_id, name, created_at, updated_at FROM categories WHERE id = ? AND deleted_at IS NULL"
 	row := p.DB.QueryRowContext(p.Ctx, query, id)
 
 	var category Category
@@ -169,7 +206,7 @@ func (p *ProductService) handleGetOperatorsByCategoryID(c *fiber.Ctx) error {
 	}
 	defer shared.CommitOrRollback(tx, err)
 
-	query := "SELECT id, ref_id, category_id, name, slug, image_url, description, created_at, updated_at FROM operators WHERE category_id = ?"
+	query := "SELECT id, ref_id, category_id, name, slug, image_url, description, created_at, updated_at FROM operators WHERE category_id = ? AND deleted_at IS NULL"
 	rows, err := p.DB.QueryContext(p.Ctx, query, id)
 	if err != nil {
 		slog.Error("Failed to query operators", "error", err)
@@ -222,7 +259,7 @@ func (p *ProductService) handleGetOperators(c *fiber.Ctx) error {
 	}
 	defer shared.CommitOrRollback(tx, err)
 
-	query := "SELECT id, ref_id, category_id, name, slug, image_url, description, created_at, updated_at FROM operators WHERE id > ? ORDER BY id LIMIT ?"
+	query := "SELECT id, ref_id, category_id, name, slug, image_url, description, created_at, updated_at FROM operators WHERE id > ? AND deleted_at IS NULL ORDER BY id LIMIT ?"
 
 	rows, err := p.DB.QueryContext(p.Ctx, query, afterID, limit)
 	if err != nil {
@@ -278,7 +315,7 @@ func (p *ProductService) handleGetOperatorByID(c *fiber.Ctx) error {
 	}
 	defer shared.CommitOrRollback(tx, err)
 
-	query := "SELECT id, ref_id, category_id, name, slug, image_url, description, created_at, updated_at FROM operators WHERE id = ?"
+	query := "SELECT id, ref_id, category_id, name, slug, image_url, description, created_at, updated_at FROM operators WHERE id = ? AND deleted_at IS NULL"
 	row := p.DB.QueryRowContext(p.Ctx, query, id)
 
 	var operator Operator
@@ -316,7 +353,7 @@ func (p *ProductService) handleGetProductTypesByOperatorID(c *fiber.Ctx) error {
 	}
 	defer shared.CommitOrRollback(tx, err)
 
-	query := "SELECT id, ref_id, operator_id, name, format_form, created_at, updated_at FROM product_types WHERE operator_id = ?"
+	query := "SELECT id, ref_id, operator_id, name, format_form, created_at, updated_at FROM product_types WHERE operator_id = ? AND deleted_at IS NULL"
 	rows, err := p.DB.QueryContext(p.Ctx, query, id)
 	if err != nil {
 		slog.Error("Failed to query product types", "error", err)
@@ -369,7 +406,7 @@ func (p *ProductService) handleGetProductTypes(c *fiber.Ctx) error {
 	}
 	defer shared.CommitOrRollback(tx, err)
 
-	query := "SELECT id, ref_id, operator_id, name, format_form, created_at, updated_at FROM product_types WHERE id > ? ORDER BY id LIMIT ?"
+	query := "SELECT id, ref_id, operator_id, name, format_form, created_at, updated_at FROM product_types WHERE id > ? AND deleted_at IS NULL ORDER BY id LIMIT ?"
 
 	rows, err := p.DB.QueryContext(p.Ctx, query, afterID, limit)
 	if err != nil {
@@ -425,7 +462,7 @@ func (p *ProductService) handleGetProductTypeByID(c *fiber.Ctx) error {
 	}
 	defer shared.CommitOrRollback(tx, err)
 
-	query := "SELECT id, ref_id, operator_id, name, format_form, created_at, updated_at FROM product_types WHERE id = ?"
+	query := "SELECT id, ref_id, operator_id, name, format_form, created_at, updated_at FROM product_types WHERE id = ? AND deleted_at IS NULL"
 	row := p.DB.QueryRowContext(p.Ctx, query, id)
 
 	var productType ProductType
@@ -437,6 +474,12 @@ func (p *ProductService) handleGetProductTypeByID(c *fiber.Ctx) error {
 		return err
 	}
 
+	form, err := shared.ParseFormSchema(productType.FormatForm)
+	if err != nil {
+		slog.Warn("Malformed format form", "product-type-id", productType.Id, "error", err)
+	}
+	productType.Form = form
+
 	return c.JSON(fiber.Map{
 		"message": "Product type retrieved successfully",
 		"data":    productType,
@@ -463,7 +506,10 @@ func (p *ProductService) handleGetProductsByProductTypeID(c *fiber.Ctx) error {
 	}
 	defer shared.CommitOrRollback(tx, err)
 
-	query := "SELECT id, ref_id, product_type_id, name, description, image_url, created_at, updated_at FROM products WHERE product_type_id = ?"
+	query := `SELECT p.id, p.ref_id, p.product_type_id, p.name, p.description, p.image_url, p.created_at, p.updated_at
+				FROM products p JOIN product_types pt ON pt.id = p.product_type_id
+				JOIN operators o ON o.id = pt.operator_id
+				WHERE p.product_type_id = ? AND p.deleted_at IS NULL AND ` + listedProductsCondition
 	rows, err := p.DB.QueryContext(p.Ctx, query, id)
 	if err != nil {
 		slog.Error("Failed to query products", "error", err)
@@ -490,24 +536,35 @@ func (p *ProductService) handleGetProductsByProductTypeID(c *fiber.Ctx) error {
 	})
 }
 
+// handleGetProducts pages by page and size. A client opts into cursor paging
+// with pagination=cursor and follows next_cursor from there on, only those
+// requests hold a point in time open.
 func (p *ProductService) handleGetProducts(c *fiber.Ctx) error {
-	query := c.Query("q", "")
 	pageStr := c.Query("page", "1")
 	sizeStr := c.Query("size", "10")
 
+	size, err := strconv.Atoi(sizeStr)
+	if err != nil || size < 1 || size > 100 {
+		size = 10
+	}
+
+	if c.Query("cursor") != "" || c.Query("pagination") == "cursor" {
+		return p.getProductsByCursor(c, size)
+	}
+
 	page, err := strconv.Atoi(pageStr)
 	if err != nil || page < 1 {
 		page = 1
 	}
 
-	size, err := strconv.Atoi(sizeStr)
-	if err != nil || size < 1 || size > 100 {
-		size = 10
+	params, err := parseProductSearchParams(c)
+	if err != nil {
+		return err
 	}
+	params.From = (page - 1) * size
+	params.Size = size
 
-	from := (page - 1) * size
-
-	esRes, err := p.searchProducts(c.Context(), query, from, size)
+	esRes, err := p.searchProducts(c.Context(), params)
 	if err != nil {
 		return err
 	}
@@ -517,6 +574,10 @@ func (p *ProductService) handleGetProducts(c *fiber.Ctx) error {
 		products = append(products, &hit.Source)
 	}
 
+	if err := p.applyTierPrices(c.Context(), products, requestTier(c)); err != nil {
+		return err
+	}
+
 	totalHits := esRes.Hits.Total.Value
 	totalPages := int(math.Ceil(float64(totalHits) / float64(size)))
 
@@ -524,11 +585,13 @@ func (p *ProductService) handleGetProducts(c *fiber.Ctx) error {
 		"message": "Products retrieved successfully",
 		"data": fiber.Map{
 			"products": products,
+			"facets":   esRes.facets(params.PriceInterval),
 			"meta": fiber.Map{
 				"total_hits":   totalHits,
 				"total_pages":  totalPages,
 				"current_page": page,
 				"size":         size,
+				"sort":         params.Sort,
 			},
 		},
 		"errors": nil,
@@ -554,11 +617,16 @@ func (p *ProductService) handleGetProductByID(c *fiber.Ctx) error {
 	}
 	defer shared.CommitOrRollback(tx, err)
 
-	query := "SELECT id, ref_id, product_type_id, name, description, image_url, price, created_at, updated_at FROM products WHERE id = ?"
-	row := p.DB.QueryRowContext(p.Ctx, query, id)
+	query := `SELECT p.id, p.ref_id, p.product_type_id, p.name, p.description, p.image_url, COALESCE(tp.price, p.price), p.price, p.created_at, p.updated_at, ` + availabilityColumns + `
+				FROM products p JOIN product_types pt ON pt.id = p.product_type_id
+				JOIN operators o ON o.id = pt.operator_id
+				LEFT JOIN product_tier_prices tp ON tp.product_id = p.id AND tp.tier = ? WHERE p.id = ? AND p.deleted_at IS NULL`
+	row := p.DB.QueryRowContext(p.Ctx, query, requestTier(c), id)
 
 	var product Product
-	if err := row.Scan(&product.Id, &product.RefId, &product.ProductTypeId, &product.Name, &product.Description, &product.ImageUrl, &product.Price, &product.CreatedAt, &product.UpdatedAt); err != nil {
+	var availabilityRow availabilityRow
+	dest := []any{&product.Id, &product.RefId, &product.ProductTypeId, &product.Name, &product.Description, &product.ImageUrl, &product.Price, &product.BasePrice, &product.CreatedAt, &product.UpdatedAt}
+	if err := row.Scan(append(dest, availabilityRow.scanTargets()...)...); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return fiber.NewError(fiber.StatusNotFound, "Product not found")
 		}
@@ -566,6 +634,11 @@ func (p *ProductService) handleGetProductByID(c *fiber.Ctx) error {
 		return err
 	}
 
+	if product.BasePrice == product.Price {
+		product.BasePrice = 0
+	}
+	product.Availability = availabilityRow.resolve(time.Now())
+
 	return c.JSON(fiber.Map{
 		"message": "Product retrieved successfully",
 		"data":    product,
@@ -589,15 +662,15 @@ func (p *ProductService) handleProductIndexingToES(c *fiber.Ctx) error {
 
 	query := `
 		SELECT
-			p.id, p.name, p.image_url, p.price, p.description,
+			p.id, p.name, p.image_url, p.price, p.description, p.created_at,
 			pt.id as type_id, pt.name as type_name,
 			o.id as operator_id, o.name as operator_name, o.slug as operator_slug, o.image_url as operator_image_url,
 			c.id as category_id, c.name as category_name
 		FROM products p
 			JOIN product_types pt ON p.product_type_id = pt.id
 			JOIN operators o ON pt.operator_id = o.id
-			JOIN categories c ON o.category_id = c.id;
-`
+			JOIN categories c ON o.category_id = c.id
+		WHERE p.deleted_at IS NULL AND ` + listedProductsCondition
 
 	rows, err := p.DB.QueryContext(p.Ctx, query)
 	if err != nil {
@@ -614,8 +687,9 @@ func (p *ProductService) handleProductIndexingToES(c *fiber.Ctx) error {
 		product.Operator = &ipb.Operator{}
 		product.Category = &ipb.Category{}
 
+		var createdAt time.Time
 		err = rows.Scan(
-			&product.Id, &product.Name, &product.ImageUrl, &product.Price, &product.Description,
+			&product.Id, &product.Name, &product.ImageUrl, &product.Price, &product.Description, &createdAt,
 			&product.ProductType.Id, &product.ProductType.Name,
 			&product.Operator.Id, &product.Operator.Name, &product.Operator.Slug, &product.Operator.ImageUrl,
 			&product.Category.Id, &product.Category.Name,
@@ -624,6 +698,7 @@ func (p *ProductService) handleProductIndexingToES(c *fiber.Ctx) error {
 			slog.Error("Failed to scan product row", "error", err)
 			return err
 		}
+		product.CreatedAt = createdAt.Format(time.RFC3339)
 
 		if err := stream.Send(&product); err != nil {
 			slog.Error("Failed to send product to stream", "error", err)
@@ -648,56 +723,3 @@ func (p *ProductService) handleProductIndexingToES(c *fiber.Ctx) error {
 		"errors": nil,
 	})
 }
-
-func (p *ProductService) searchProducts(ctx context.Context, query string, from, size int) (*EsResponse, error) {
-	var response *EsResponse
-	var reqReader io.Reader = nil
-	var searchQuery = Map{}
-
-	if query != "" {
-		searchQuery = Map{
-			"query": Map{
-				"multi_match": Map{
-					"query":     query,
-					"fields":    []string{"name", "description"},
-					"fuzziness": "AUTO",
-				},
-			},
-		}
-	}
-
-	searchQuery["from"] = from
-	searchQuery["size"] = size
-
-	reqBuf, err := json.Marshal(searchQuery)
-	if err != nil {
-		slog.Error("Error occurred while marshaling request", "error", err)
-		return nil, err
-	}
-
-	reqReader = bytes.NewReader(reqBuf)
-
-	searchReq := esapi.SearchRequest{
-		Index:          []string{"products"},
-		Body:           reqReader,
-		TrackTotalHits: true,
-	}
-
-	res, err := searchReq.Do(ctx, p.EsClient)
-	if err != nil {
-		slog.Error("Error occurred while searching products", "error", err)
-		return nil, err
-	}
-
-	if res.IsError() {
-		slog.Error("Error occurred while searching products", "error", res.String())
-		return nil, errors.New(res.String())
-	}
-
-	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
-		slog.Error("Error occurred while decoding response", "error", err)
-		return nil, err
-	}
-
-	return response, nil
-}
@@ -0,0 +1,497 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	SortRelevance = "relevance"
+	SortPriceAsc  = "price_asc"
+	SortPriceDesc = "price_desc"
+	SortNewest    = "newest"
+)
+
+const (
+	defaultPriceInterval = 10000
+	maxFacetBuckets      = 50
+	// how long a cursor stays usable after its last page was read
+	pitKeepAlive = "5m"
+)
+
+// ProductSearchParams are the filters of GET /products. Prices filter the
+// retail price the index holds, not a tier price.
+type ProductSearchParams struct {
+	Query         string `json:"q,omitempty"`
+	CategoryId    int    `json:"category_id,omitempty"`
+	OperatorId    int    `json:"operator_id,omitempty"`
+	ProductTypeId int    `json:"product_type_id,omitempty"`
+	MinPrice      *int   `json:"min_price,omitempty"`
+	MaxPrice      *int   `json:"max_price,omitempty"`
+	Sort          string `json:"sort"`
+	PriceInterval int    `json:"price_interval"`
+	From          int    `json:"-"`
+	Size          int    `json:"-"`
+	// PitId and After page through a point in time instead of From
+	PitId string            `json:"-"`
+	After []json.RawMessage `json:"-"`
+}
+
+// productCursor is what next_cursor holds. The filters travel with it, so
+// every page of a cursor searches the same way.
+type productCursor struct {
+	PitId string `json:"pit"`
+	// After holds the sort values of the last hit as Elasticsearch sent
+	// them, large numbers would lose precision as floats
+	After  []json.RawMessage   `json:"after"`
+	Params ProductSearchParams `json:"params"`
+}
+
+func encodeProductCursor(cursor *productCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeProductCursor(value string) (*productCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid 'cursor' parameter")
+	}
+
+	cursor := &productCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil || cursor.PitId == "" || len(cursor.After) == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid 'cursor' parameter")
+	}
+
+	return cursor, nil
+}
+
+func parseIdParam(c *fiber.Ctx, name string) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 1 {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "Invalid '"+name+"' parameter")
+	}
+
+	return id, nil
+}
+
+func parsePriceParam(c *fiber.Ctx, name string) (*int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	price, err := strconv.Atoi(value)
+	if err != nil || price < 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid '"+name+"' parameter")
+	}
+
+	return &price, nil
+}
+
+func parseProductSearchParams(c *fiber.Ctx) (*ProductSearchParams, error) {
+	params := &ProductSearchParams{Query: c.Query("q", ""), Sort: c.Query("sort", SortRelevance)}
+
+	var err error
+	if params.CategoryId, err = parseIdParam(c, "category_id"); err != nil {
+		return nil, err
+	}
+	if params.OperatorId, err = parseIdParam(c, "operator_id"); err != nil {
+		return nil, err
+	}
+	if params.ProductTypeId, err = parseIdParam(c, "product_type_id"); err != nil {
+		return nil, err
+	}
+	if params.MinPrice, err = parsePriceParam(c, "min_price"); err != nil {
+		return nil, err
+	}
+	if params.MaxPrice, err = parsePriceParam(c, "max_price"); err != nil {
+		return nil, err
+	}
+	if params.MinPrice != nil && params.MaxPrice != nil && *params.MinPrice > *params.MaxPrice {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "'min_price' cannot be above 'max_price'")
+	}
+
+	switch params.Sort {
+	case SortRelevance, SortPriceAsc, SortPriceDesc, SortNewest:
+	default:
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid 'sort' parameter")
+	}
+
+	params.PriceInterval = c.QueryInt("price_interval", defaultPriceInterval)
+	if params.PriceInterval < 1 {
+		params.PriceInterval = defaultPriceInterval
+	}
+
+	return params, nil
+}
+
+// filters returns the filter of every facet dimension that is set, keyed by
+// dimension so a facet can leave out its own filter.
+func (s *ProductSearchParams) filters() map[string]Map {
+	filters := map[string]Map{}
+
+	if s.CategoryId > 0 {
+		filters["category"] = Map{"term": Map{"category.id": strconv.Itoa(s.CategoryId)}}
+	}
+	if s.OperatorId > 0 {
+		filters["operator"] = Map{"term": Map{"operator.id": strconv.Itoa(s.OperatorId)}}
+	}
+	if s.ProductTypeId > 0 {
+		filters["product_type"] = Map{"term": Map{"product_type.id": strconv.Itoa(s.ProductTypeId)}}
+	}
+
+	if s.MinPrice != nil || s.MaxPrice != nil {
+		priceRange := Map{}
+		if s.MinPrice != nil {
+			priceRange["gte"] = *s.MinPrice
+		}
+		if s.MaxPrice != nil {
+			priceRange["lte"] = *s.MaxPrice
+		}
+		filters["price"] = Map{"range": Map{"price": priceRange}}
+	}
+
+	return filters
+}
+
+func filtersExcept(filters map[string]Map, dimension string) Map {
+	clauses := []Map{}
+	for name, filter := range filters {
+		if name != dimension {
+			clauses = append(clauses, filter)
+		}
+	}
+
+	return Map{"bool": Map{"filter": clauses}}
+}
+
+func (s *ProductSearchParams) sort() []Map {
+	switch s.Sort {
+	case SortPriceAsc:
+		return []Map{{"price": "asc"}, {"id": "asc"}}
+	case SortPriceDesc:
+		return []Map{{"price": "desc"}, {"id": "asc"}}
+	case SortNewest:
+		// documents indexed before created_at existed come last
+		return []Map{{"created_at": Map{"order": "desc", "missing": "_last", "unmapped_type": "date"}}, {"id": "asc"}}
+	default:
+		return []Map{{"_score": "desc"}, {"id": "asc"}}
+	}
+}
+
+// body builds the search request. Filters go in post_filter so every facet
+// counts over the other filters only, picking a category still shows the
+// count of the categories next to it.
+func (s *ProductSearchParams) body() Map {
+	query := Map{"match_all": Map{}}
+	if s.Query != "" {
+		query = Map{
+			"multi_match": Map{
+				"query":     s.Query,
+				"fields":    []string{"name", "description"},
+				"fuzziness": "AUTO",
+			},
+		}
+	}
+
+	filters := s.filters()
+	termsFacet := func(field string, source string) Map {
+		return Map{
+			"terms": Map{"field": field, "size": maxFacetBuckets},
+			"aggs": Map{
+				"top": Map{"top_hits": Map{"size": 1, "_source": []string{source}}},
+			},
+		}
+	}
+
+	body := Map{
+		"query":       query,
+		"post_filter": filtersExcept(filters, ""),
+		"sort":        s.sort(),
+		"size":        s.Size,
+		"aggs": Map{
+			"categories": Map{
+				"filter": filtersExcept(filters, "category"),
+				"aggs":   Map{"buckets": termsFacet("category.id", "category")},
+			},
+			"operators": Map{
+				"filter": filtersExcept(filters, "operator"),
+				"aggs":   Map{"buckets": termsFacet("operator.id", "operator")},
+			},
+			"prices": Map{
+				"filter": filtersExcept(filters, "price"),
+				"aggs": Map{
+					"buckets": Map{"histogram": Map{"field": "price", "interval": s.PriceInterval, "min_doc_count": 1}},
+				},
+			},
+		},
+	}
+
+	if s.PitId == "" {
+		body["from"] = s.From
+		return body
+	}
+
+	body["pit"] = Map{"id": s.PitId, "keep_alive": pitKeepAlive}
+	// facets and totals come with the first page of a cursor only
+	if len(s.After) > 0 {
+		body["search_after"] = s.After
+		delete(body, "aggs")
+	}
+
+	return body
+}
+
+type esTermsFacet struct {
+	Buckets struct {
+		Buckets []struct {
+			Key      string `json:"key"`
+			DocCount int    `json:"doc_count"`
+			Top      struct {
+				Hits struct {
+					Hits []struct {
+						Source ProductSearch `json:"_source"`
+					} `json:"hits"`
+				} `json:"hits"`
+			} `json:"top"`
+		} `json:"buckets"`
+	} `json:"buckets"`
+}
+
+type esHistogramFacet struct {
+	Buckets struct {
+		Buckets []struct {
+			Key      float64 `json:"key"`
+			DocCount int     `json:"doc_count"`
+		} `json:"buckets"`
+	} `json:"buckets"`
+}
+
+type FacetBucket struct {
+	Id    int    `json:"id"`
+	Name  string `json:"name"`
+	Slug  string `json:"slug,omitempty"`
+	Count int    `json:"count"`
+}
+
+type PriceBucket struct {
+	From  int `json:"from"`
+	To    int `json:"to"`
+	Count int `json:"count"`
+}
+
+type ProductFacets struct {
+	Categories []FacetBucket `json:"categories"`
+	Operators  []FacetBucket `json:"operators"`
+	Prices     []PriceBucket `json:"prices"`
+}
+
+// facets turns the aggregations into facets, interval is the width of the
+// price buckets.
+func (e *EsResponse) facets(interval int) *ProductFacets {
+	facets := &ProductFacets{Categories: []FacetBucket{}, Operators: []FacetBucket{}, Prices: []PriceBucket{}}
+
+	for _, bucket := range e.Aggregations.Categories.Buckets.Buckets {
+		id, _ := strconv.Atoi(bucket.Key)
+		facet := FacetBucket{Id: id, Count: bucket.DocCount}
+		if hits := bucket.Top.Hits.Hits; len(hits) > 0 {
+			facet.Name = hits[0].Source.Category.Name
+		}
+		facets.Categories = append(facets.Categories, facet)
+	}
+
+	for _, bucket := range e.Aggregations.Operators.Buckets.Buckets {
+		id, _ := strconv.Atoi(bucket.Key)
+		facet := FacetBucket{Id: id, Count: bucket.DocCount}
+		if hits := bucket.Top.Hits.Hits; len(hits) > 0 {
+			facet.Name = hits[0].Source.Operator.Name
+			facet.Slug = hits[0].Source.Operator.Slug
+		}
+		facets.Operators = append(facets.Operators, facet)
+	}
+
+	for _, bucket := range e.Aggregations.Prices.Buckets.Buckets {
+		from := int(bucket.Key)
+		facets.Prices = append(facets.Prices, PriceBucket{From: from, To: from + interval, Count: bucket.DocCount})
+	}
+
+	return facets
+}
+
+func (p *ProductService) searchProducts(ctx context.Context, params *ProductSearchParams) (*EsResponse, error) {
+	var response *EsResponse
+
+	reqBuf, err := json.Marshal(params.body())
+	if err != nil {
+		slog.Error("Error occurred while marshaling request", "error", err)
+		return nil, err
+	}
+
+	searchReq := esapi.SearchRequest{
+		Index:          []string{ProductIndex},
+		Body:           bytes.NewReader(reqBuf),
+		TrackTotalHits: true,
+	}
+	// a point in time already names the index
+	if params.PitId != "" {
+		searchReq.Index = nil
+		searchReq.TrackTotalHits = len(params.After) == 0
+	}
+
+	res, err := searchReq.Do(ctx, p.EsClient)
+	if err != nil {
+		slog.Error("Error occurred while searching products", "error", err)
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	// an expired point in time is not found
+	if params.PitId != "" && res.StatusCode == fiber.StatusNotFound {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Cursor expired, please search again")
+	}
+
+	if res.IsError() {
+		slog.Error("Error occurred while searching products", "error", res.String())
+		return nil, errors.New(res.String())
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		slog.Error("Error occurred while decoding response", "error", err)
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (p *ProductService) openProductPit(ctx context.Context) (string, error) {
+	res, err := esapi.OpenPointInTimeRequest{Index: []string{ProductIndex}, KeepAlive: pitKeepAlive}.Do(ctx, p.EsClient)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return "", errors.New(res.String())
+	}
+
+	var pitRes struct {
+		Id string `json:"id"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&pitRes); err != nil {
+		return "", err
+	}
+
+	return pitRes.Id, nil
+}
+
+// closeProductPit frees a point in time after its last page, it would
+// expire on its own otherwise.
+func (p *ProductService) closeProductPit(ctx context.Context, pitId string) {
+	body, err := json.Marshal(Map{"id": pitId})
+	if err != nil {
+		return
+	}
+
+	res, err := esapi.ClosePointInTimeRequest{Body: bytes.NewReader(body)}.Do(ctx, p.EsClient)
+	if err != nil {
+		slog.Error("Error occurred while closing point in time", "error", err)
+		return
+	}
+	defer res.Body.Close()
+}
+
+// getProductsByCursor pages with search_after over a point in time, so deep
+// pages cost the same as the first and stay consistent while paging.
+func (p *ProductService) getProductsByCursor(c *fiber.Ctx, size int) error {
+	var params *ProductSearchParams
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := decodeProductCursor(value)
+		if err != nil {
+			return err
+		}
+		params = &cursor.Params
+		params.PitId = cursor.PitId
+		params.After = cursor.After
+	} else {
+		var err error
+		params, err = parseProductSearchParams(c)
+		if err != nil {
+			return err
+		}
+
+		params.PitId, err = p.openProductPit(c.Context())
+		if err != nil {
+			slog.Error("Error occurred while opening point in time", "error", err)
+			return err
+		}
+	}
+	params.Size = size
+
+	esRes, err := p.searchProducts(c.Context(), params)
+	if err != nil {
+		return err
+	}
+
+	products := []*ProductSearch{}
+	for _, hit := range esRes.Hits.Hits {
+		products = append(products, &hit.Source)
+	}
+
+	if err := p.applyTierPrices(c.Context(), products, requestTier(c)); err != nil {
+		return err
+	}
+
+	pitId := params.PitId
+	if esRes.PitId != "" {
+		pitId = esRes.PitId
+	}
+
+	var nextCursor *string
+	if hits := esRes.Hits.Hits; len(hits) == size {
+		cursor, err := encodeProductCursor(&productCursor{PitId: pitId, After: hits[len(hits)-1].Sort, Params: *params})
+		if err != nil {
+			return err
+		}
+		nextCursor = &cursor
+	} else {
+		p.closeProductPit(c.Context(), pitId)
+	}
+
+	meta := fiber.Map{
+		"size": size,
+		"sort": params.Sort,
+	}
+	data := fiber.Map{
+		"products":    products,
+		"meta":        meta,
+		"next_cursor": nextCursor,
+	}
+	if len(params.After) == 0 {
+		meta["total_hits"] = esRes.Hits.Total.Value
+		data["facets"] = esRes.facets(params.PriceInterval)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Products retrieved successfully",
+		"data":    data,
+		"errors":  nil,
+	})
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"os"
@@ -21,7 +22,10 @@ func NewAppServer(db *sql.DB, esClient *ESClient) *AppServer {
 	validate := validator.New()
 
 	server := fiber.New(fiber.Config{
-		ErrorHandler: shared.ErrorHandler,
+		ErrorHandler:            shared.ErrorHandler,
+		ProxyHeader:             shared.ClientIPHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          shared.TrustedProxies(),
 	})
 
 	app := server.Group("/api")
@@ -33,9 +37,13 @@ func NewAppServer(db *sql.DB, esClient *ESClient) *AppServer {
 
 	indexerService := ipb.NewIndexerServiceClient(indexerServiceConn)
 
-	productService := NewProductService(validate, db, &indexerService, esClient.Client)
+	producer := NewKafkaProducer(os.Getenv("KAFKA_HOST") + ":" + os.Getenv("KAFKA_PORT"))
+
+	productService := NewProductService(validate, db, &indexerService, esClient.Client, producer, shared.NewRedis())
 	productService.RegisterRoutes(app)
 
+	go productService.RunPricing(context.Background())
+
 	return &AppServer{
 		server: server,
 	}
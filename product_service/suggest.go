@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"html"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// the edge n-grams of products.json start at two characters
+	minSuggestLength    = 2
+	maxSuggestLength    = 50
+	defaultSuggestLimit = 5
+	maxSuggestLimit     = 10
+
+	// suggestions answer while the user types, a late answer is useless
+	suggestTimeout   = 300 * time.Millisecond
+	suggestEsTimeout = "150ms"
+
+	// a prefix is cached once it was asked this often within the window
+	suggestPopularAfter  = 3
+	suggestPopularWindow = time.Hour
+	suggestCacheExpiry   = 5 * time.Minute
+)
+
+type ProductSuggestion struct {
+	Id        int            `json:"id"`
+	Name      string         `json:"name"`
+	Highlight string         `json:"highlight"`
+	Price     int            `json:"price"`
+	ImageUrl  string         `json:"image_url"`
+	Operator  OperatorSearch `json:"operator"`
+}
+
+type NameSuggestion struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug,omitempty"`
+	Highlight string `json:"highlight"`
+}
+
+type Suggestions struct {
+	Products   []ProductSuggestion `json:"products"`
+	Operators  []NameSuggestion    `json:"operators"`
+	Categories []NameSuggestion    `json:"categories"`
+}
+
+type esSuggestResponse struct {
+	TimedOut bool `json:"timed_out"`
+	Hits     struct {
+		Hits []struct {
+			Source ProductSearch `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations struct {
+		Operators  esTermsFacet `json:"operators"`
+		Categories esTermsFacet `json:"categories"`
+	} `json:"aggregations"`
+}
+
+// normalizeSuggestQuery lowercases and collapses spaces, so prefixes that
+// only differ in case share a cache entry.
+func normalizeSuggestQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// highlightPrefixes wraps the start of every word of text that one of the
+// query terms is a prefix of in <em> tags. Names come from admins and
+// supplier price lists, the result is HTML escaped apart from the tags.
+func highlightPrefixes(text string, query string) string {
+	terms := strings.Fields(query)
+
+	words := strings.Split(text, " ")
+	for i, word := range words {
+		lowered := strings.ToLower(word)
+		longest := 0
+		for _, term := range terms {
+			if strings.HasPrefix(lowered, term) && len(term) > longest {
+				longest = len(term)
+			}
+		}
+		// lowercasing can change byte lengths, leave such words alone
+		if longest > 0 && len(lowered) == len(word) {
+			words[i] = "<em>" + html.EscapeString(word[:longest]) + "</em>" + html.EscapeString(word[longest:])
+		} else {
+			words[i] = html.EscapeString(word)
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// suggestBody matches product names on their edge n-grams, operator names
+// by phrase prefix and category names by keyword prefix. Products come from
+// post_filter, operators and categories from aggregations over the rest.
+func suggestBody(query string, limit int) Map {
+	productQuery := Map{"match": Map{"name": Map{"query": query, "operator": "and"}}}
+	operatorQuery := Map{"match_phrase_prefix": Map{"operator.name": query}}
+	categoryQuery := Map{"prefix": Map{"category.name": Map{"value": query, "case_insensitive": true}}}
+
+	namesFacet := func(field string, source string, filter Map) Map {
+		return Map{
+			"filter": filter,
+			"aggs": Map{
+				"buckets": Map{
+					"terms": Map{"field": field, "size": limit},
+					"aggs": Map{
+						"top": Map{"top_hits": Map{"size": 1, "_source": []string{source}}},
+					},
+				},
+			},
+		}
+	}
+
+	return Map{
+		"timeout": suggestEsTimeout,
+		"size":    limit,
+		"_source": []string{"id", "name", "price", "image_url", "operator"},
+		"query": Map{
+			"bool": Map{
+				"should":               []Map{productQuery, operatorQuery, categoryQuery},
+				"minimum_should_match": 1,
+			},
+		},
+		"post_filter": productQuery,
+		"aggs": Map{
+			"operators":  namesFacet("operator.id", "operator", operatorQuery),
+			"categories": namesFacet("category.id", "category", categoryQuery),
+		},
+	}
+}
+
+func (p *ProductService) suggest(ctx context.Context, query string, limit int) (*Suggestions, bool, error) {
+	reqBuf, err := json.Marshal(suggestBody(query, limit))
+	if err != nil {
+		return nil, false, err
+	}
+
+	searchReq := esapi.SearchRequest{
+		Index: []string{ProductIndex},
+		Body:  bytes.NewReader(reqBuf),
+	}
+
+	res, err := searchReq.Do(ctx, p.EsClient)
+	if err != nil {
+		return nil, false, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, false, errors.New(res.String())
+	}
+
+	var esRes esSuggestResponse
+	if err := json.NewDecoder(res.Body).Decode(&esRes); err != nil {
+		return nil, false, err
+	}
+
+	suggestions := &Suggestions{Products: []ProductSuggestion{}, Operators: []NameSuggestion{}, Categories: []NameSuggestion{}}
+	for _, hit := range esRes.Hits.Hits {
+		suggestions.Products = append(suggestions.Products, ProductSuggestion{
+			Id:        hit.Source.ID,
+			Name:      hit.Source.Name,
+			Highlight: highlightPrefixes(hit.Source.Name, query),
+			Price:     hit.Source.Price,
+			ImageUrl:  hit.Source.ImageUrl,
+			Operator:  hit.Source.Operator,
+		})
+	}
+
+	for _, bucket := range esRes.Aggregations.Operators.Buckets.Buckets {
+		if len(bucket.Top.Hits.Hits) == 0 {
+			continue
+		}
+		operator := bucket.Top.Hits.Hits[0].Source.Operator
+		suggestions.Operators = append(suggestions.Operators, NameSuggestion{
+			Id:        operator.ID,
+			Name:      operator.Name,
+			Slug:      operator.Slug,
+			Highlight: highlightPrefixes(operator.Name, query),
+		})
+	}
+
+	for _, bucket := range esRes.Aggregations.Categories.Buckets.Buckets {
+		if len(bucket.Top.Hits.Hits) == 0 {
+			continue
+		}
+		category := bucket.Top.Hits.Hits[0].Source.Category
+		suggestions.Categories = append(suggestions.Categories, NameSuggestion{
+			Id:        category.ID,
+			Name:      category.Name,
+			Highlight: highlightPrefixes(category.Name, query),
+		})
+	}
+
+	return suggestions, !esRes.TimedOut, nil
+}
+
+// handleSuggestProducts answers search-as-you-type. Popular prefixes are
+// served from Redis, partial results of a timed out search are not cached.
+func (p *ProductService) handleSuggestProducts(c *fiber.Ctx) error {
+	query := normalizeSuggestQuery(c.Query("q"))
+	if utf8.RuneCountInString(query) > maxSuggestLength {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid 'q' parameter")
+	}
+
+	limit := c.QueryInt("limit", defaultSuggestLimit)
+	if limit < 1 || limit > maxSuggestLimit {
+		limit = defaultSuggestLimit
+	}
+
+	if utf8.RuneCountInString(query) < minSuggestLength {
+		return c.JSON(fiber.Map{
+			"message": "Suggestions retrieved successfully",
+			"data":    &Suggestions{Products: []ProductSuggestion{}, Operators: []NameSuggestion{}, Categories: []NameSuggestion{}},
+			"errors":  nil,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context(), suggestTimeout)
+	defer cancel()
+
+	cacheKey := "product-suggest:" + strconv.Itoa(limit) + ":" + query
+	cached, err := p.RedisClient.Get(ctx, cacheKey).Bytes()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		slog.Error("Failed to read suggestion cache", "error", err)
+	}
+	if len(cached) > 0 {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Send(cached)
+	}
+
+	suggestions, complete, err := p.suggest(ctx, query, limit)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fiber.NewError(fiber.StatusGatewayTimeout, "Suggestions took too long, please try again")
+		}
+		slog.Error("Failed to suggest products", "error", err)
+		return err
+	}
+
+	body, err := json.Marshal(fiber.Map{
+		"message": "Suggestions retrieved successfully",
+		"data":    suggestions,
+		"errors":  nil,
+	})
+	if err != nil {
+		return err
+	}
+
+	if complete {
+		p.cachePopularSuggestion(ctx, query, cacheKey, body)
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(body)
+}
+
+func (p *ProductService) cachePopularSuggestion(ctx context.Context, query string, cacheKey string, body []byte) {
+	popularityKey := "product-suggest:asked:" + query
+	asked, err := p.RedisClient.Incr(ctx, popularityKey).Result()
+	if err != nil {
+		slog.Error("Failed to count suggestion query", "error", err)
+		return
+	}
+	if asked == 1 {
+		p.RedisClient.Expire(ctx, popularityKey, suggestPopularWindow)
+	}
+
+	if asked >= suggestPopularAfter {
+		if err := p.RedisClient.SetEx(ctx, cacheKey, body, suggestCacheExpiry).Err(); err != nil {
+			slog.Error("Failed to write suggestion cache", "error", err)
+		}
+	}
+}
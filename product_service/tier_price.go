@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+// requestTier is the tier prices are shown in, retail for anonymous callers.
+func requestTier(c *fiber.Ctx) string {
+	claims := shared.OptionalUserClaims(c)
+	if claims == nil || !shared.IsTier(claims.Tier) {
+		return shared.TierRetail
+	}
+
+	return claims.Tier
+}
+
+// handleUpdateTierPrices replaces the price overrides of a product. Tiers
+// left out of the request pay the base price again.
+func (p *ProductService) handleUpdateTierPrices(c *fiber.Ctx) (err error) {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	updateRequest := &UpdateTierPricesRequest{}
+	if err := c.BodyParser(updateRequest); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err = p.validate.Struct(updateRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*updateRequest, err.(validator.ValidationErrors))
+	}
+
+	for tier := range updateRequest.Prices {
+		// retail is what products.price already holds
+		if !shared.IsTier(tier) || tier == shared.TierRetail {
+			return fiber.NewError(fiber.StatusUnprocessableEntity, "Invalid tier: "+tier)
+		}
+	}
+
+	tx, err := p.DB.BeginTx(c.Context(), nil)
+	if err != nil {
+		slog.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	var exists int
+	err = tx.QueryRowContext(c.Context(), "SELECT 1 FROM products WHERE id = ? AND deleted_at IS NULL FOR UPDATE", id).Scan(&exists)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fiber.NewError(fiber.StatusNotFound, "Product not found")
+		}
+		slog.Error("Failed to query product", "error", err)
+		return err
+	}
+
+	_, err = tx.ExecContext(c.Context(), "DELETE FROM product_tier_prices WHERE product_id = ?", id)
+	if err != nil {
+		slog.Error("Failed to delete tier prices", "error", err)
+		return err
+	}
+
+	for tier, price := range updateRequest.Prices {
+		_, err = tx.ExecContext(c.Context(), "INSERT INTO product_tier_prices (product_id, tier, price) VALUES (?, ?, ?)", id, tier, price)
+		if err != nil {
+			slog.Error("Failed to insert tier price", "error", err)
+			return err
+		}
+	}
+
+	slog.Info("Tier prices updated", "product-id", id, "by", shared.GetUserClaims(c).Subject)
+
+	return c.JSON(fiber.Map{
+		"message": "Tier prices updated successfully",
+		"data": fiber.Map{
+			"product_id": id,
+			"prices":     updateRequest.Prices,
+		},
+		"errors": nil,
+	})
+}
+
+// applyTierPrices swaps the prices of search results for the overrides of
+// tier, keeping the indexed price as base price.
+func (p *ProductService) applyTierPrices(ctx context.Context, products []*ProductSearch, tier string) error {
+	if tier == shared.TierRetail || len(products) == 0 {
+		return nil
+	}
+
+	byId := make(map[int]*ProductSearch, len(products))
+	placeholders := make([]string, 0, len(products))
+	args := []any{tier}
+	for _, product := range products {
+		byId[product.ID] = product
+		placeholders = append(placeholders, "?")
+		args = append(args, product.ID)
+	}
+
+	query := "SELECT product_id, price FROM product_tier_prices WHERE tier = ? AND product_id IN (" + strings.Join(placeholders, ", ") + ")"
+	rows, err := p.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		slog.Error("Failed to query tier prices", "error", err)
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productId, price int
+		if err := rows.Scan(&productId, &price); err != nil {
+			slog.Error("Failed to scan tier price row", "error", err)
+			return err
+		}
+
+		if product, ok := byId[productId]; ok && price != product.Price {
+			product.BasePrice = product.Price
+			product.Price = price
+		}
+	}
+
+	return rows.Err()
+}
@@ -138,3 +138,600 @@ ALTER TABLE orders drop column payment_method_name;
 ALTER TABLE orders add column channel_code varchar(100) not null after server_id;
 
 
+create table roles
+(
+    id         bigint auto_increment primary key,
+    name       varchar(100)                        not null,
+    created_at timestamp default CURRENT_TIMESTAMP not null,
+    updated_at timestamp default CURRENT_TIMESTAMP not null on update CURRENT_TIMESTAMP
+)
+    engine = innodb;
+
+create unique index roles_name_uindex
+    on roles (name);
+
+create table permissions
+(
+    id         bigint auto_increment primary key,
+    name       varchar(100)                        not null,
+    created_at timestamp default CURRENT_TIMESTAMP not null,
+    updated_at timestamp default CURRENT_TIMESTAMP not null on update CURRENT_TIMESTAMP
+)
+    engine = innodb;
+
+create unique index permissions_name_uindex
+    on permissions (name);
+
+create table role_permissions
+(
+    role_id       bigint not null,
+    permission_id bigint not null,
+
+    primary key (role_id, permission_id),
+    constraint role_permissions_role_id_foreign
+        foreign key (role_id) references roles (id) on delete cascade on update cascade,
+    constraint role_permissions_permission_id_foreign
+        foreign key (permission_id) references permissions (id) on delete cascade on update cascade
+)
+    engine = innodb;
+
+create table user_roles
+(
+    user_id    bigint                              not null,
+    role_id    bigint                              not null,
+    created_at timestamp default CURRENT_TIMESTAMP not null,
+
+    primary key (user_id, role_id),
+    constraint user_roles_user_id_foreign
+        foreign key (user_id) references users (id) on delete cascade on update cascade,
+    constraint user_roles_role_id_foreign
+        foreign key (role_id) references roles (id) on delete cascade on update cascade
+)
+    engine = innodb;
+
+-- Insert roles
+INSERT INTO roles (name)
+VALUES ('customer'),
+       ('support'),
+       ('catalog-admin'),
+       ('finance'),
+       ('superadmin');
+
+-- Insert permissions
+INSERT INTO permissions (name)
+VALUES ('users:read'),
+       ('users:manage_roles'),
+       ('orders:read_all'),
+       ('catalog:write'),
+       ('payments:read');
+
+-- Grant permissions to roles
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+         CROSS JOIN permissions p
+WHERE (r.name = 'support' AND p.name IN ('users:read', 'orders:read_all'))
+   OR (r.name = 'catalog-admin' AND p.name IN ('catalog:write'))
+   OR (r.name = 'finance' AND p.name IN ('orders:read_all', 'payments:read'))
+   OR (r.name = 'superadmin');
+
+-- Every existing user is a customer
+INSERT INTO user_roles (user_id, role_id)
+SELECT u.id, r.id
+FROM users u
+         JOIN roles r ON r.name = 'customer';
+
+alter table users
+    add column email_verification_expires_at timestamp default null null after email_verification_token;
+
+create table pending_contact_changes
+(
+    id           bigint auto_increment primary key,
+    user_id      bigint                              not null,
+    type         varchar(50)                         not null,
+    new_value    varchar(255)                        not null,
+    token_hash   varchar(64)                         not null,
+    expires_at   timestamp                           not null,
+    confirmed_at timestamp default null              null,
+    created_at   timestamp default CURRENT_TIMESTAMP not null,
+    constraint pending_contact_changes_user_id_foreign
+        foreign key (user_id) references users (id) on delete cascade on update cascade
+)
+    engine = innodb;
+
+create index pending_contact_changes_token_hash_index
+    on pending_contact_changes (token_hash);
+
+-- a confirmed phone number belongs to one account, duplicates have to be
+-- resolved before this runs
+drop index users_phone_number_index on users;
+create unique index users_phone_number_uindex
+    on users (phone_number);
+
+alter table users
+    add column deleted_at timestamp default null null;
+
+create table account_deletions
+(
+    id            bigint auto_increment primary key,
+    user_id       bigint                              not null,
+    token_hash    varchar(64)                         not null,
+    expires_at    timestamp                           not null,
+    confirmed_at  timestamp default null              null,
+    scheduled_for timestamp default null              null,
+    executed_at   timestamp default null              null,
+    cancelled_at  timestamp default null              null,
+    created_at    timestamp default CURRENT_TIMESTAMP not null,
+    constraint account_deletions_user_id_foreign
+        foreign key (user_id) references users (id) on delete cascade on update cascade
+)
+    engine = innodb;
+
+create index account_deletions_token_hash_index
+    on account_deletions (token_hash);
+
+create index account_deletions_scheduled_for_index
+    on account_deletions (scheduled_for);
+
+alter table users
+    add column status        varchar(20)  default 'active' not null after password,
+    add column status_reason varchar(255) default null     null after status;
+
+create index users_status_index
+    on users (status);
+
+create index users_created_at_index
+    on users (created_at);
+
+INSERT INTO permissions (name)
+VALUES ('users:write');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+         CROSS JOIN permissions p
+WHERE p.name = 'users:write'
+  AND r.name IN ('support', 'superadmin');
+
+create table saved_destinations
+(
+    id              bigint auto_increment primary key,
+    user_id         bigint                                 not null,
+    label           varchar(100)                           not null,
+    operator_id     bigint       default null              null,
+    product_type_id bigint       default null              null,
+    destination     varchar(255)                           not null,
+    server_id       varchar(255) default ''                not null,
+    created_at      timestamp    default CURRENT_TIMESTAMP not null,
+    updated_at      timestamp    default CURRENT_TIMESTAMP not null on update CURRENT_TIMESTAMP,
+
+    constraint saved_destinations_user_id_foreign
+        foreign key (user_id) references users (id) on delete cascade on update cascade,
+    constraint saved_destinations_operator_id_foreign
+        foreign key (operator_id) references operators (id) on delete cascade on update cascade,
+    constraint saved_destinations_product_type_id_foreign
+        foreign key (product_type_id) references product_types (id) on delete cascade on update cascade
+)
+    engine = innodb;
+
+create unique index saved_destinations_user_id_label_uindex
+    on saved_destinations (user_id, label);
+
+create table wallets
+(
+    id         bigint auto_increment primary key,
+    user_id    bigint                              not null,
+    balance    bigint    default 0                 not null,
+    created_at timestamp default CURRENT_TIMESTAMP not null,
+    updated_at timestamp default CURRENT_TIMESTAMP not null on update CURRENT_TIMESTAMP,
+
+    constraint wallets_balance_check check (balance >= 0)
+)
+    engine = innodb;
+
+create unique index wallets_user_id_uindex
+    on wallets (user_id);
+
+-- Every journal moves money between two accounts with one debit and one
+-- credit entry. Wallet accounts are named wallet:<id>, the others are system
+-- accounts such as payment-gateway or sales.
+create table ledger_entries
+(
+    id             bigint auto_increment primary key,
+    journal_id     varchar(100)                        not null,
+    account        varchar(100)                        not null,
+    wallet_id      bigint    default null              null,
+    direction      varchar(10)                         not null,
+    amount         bigint                              not null,
+    balance_after  bigint    default null              null,
+    reference_type varchar(50)                         not null,
+    reference_id   varchar(255)                        not null,
+    description    varchar(255)                        not null,
+    created_at     timestamp default CURRENT_TIMESTAMP not null,
+
+    constraint ledger_entries_wallet_id_foreign
+        foreign key (wallet_id) references wallets (id),
+    constraint ledger_entries_amount_check check (amount > 0)
+)
+    engine = innodb;
+
+create unique index ledger_entries_journal_id_account_uindex
+    on ledger_entries (journal_id, account);
+
+create index ledger_entries_wallet_id_index
+    on ledger_entries (wallet_id, id);
+
+create table wallet_topups
+(
+    id                   varchar(255) primary key,
+    user_id              bigint                              not null,
+    amount               int                                 not null,
+    service_charge       int                                 not null,
+    total_amount         int                                 not null,
+    channel_code         varchar(100)                        not null,
+    payment_reference_id varchar(255) default null           null,
+    status               varchar(50)                         not null,
+    failure_code         varchar(50)  default null           null,
+    created_at           timestamp default CURRENT_TIMESTAMP not null,
+    updated_at           timestamp default CURRENT_TIMESTAMP not null on update CURRENT_TIMESTAMP
+)
+    engine = innodb;
+
+create index wallet_topups_user_id_index
+    on wallet_topups (user_id);
+
+INSERT INTO permissions (name)
+VALUES ('wallet:manage');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+         CROSS JOIN permissions p
+WHERE p.name = 'wallet:manage'
+  AND r.name IN ('finance', 'superadmin');
+
+alter table users
+    add column tier varchar(20) default 'retail' not null after status_reason;
+
+create table product_tier_prices
+(
+    product_id bigint                              not null,
+    tier       varchar(20)                         not null,
+    price      int                                 not null,
+    created_at timestamp default CURRENT_TIMESTAMP not null,
+    updated_at timestamp default CURRENT_TIMESTAMP not null on update CURRENT_TIMESTAMP,
+    primary key (product_id, tier),
+    constraint product_tier_prices_products_id_fk
+        foreign key (product_id) references products (id)
+            on delete cascade
+)
+    engine = innodb;
+
+alter table users
+    add column referral_code varchar(20)  default null null,
+    add column signup_ip     varchar(45)  default null null,
+    add column signup_device varchar(255) default null null;
+
+create unique index users_referral_code_uindex
+    on users (referral_code);
+
+create table referrals
+(
+    id              bigint auto_increment primary key,
+    referrer_id     bigint                              not null,
+    referred_id     bigint                              not null,
+    status          varchar(20)                         not null,
+    reject_reason   varchar(50) default null            null,
+    signup_ip       varchar(45) default null            null,
+    signup_device   varchar(255) default null           null,
+    referrer_reward int                                 not null,
+    referred_reward int                                 not null,
+    order_id        varchar(255) default null           null,
+    qualified_at    timestamp default null              null,
+    rewarded_at     timestamp default null              null,
+    revoked_at      timestamp default null              null,
+    created_at      timestamp default CURRENT_TIMESTAMP not null,
+    updated_at      timestamp default CURRENT_TIMESTAMP not null on update CURRENT_TIMESTAMP,
+    constraint referrals_referred_id_uindex
+        unique (referred_id),
+    constraint referrals_referrer_id_fk
+        foreign key (referrer_id) references users (id),
+    constraint referrals_referred_id_fk
+        foreign key (referred_id) references users (id)
+)
+    engine = innodb;
+
+create index referrals_referrer_id_index
+    on referrals (referrer_id);
+
+create index referrals_order_id_index
+    on referrals (order_id);
+
+create index referrals_signup_ip_index
+    on referrals (signup_ip, created_at);
+
+create table loyalty_point_rules
+(
+    id                  bigint auto_increment primary key,
+    category_id         bigint default null                 null,
+    product_id          bigint default null                 null,
+    points_per_thousand int                                 not null,
+    created_at          timestamp default CURRENT_TIMESTAMP not null,
+    updated_at          timestamp default CURRENT_TIMESTAMP not null on update CURRENT_TIMESTAMP,
+    constraint loyalty_point_rules_category_id_uindex
+        unique (category_id),
+    constraint loyalty_point_rules_product_id_uindex
+        unique (product_id),
+    constraint loyalty_point_rules_categories_id_fk
+        foreign key (category_id) references categories (id) on delete cascade,
+    constraint loyalty_point_rules_products_id_fk
+        foreign key (product_id) references products (id) on delete cascade
+)
+    engine = innodb;
+
+alter table orders
+    add column points_redeemed int default 0 not null after total_amount,
+    add column points_discount int default 0 not null after points_redeemed,
+    add column points_earned   int default 0 not null after points_discount;
+
+create table loyalty_point_entries
+(
+    id           bigint auto_increment primary key,
+    user_id      bigint                              not null,
+    type         varchar(20)                         not null,
+    points       int                                 not null,
+    remaining    int default null                    null,
+    expires_at   timestamp default null              null,
+    reference_id varchar(255)                        not null,
+    description  varchar(255)                        not null,
+    created_at   timestamp default CURRENT_TIMESTAMP not null,
+    constraint loyalty_point_entries_type_reference_id_uindex
+        unique (type, reference_id),
+    constraint loyalty_point_entries_users_id_fk
+        foreign key (user_id) references users (id)
+)
+    engine = innodb;
+
+create index loyalty_point_entries_user_id_index
+    on loyalty_point_entries (user_id, id);
+
+create index loyalty_point_entries_expires_at_index
+    on loyalty_point_entries (expires_at, remaining);
+
+create table loyalty_point_allocations
+(
+    redeem_entry_id bigint not null,
+    lot_entry_id    bigint not null,
+    points          int    not null,
+    primary key (redeem_entry_id, lot_entry_id),
+    constraint loyalty_point_allocations_redeem_fk
+        foreign key (redeem_entry_id) references loyalty_point_entries (id),
+    constraint loyalty_point_allocations_lot_fk
+        foreign key (lot_entry_id) references loyalty_point_entries (id)
+)
+    engine = innodb;
+
+alter table categories
+    add column updated_by bigint    default null null,
+    add column deleted_at timestamp default null null;
+
+alter table operators
+    add column updated_by bigint    default null null,
+    add column deleted_at timestamp default null null;
+
+alter table product_types
+    add column updated_by bigint    default null null,
+    add column deleted_at timestamp default null null;
+
+alter table products
+    add column updated_by bigint    default null null,
+    add column deleted_at timestamp default null null;
+
+-- format_form as form schema, the older {"name": "type"} maps are still read.
+-- The amount or package is the product itself, so only the account is asked.
+UPDATE product_types
+SET format_form = '{"fields": [{"name": "phone_number", "type": "number", "label": "Phone number", "required": true, "pattern": "^08[0-9]+$", "min_length": 10, "max_length": 13, "help_text": "Use the 08xxxxxxxxxx format"}]}'
+WHERE ref_id = 'PT001';
+UPDATE product_types
+SET format_form = '{"fields": [{"name": "phone_number", "type": "number", "label": "Phone number", "required": true, "pattern": "^08[0-9]+$", "min_length": 10, "max_length": 13, "help_text": "Use the 08xxxxxxxxxx format"}]}'
+WHERE ref_id = 'PT002';
+UPDATE product_types
+SET format_form = '{"fields": [{"name": "customer_id", "type": "string", "label": "Customer ID", "required": true, "min_length": 6, "max_length": 20}]}'
+WHERE ref_id = 'PT003';
+
+create table operator_prefixes
+(
+    id          bigint auto_increment primary key,
+    operator_id bigint                              not null,
+    prefix      varchar(8)                          not null,
+    created_at  timestamp default CURRENT_TIMESTAMP not null,
+
+    constraint operator_prefixes_prefix_unique
+        unique (prefix),
+    constraint operator_prefixes_operator_id_foreign
+        foreign key (operator_id) references operators (id) on delete cascade on update cascade
+)
+    engine = innodb;
+
+INSERT INTO operator_prefixes (operator_id, prefix)
+VALUES (1, '0811'),
+       (1, '0812'),
+       (1, '0813'),
+       (1, '0821'),
+       (1, '0822'),
+       (1, '0852'),
+       (1, '0853'),
+       (2, '0814'),
+       (2, '0815'),
+       (2, '0816'),
+       (2, '0855'),
+       (2, '0856'),
+       (2, '0857'),
+       (2, '0858');
+
+-- account inquiry, inquiry_provider names the adapter (fake or http) and
+-- inquiry_code is the game code at the provider
+alter table operators
+    add column inquiry_provider varchar(32) default null null,
+    add column inquiry_code     varchar(64) default null null;
+
+-- availability, maintenance applies between start and end, open ends allowed
+alter table operators
+    add column status            varchar(16) default 'active' not null,
+    add column maintenance_start timestamp   default null     null,
+    add column maintenance_end   timestamp   default null     null;
+
+-- stock is null for products without stock tracking
+alter table products
+    add column status            varchar(16) default 'active' not null,
+    add column stock             int         default null     null,
+    add column maintenance_start timestamp   default null     null,
+    add column maintenance_end   timestamp   default null     null;
+
+create table product_stock_reservations
+(
+    order_id    varchar(36)                         not null primary key,
+    product_id  bigint                              not null,
+    quantity    int                                 not null,
+    released_at timestamp default null              null,
+    created_at  timestamp default CURRENT_TIMESTAMP not null,
+
+    constraint product_stock_reservations_product_id_foreign
+        foreign key (product_id) references products (id) on delete cascade on update cascade
+)
+    engine = innodb;
+
+create table suppliers
+(
+    id         bigint auto_increment primary key,
+    code       varchar(32)                           not null,
+    name       varchar(255)                          not null,
+    status     varchar(16) default 'active'          not null,
+    created_at timestamp   default CURRENT_TIMESTAMP not null,
+    updated_at timestamp   default CURRENT_TIMESTAMP not null on update CURRENT_TIMESTAMP,
+
+    constraint suppliers_code_unique
+        unique (code)
+)
+    engine = innodb;
+
+create table supplier_products
+(
+    id           bigint auto_increment primary key,
+    supplier_id  bigint                              not null,
+    product_id   bigint                              not null,
+    supplier_sku varchar(64)                         not null,
+    cost         int                                 not null,
+    updated_at   timestamp default CURRENT_TIMESTAMP not null on update CURRENT_TIMESTAMP,
+
+    constraint supplier_products_supplier_product_unique
+        unique (supplier_id, product_id),
+    constraint supplier_products_supplier_id_foreign
+        foreign key (supplier_id) references suppliers (id) on delete cascade on update cascade,
+    constraint supplier_products_product_id_foreign
+        foreign key (product_id) references products (id) on delete cascade on update cascade
+)
+    engine = innodb;
+
+-- an operator rule goes before the rule of its category, products without a
+-- rule keep their hand-entered price
+create table margin_rules
+(
+    id          bigint auto_increment primary key,
+    category_id bigint                              null,
+    operator_id bigint                              null,
+    type        varchar(16)                         not null,
+    value       decimal(12, 2)                      not null,
+    min_profit  int       default 0                 not null,
+    updated_at  timestamp default CURRENT_TIMESTAMP not null on update CURRENT_TIMESTAMP,
+
+    constraint margin_rules_category_id_unique
+        unique (category_id),
+    constraint margin_rules_operator_id_unique
+        unique (operator_id),
+    constraint margin_rules_category_id_foreign
+        foreign key (category_id) references categories (id) on delete cascade on update cascade,
+    constraint margin_rules_operator_id_foreign
+        foreign key (operator_id) references operators (id) on delete cascade on update cascade
+)
+    engine = innodb;
+
+create table product_price_history
+(
+    id         bigint auto_increment primary key,
+    product_id bigint                              not null,
+    old_price  int                                 not null,
+    new_price  int                                 not null,
+    cost       int                                 not null,
+    reason     varchar(32)                         not null,
+    created_at timestamp default CURRENT_TIMESTAMP not null,
+
+    index product_price_history_product_id_created_at_index (product_id, created_at),
+    constraint product_price_history_product_id_foreign
+        foreign key (product_id) references products (id) on delete cascade on update cascade
+)
+    engine = innodb;
+
+INSERT INTO suppliers (code, name)
+VALUES ('digiflazz', 'Digiflazz'),
+       ('vippayment', 'VIP Payment');
+
+-- price lists are imported by ref_id
+alter table categories
+    add constraint categories_ref_id_unique unique (ref_id);
+alter table operators
+    add constraint operators_ref_id_unique unique (ref_id);
+alter table product_types
+    add constraint product_types_ref_id_unique unique (ref_id);
+alter table products
+    add constraint products_ref_id_unique unique (ref_id);
+
+-- fulfillment routes a product over its suppliers, priority breaks ties and
+-- the counts give the success rate
+alter table supplier_products
+    add column priority      int default 0 not null after cost,
+    add column success_count int default 0 not null after priority,
+    add column failure_count int default 0 not null after success_count;
+
+-- null follows SUPPLIER_ROUTING_POLICY
+alter table products
+    add column routing_policy varchar(16) default null null;
+
+alter table orders
+    add column fulfillment_status varchar(16)  default 'pending' not null after failure_code,
+    add column fulfilled_by       varchar(32)  default null null after fulfillment_status,
+    add column serial_number      varchar(255) default null null after fulfilled_by;
+
+create table order_fulfillment_attempts
+(
+    id            bigint auto_increment primary key,
+    order_id      varchar(255)                        not null,
+    attempt       int                                 not null,
+    policy        varchar(16)                         not null,
+    supplier_id   bigint                              not null,
+    supplier_code varchar(32)                         not null,
+    supplier_sku  varchar(64)                         not null,
+    cost          int                                 not null,
+    outcome       varchar(16)                         not null,
+    message       varchar(255) default null           null,
+    created_at    timestamp default CURRENT_TIMESTAMP not null,
+
+    constraint order_fulfillment_attempts_order_attempt_unique
+        unique (order_id, attempt),
+    constraint order_fulfillment_attempts_order_id_foreign
+        foreign key (order_id) references orders (id) on delete cascade on update cascade
+)
+    engine = innodb;
+
+create index orders_fulfillment_status_index
+    on orders (fulfillment_status);
+
+INSERT INTO permissions (name)
+VALUES ('orders:manage');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r
+         CROSS JOIN permissions p
+WHERE p.name = 'orders:manage'
+  AND r.name IN ('support', 'superadmin');
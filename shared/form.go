@@ -0,0 +1,226 @@
+package shared
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	FormFieldString = "string"
+	FormFieldNumber = "number"
+)
+
+// orders store two destination values, destination and server id
+const maxFormFields = 2
+
+// FormField describes one destination input of a product type.
+type FormField struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Label     string `json:"label"`
+	Required  bool   `json:"required"`
+	Pattern   string `json:"pattern,omitempty"`
+	MinLength int    `json:"min_length,omitempty"`
+	MaxLength int    `json:"max_length,omitempty"`
+	HelpText  string `json:"help_text,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+// FormSchema is the parsed product_types.format_form. The first field fills
+// the order destination and the second one the server id.
+type FormSchema struct {
+	Fields []*FormField `json:"fields"`
+}
+
+// ParseFormSchema reads a format_form. Besides {"fields": [...]} it accepts
+// the older {"name": "type"} maps, where only the first field is required.
+func ParseFormSchema(formatForm string) (*FormSchema, error) {
+	raw := []byte(strings.TrimSpace(formatForm))
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, errors.New("format form must be a JSON object")
+	}
+
+	schema := &FormSchema{}
+	if _, ok := probe["fields"]; ok {
+		if err := json.Unmarshal(raw, schema); err != nil {
+			return nil, errors.New("format form fields are malformed")
+		}
+	} else {
+		fields, err := parseLegacyFormFields(raw)
+		if err != nil {
+			return nil, err
+		}
+		schema.Fields = fields
+	}
+
+	if err := schema.compile(); err != nil {
+		return nil, err
+	}
+
+	return schema, nil
+}
+
+// parseLegacyFormFields walks the tokens so fields keep the order they are
+// written in, a map would lose it.
+func parseLegacyFormFields(raw []byte) ([]*FormField, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	var fields []*FormField
+	for decoder.More() {
+		nameToken, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		var fieldType string
+		if err := decoder.Decode(&fieldType); err != nil {
+			return nil, errors.New("format form types must be strings")
+		}
+
+		// orders sent only a destination before forms were validated, the
+		// server id of the old maps stays optional
+		name := nameToken.(string)
+		fields = append(fields, &FormField{
+			Name:     name,
+			Type:     fieldType,
+			Label:    strings.ReplaceAll(name, "_", " "),
+			Required: len(fields) == 0,
+		})
+	}
+
+	return fields, nil
+}
+
+func (s *FormSchema) compile() error {
+	if len(s.Fields) == 0 {
+		return errors.New("format form needs at least one field")
+	}
+	if len(s.Fields) > maxFormFields {
+		return fmt.Errorf("format form has at most %d fields", maxFormFields)
+	}
+
+	seen := make(map[string]bool, len(s.Fields))
+	for _, field := range s.Fields {
+		if field.Name == "" {
+			return errors.New("format form field name is required")
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("format form field %s is duplicated", field.Name)
+		}
+		seen[field.Name] = true
+
+		if field.Type != FormFieldString && field.Type != FormFieldNumber {
+			return fmt.Errorf("format form field %s has unknown type %s", field.Name, field.Type)
+		}
+		if field.MinLength < 0 || field.MaxLength < 0 || (field.MaxLength > 0 && field.MinLength > field.MaxLength) {
+			return fmt.Errorf("format form field %s has invalid length limits", field.Name)
+		}
+		if field.Label == "" {
+			field.Label = strings.ReplaceAll(field.Name, "_", " ")
+		}
+
+		if field.Pattern != "" {
+			pattern, err := regexp.Compile(field.Pattern)
+			if err != nil {
+				return fmt.Errorf("format form field %s has invalid pattern", field.Name)
+			}
+			field.pattern = pattern
+		}
+	}
+
+	return nil
+}
+
+// Values maps destination and server id onto the fields by position.
+func (s *FormSchema) Values(destination string, serverId string) map[string]string {
+	values := make(map[string]string, len(s.Fields))
+	for i, value := range []string{destination, serverId} {
+		if i < len(s.Fields) {
+			values[s.Fields[i].Name] = value
+		}
+	}
+
+	return values
+}
+
+// Destination is the reverse of Values.
+func (s *FormSchema) Destination(values map[string]string) (destination string, serverId string) {
+	destination = strings.TrimSpace(values[s.Fields[0].Name])
+	if len(s.Fields) > 1 {
+		serverId = strings.TrimSpace(values[s.Fields[1].Name])
+	}
+
+	return destination, serverId
+}
+
+// Validate returns nil when values fit the form, otherwise the errors of
+// every field keyed by field name, nil for fields that passed.
+func (s *FormSchema) Validate(values map[string]string) Errors {
+	errMsgs := make(Errors, len(s.Fields))
+	failed := false
+
+	for _, field := range s.Fields {
+		errMsgs[field.Name] = nil
+		if msg := field.validate(strings.TrimSpace(values[field.Name])); msg != "" {
+			errMsgs[field.Name] = msg
+			failed = true
+		}
+	}
+
+	for name := range values {
+		if _, ok := errMsgs[name]; !ok {
+			errMsgs[name] = fmt.Sprintf("The %s field is not part of this form", name)
+			failed = true
+		}
+	}
+
+	if !failed {
+		return nil
+	}
+
+	return errMsgs
+}
+
+func (f *FormField) validate(value string) string {
+	if value == "" {
+		if f.Required {
+			return fmt.Sprintf("The %s field is required", f.Label)
+		}
+		return ""
+	}
+
+	if f.Type == FormFieldNumber {
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+			return fmt.Sprintf("The %s field must be a number", f.Label)
+		}
+	}
+
+	length := utf8.RuneCountInString(value)
+	if f.MinLength > 0 && length < f.MinLength {
+		return fmt.Sprintf("The %s field must be at least %d characters", f.Label, f.MinLength)
+	}
+	if f.MaxLength > 0 && length > f.MaxLength {
+		return fmt.Sprintf("The %s field must be at most %d characters", f.Label, f.MaxLength)
+	}
+
+	if f.pattern != nil && !f.pattern.MatchString(value) {
+		if f.HelpText != "" {
+			return f.HelpText
+		}
+		return fmt.Sprintf("The %s field format is invalid", f.Label)
+	}
+
+	return ""
+}
@@ -0,0 +1,68 @@
+package shared
+
+import "testing"
+
+func TestParseFormSchemaLegacy(t *testing.T) {
+	form, err := ParseFormSchema(`{"phone_number": "string", "amount": "number"}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(form.Fields) != 2 || form.Fields[0].Name != "phone_number" || form.Fields[1].Name != "amount" {
+		t.Fatalf("fields lost their order: %+v", form.Fields)
+	}
+	if !form.Fields[0].Required || form.Fields[1].Required {
+		t.Fatalf("only the first legacy field should be required")
+	}
+
+	if errs := form.Validate(form.Values("081234567890", "")); errs != nil {
+		t.Fatalf("destination without server id rejected: %v", errs)
+	}
+	if errs := form.Validate(form.Values("", "")); errs == nil {
+		t.Fatalf("empty destination accepted")
+	}
+}
+
+func TestParseFormSchemaFields(t *testing.T) {
+	form, err := ParseFormSchema(`{"fields": [{"name": "phone_number", "type": "number", "required": true, "pattern": "^08[0-9]+$", "min_length": 10, "max_length": 13}]}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		destination string
+		valid       bool
+	}{
+		{"081234567890", true},
+		{"", false},
+		{"0812", false},
+		{"628123456789", false},
+		{"08123456789a", false},
+	}
+
+	for _, test := range tests {
+		errs := form.Validate(form.Values(test.destination, ""))
+		if (errs == nil) != test.valid {
+			t.Errorf("destination %q: valid = %v, errors %v", test.destination, errs == nil, errs)
+		}
+	}
+
+	if errs := form.Validate(map[string]string{"phone_number": "081234567890", "amount": "5"}); errs == nil {
+		t.Errorf("field outside the form accepted")
+	}
+}
+
+func TestParseFormSchemaInvalid(t *testing.T) {
+	for _, formatForm := range []string{
+		`[]`,
+		`{"fields": []}`,
+		`{"a": "string", "b": "string", "c": "string"}`,
+		`{"a": "date"}`,
+		`{"fields": [{"name": "a", "type": "string", "pattern": "("}]}`,
+		`{"fields": [{"name": "a", "type": "string"}, {"name": "a", "type": "string"}]}`,
+	} {
+		if _, err := ParseFormSchema(formatForm); err == nil {
+			t.Errorf("%s: expected an error", formatForm)
+		}
+	}
+}
@@ -1,14 +1,21 @@
 package shared
 
 import (
+	"context"
 	"log/slog"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 )
 
-func NewGrpcClientConn(target string) *grpc.ClientConn {
-	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+// serviceAuthorizationKey carries service tokens, apart from the user token
+// a call may forward in "authorization".
+const serviceAuthorizationKey = "service-authorization"
+
+func NewGrpcClientConn(target string, opts ...grpc.DialOption) *grpc.ClientConn {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	conn, err := grpc.NewClient(target, opts...)
 	if err != nil {
 		slog.Error("error occurred while connect to grpc service", "err", err.Error())
 		panic(err)
@@ -16,3 +23,17 @@ func NewGrpcClientConn(target string) *grpc.ClientConn {
 
 	return conn
 }
+
+// WithServiceToken sends a service token of caller with every call of the
+// connection, for the methods guarded with PermServiceCall.
+func WithServiceToken(caller string) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req any, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		token, err := getServiceToken(caller)
+		if err != nil {
+			return err
+		}
+
+		ctx = metadata.AppendToOutgoingContext(ctx, serviceAuthorizationKey, "Bearer "+token)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	})
+}
@@ -3,10 +3,12 @@ package shared
 import (
 	"fmt"
 	"github.com/gofiber/fiber/v2"
+	"sync"
 	"time"
 )
 
 var (
+	tokenMu         sync.Mutex
 	cachedToken     string
 	tokenExpiryTime time.Time
 )
@@ -18,6 +20,9 @@ type HttpClientRes struct {
 }
 
 func getServiceToken(serviceName string) (string, error) {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+
 	if cachedToken != "" && time.Now().Before(tokenExpiryTime) {
 		return cachedToken, nil
 	}
@@ -35,10 +40,12 @@ func getServiceToken(serviceName string) (string, error) {
 	return token, nil
 }
 
-func CallService(hostname string, port string, url string, method string, body interface{}) (*HttpClientRes, error) {
+// CallService calls another service as caller, the token is signed with the
+// secret of caller.
+func CallService(caller string, hostname string, port string, url string, method string, body interface{}) (*HttpClientRes, error) {
 	serviceURL := fmt.Sprintf("http://%s:%s", hostname, port)
 
-	jwtForService, err := getServiceToken(hostname)
+	jwtForService, err := getServiceToken(caller)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,77 @@
+package shared
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const inquiryTokenAudience = "account-inquiry"
+
+const InquiryTokenExpiry = 15 * time.Minute
+
+// InquiryClaims prove a destination resolved to an account, order service
+// takes them instead of asking the provider again.
+type InquiryClaims struct {
+	ProductTypeId int    `json:"product_type_id"`
+	Destination   string `json:"destination"`
+	ServerId      string `json:"server_id,omitempty"`
+	Nickname      string `json:"nickname"`
+	jwt.RegisteredClaims
+}
+
+func getInquiryTokenSecret() ([]byte, error) {
+	secret := os.Getenv("INQUIRY_TOKEN_SECRET")
+	if secret == "" {
+		return nil, errors.New("missing configuration: INQUIRY_TOKEN_SECRET")
+	}
+
+	return []byte(secret), nil
+}
+
+func NewInquiryToken(productTypeId int, destination string, serverId string, nickname string) (string, time.Time, error) {
+	secret, err := getInquiryTokenSecret()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(InquiryTokenExpiry)
+	claims := InquiryClaims{
+		ProductTypeId: productTypeId,
+		Destination:   destination,
+		ServerId:      serverId,
+		Nickname:      nickname,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{inquiryTokenAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signed, expiresAt, nil
+}
+
+// ParseInquiryToken checks the signature, audience and expiry of a token.
+func ParseInquiryToken(signed string) (*InquiryClaims, error) {
+	secret, err := getInquiryTokenSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &InquiryClaims{}
+	_, err = jwt.ParseWithClaims(signed, claims, func(token *jwt.Token) (interface{}, error) {
+		return secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(inquiryTokenAudience))
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
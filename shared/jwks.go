@@ -0,0 +1,205 @@
+package shared
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	jwksCacheTTL        = 5 * time.Minute
+	jwksMinRefreshDelay = 10 * time.Second
+)
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKSSource returns the current set of public keys used to verify user tokens.
+type JWKSSource func(ctx context.Context) (*JWKS, error)
+
+type jwksCache struct {
+	mu          sync.RWMutex
+	source      JWKSSource
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+}
+
+var userKeys = &jwksCache{source: fetchJWKSFromURL}
+
+// UseJWKSSource replaces the default HTTP fetcher. auth_service uses it to
+// verify against its own key manager instead of calling itself over HTTP.
+func UseJWKSSource(source JWKSSource) {
+	userKeys.mu.Lock()
+	defer userKeys.mu.Unlock()
+
+	userKeys.source = source
+	userKeys.keys = nil
+	userKeys.fetchedAt = time.Time{}
+	userKeys.lastAttempt = time.Time{}
+}
+
+func NewJWK(kid string, alg string, pub crypto.PublicKey) (JWK, error) {
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP",
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(key),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key size")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
+
+func (c *jwksCache) get(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	fresh := time.Since(c.fetchedAt) < jwksCacheTTL
+	c.mu.RUnlock()
+
+	if ok && fresh {
+		return key, nil
+	}
+
+	// refresh when the cache is stale or the kid is unknown (the key was
+	// probably rotated), but never hammer the source on garbage kids
+	if err := c.refresh(ctx); err != nil && !ok {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key, ok = c.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	return key, nil
+}
+
+// refresh fetches outside the lock so token checks keep reading the cached
+// keys meanwhile, lastAttempt lets only one caller fetch at a time.
+func (c *jwksCache) refresh(ctx context.Context) error {
+	c.mu.Lock()
+	if time.Since(c.lastAttempt) < jwksMinRefreshDelay {
+		c.mu.Unlock()
+		return nil
+	}
+	c.lastAttempt = time.Now()
+	source := c.source
+	c.mu.Unlock()
+
+	jwks, err := source(ctx)
+	if err != nil {
+		slog.Error("Error fetching JWKS", "err", err)
+		return err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		pub, err := jwk.PublicKey()
+		if err != nil {
+			slog.Warn("Skipping invalid JWK", "kid", jwk.Kid, "err", err)
+			continue
+		}
+		keys[jwk.Kid] = pub
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.keys = keys
+	c.fetchedAt = time.Now()
+
+	return nil
+}
+
+func fetchJWKSFromURL(_ context.Context) (*JWKS, error) {
+	jwksURL := os.Getenv("JWKS_URL")
+	if jwksURL == "" {
+		return nil, errors.New("missing configuration: JWKS_URL")
+	}
+
+	statusCode, body, errs := fiber.Get(jwksURL).Timeout(5 * time.Second).Bytes()
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	if statusCode != fiber.StatusOK {
+		return nil, fmt.Errorf("jwks endpoint returned status %d", statusCode)
+	}
+
+	var jwks JWKS
+	if err := json.Unmarshal(body, &jwks); err != nil {
+		return nil, err
+	}
+
+	return &jwks, nil
+}
@@ -1,19 +1,32 @@
 package shared
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
-var (
-	serviceSecretKey []byte // Replace with your actual secret key
-	secretKey        []byte
-)
+const UserTokenIssuer = "topupstore-microservice"
+
+// UserTokenSigningMethods lists the asymmetric algorithms auth service may sign
+// user tokens with. HMAC is deliberately absent so a leaked service secret
+// can never be used to mint user tokens.
+var UserTokenSigningMethods = []string{"EdDSA", "RS256"}
+
+// serviceSecrets caches the per service secrets read from
+// SERVICE_JWT_SECRETS_DIR, keyed by service name.
+var serviceSecrets sync.Map
+
+var serviceNamePattern = regexp.MustCompile(`^[a-z]+(-[a-z]+)*$`)
 
 type ServiceCustomClaims struct {
 	Service string `json:"service"`
@@ -36,22 +49,32 @@ func JWTServiceMiddleware(c *fiber.Ctx) error {
 	return c.Next()
 }
 
+// GenerateJWTForService signs a token as serviceName with the secret of that
+// service. Every service has its own secret and only the services it calls
+// are given a copy, a leaked secret cannot impersonate any other service.
 func GenerateJWTForService(serviceName string) (string, error) {
 	claims := ServiceCustomClaims{
 		Service: serviceName,
 		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    serviceName,
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	secret := getServiceSecretKey()
+	secret, err := getServiceSecret(serviceName)
+	if err != nil {
+		return "", err
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = serviceName
 	return token.SignedString(secret)
 }
 
+// ValidateJWTForService accepts tokens of the services listed in
+// SERVICE_JWT_CALLERS, the kid header names the calling service.
 func ValidateJWTForService(tokenString string) (*ServiceCustomClaims, *jwt.Token, error) {
 	claims := &ServiceCustomClaims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
@@ -59,8 +82,15 @@ func ValidateJWTForService(tokenString string) (*ServiceCustomClaims, *jwt.Token
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		// Return the secret key for validation
-		return getServiceSecretKey(), nil
+
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" || kid != claims.Service {
+			return nil, fmt.Errorf("kid %q does not match service %q", kid, claims.Service)
+		}
+		if !isAllowedCaller(kid) {
+			return nil, fmt.Errorf("service %s is not allowed to call this service", kid)
+		}
+		return getServiceSecret(kid)
 	})
 
 	if err != nil {
@@ -77,39 +107,47 @@ func JWTUserMiddleware(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
 	}
 
-	token, err := ValidateJWTForUser(jwtToken)
-	if err != nil || !token.Valid {
+	claims, err := ParseUserClaims(jwtToken)
+	if err != nil {
 		slog.Error("Error validating token", "err", err)
 		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
 	}
 
+	c.Locals(userClaimsKey, claims)
+
 	return c.Next()
 }
 
-func GenerateJWTForUser(userID string, expiry time.Time) (string, error) {
-	claims := jwt.RegisteredClaims{
-		Subject:   userID,
-		Issuer:    "topupstore-microservice",
-		ExpiresAt: jwt.NewNumericDate(expiry),
-		NotBefore: jwt.NewNumericDate(time.Now()),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+func ValidateJWTForUser(tokenString string) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, &UserClaims{}, userKeyFunc,
+		jwt.WithValidMethods(UserTokenSigningMethods), jwt.WithIssuer(UserTokenIssuer))
+}
+
+func ParseUserClaims(tokenString string) (*UserClaims, error) {
+	token, err := ValidateJWTForUser(tokenString)
+	if err != nil {
+		return nil, err
 	}
 
-	secret := getSecretKey()
+	claims, ok := token.Claims.(*UserClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secret)
+	if err := checkRevoked(context.Background(), claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
 }
 
-func ValidateJWTForUser(tokenString string) (*jwt.Token, error) {
-	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Validate the algorithm
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		// Return the secret key for validation
-		return getSecretKey(), nil
-	})
+func userKeyFunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, fmt.Errorf("missing kid header")
+	}
+	// Return the public key published by auth service for this kid
+	return userKeys.get(context.Background(), kid)
 }
 
 func GetUserIdFromToken(c *fiber.Ctx) (string, error) {
@@ -118,12 +156,12 @@ func GetUserIdFromToken(c *fiber.Ctx) (string, error) {
 		return "", fiber.NewError(fiber.StatusUnauthorized, err.Error())
 	}
 
-	claims, err := ValidateJWTForUser(token)
+	claims, err := ParseUserClaims(token)
 	if err != nil {
 		return "", fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
 	}
 
-	return claims.Claims.GetSubject()
+	return claims.Subject, nil
 }
 
 func GetTokenFromRequest(c *fiber.Ctx) (string, error) {
@@ -137,24 +175,46 @@ func GetTokenFromRequest(c *fiber.Ctx) (string, error) {
 	return token, nil
 }
 
-func getServiceSecretKey() []byte {
-	if serviceSecretKey != nil {
-		return serviceSecretKey
+// isAllowedCaller keeps a service from accepting its own tokens, the
+// services it calls hold its secret too.
+func isAllowedCaller(serviceName string) bool {
+	for _, caller := range strings.Split(os.Getenv("SERVICE_JWT_CALLERS"), ",") {
+		if strings.TrimSpace(caller) == serviceName {
+			return true
+		}
 	}
 
-	key := os.Getenv("SERVICE_JWT_SECRET_KEY")
-	serviceSecretKey = []byte(key)
-
-	return serviceSecretKey
+	return false
 }
 
-func getSecretKey() []byte {
-	if secretKey != nil {
-		return secretKey
+// getServiceSecret reads the secret of a service from the service_jwt_<name>
+// file in SERVICE_JWT_SECRETS_DIR (/run/secrets by default). A missing file
+// means this service does not accept calls from that one.
+func getServiceSecret(serviceName string) ([]byte, error) {
+	if secret, ok := serviceSecrets.Load(serviceName); ok {
+		return secret.([]byte), nil
+	}
+
+	if !serviceNamePattern.MatchString(serviceName) {
+		return nil, fmt.Errorf("invalid service name %q", serviceName)
+	}
+
+	dir := os.Getenv("SERVICE_JWT_SECRETS_DIR")
+	if dir == "" {
+		dir = "/run/secrets"
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "service_jwt_"+strings.ReplaceAll(serviceName, "-", "_")))
+	if err != nil {
+		return nil, fmt.Errorf("no secret for service %s: %w", serviceName, err)
+	}
+
+	secret := bytes.TrimSpace(content)
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("secret of service %s is shorter than 32 bytes", serviceName)
 	}
 
-	key := os.Getenv("USER_JWT_SECRET_KEY")
-	secretKey = []byte(key)
+	serviceSecrets.Store(serviceName, secret)
 
-	return secretKey
+	return secret, nil
 }
@@ -0,0 +1,28 @@
+package shared
+
+import "strings"
+
+// NormalizeMsisdn turns an Indonesian mobile number written as +62..., 62...
+// or 0... into the local 08... form. The bool is false for anything else.
+func NormalizeMsisdn(msisdn string) (string, bool) {
+	number := strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "").Replace(strings.TrimSpace(msisdn))
+	number = strings.TrimPrefix(number, "+")
+
+	switch {
+	case strings.HasPrefix(number, "62"):
+		number = "0" + number[2:]
+	case strings.HasPrefix(number, "8"):
+		number = "0" + number
+	}
+
+	if !strings.HasPrefix(number, "08") || len(number) < 10 || len(number) > 13 {
+		return "", false
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+
+	return number, true
+}
@@ -0,0 +1,23 @@
+package shared
+
+import (
+	"os"
+	"strings"
+)
+
+// ClientIPHeader is set by nginx to the address of the client it proxies.
+const ClientIPHeader = "X-Real-IP"
+
+// TrustedProxies lists the addresses or CIDRs in TRUSTED_PROXIES whose
+// ClientIPHeader is believed. Requests from anywhere else keep their remote
+// address, so the header cannot be spoofed by calling a service directly.
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+
+	return proxies
+}
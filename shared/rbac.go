@@ -0,0 +1,181 @@
+package shared
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	RoleCustomer     = "customer"
+	RoleSupport      = "support"
+	RoleCatalogAdmin = "catalog-admin"
+	RoleFinance      = "finance"
+	RoleSuperadmin   = "superadmin"
+)
+
+const (
+	PermUsersRead        = "users:read"
+	PermUsersWrite       = "users:write"
+	PermUsersManageRoles = "users:manage_roles"
+	PermOrdersReadAll    = "orders:read_all"
+	PermOrdersManage     = "orders:manage"
+	PermCatalogWrite     = "catalog:write"
+	PermPaymentsRead     = "payments:read"
+	PermWalletManage     = "wallet:manage"
+
+	// PermServiceCall marks gRPC methods only services may call, with a
+	// service token of a caller listed in SERVICE_JWT_CALLERS
+	PermServiceCall = "service:call"
+)
+
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusBanned    = "banned"
+)
+
+// membership tiers, products may have a price override per tier
+const (
+	TierRetail   = "retail"
+	TierSilver   = "silver"
+	TierGold     = "gold"
+	TierReseller = "reseller"
+)
+
+func IsTier(tier string) bool {
+	return tier == TierRetail || tier == TierSilver || tier == TierGold || tier == TierReseller
+}
+
+const userClaimsKey = "user_claims"
+
+type UserClaims struct {
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// Tier is the tier at login time, it may be outdated until the next login
+	Tier string `json:"tier,omitempty"`
+	jwt.RegisteredClaims
+}
+
+func (u *UserClaims) HasPermission(permission string) bool {
+	return slices.Contains(u.Permissions, permission)
+}
+
+// RequirePermission only lets requests through when the user token carries
+// the given permission. The claims are stored in the context for handlers.
+func RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		jwtToken, err := GetTokenFromRequest(c)
+		if err != nil {
+			slog.Error("Error getting token from request", "err", err)
+			return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		}
+
+		claims, err := ParseUserClaims(jwtToken)
+		if err != nil {
+			slog.Error("Error validating token", "err", err)
+			return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+		}
+
+		if !claims.HasPermission(permission) {
+			slog.Warn("Permission denied", "sub", claims.Subject, "permission", permission)
+			return fiber.NewError(fiber.StatusForbidden, "You do not have permission to access this resource")
+		}
+
+		c.Locals(userClaimsKey, claims)
+
+		return c.Next()
+	}
+}
+
+// OptionalUserClaims is for public endpoints that show more to logged-in
+// users. It returns nil for anonymous requests and invalid tokens.
+func OptionalUserClaims(c *fiber.Ctx) *UserClaims {
+	if claims := GetUserClaims(c); claims != nil {
+		return claims
+	}
+
+	jwtToken, err := GetTokenFromRequest(c)
+	if err != nil {
+		return nil
+	}
+
+	claims, err := ParseUserClaims(jwtToken)
+	if err != nil {
+		slog.Warn("Ignoring invalid token on public endpoint", "err", err)
+		return nil
+	}
+
+	c.Locals(userClaimsKey, claims)
+
+	return claims
+}
+
+// GetUserClaims returns the claims stored by JWTUserMiddleware or
+// RequirePermission, nil when the request did not pass through them.
+func GetUserClaims(c *fiber.Ctx) *UserClaims {
+	claims, _ := c.Locals(userClaimsKey).(*UserClaims)
+	return claims
+}
+
+// PermissionUnaryInterceptor guards the gRPC methods listed in rules (full
+// method name to permission). The caller must forward the user token in the
+// "authorization" metadata, see WithBearerToken, or for PermServiceCall send
+// its service token, see WithServiceToken. Unlisted methods pass through.
+func PermissionUnaryInterceptor(rules map[string]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		permission, ok := rules[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		if permission == PermServiceCall {
+			values := md.Get(serviceAuthorizationKey)
+			if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+				return nil, status.Error(codes.Unauthenticated, "missing or invalid service authorization metadata")
+			}
+
+			claims, _, err := ValidateJWTForService(strings.TrimPrefix(values[0], "Bearer "))
+			if err != nil {
+				slog.Warn("Rejected service token", "method", info.FullMethod, "err", err)
+				return nil, status.Error(codes.Unauthenticated, "Invalid service token")
+			}
+
+			slog.Debug("Service call", "service", claims.Service, "method", info.FullMethod)
+			return handler(ctx, req)
+		}
+
+		values := md.Get("authorization")
+		if len(values) == 0 || !strings.HasPrefix(values[0], "Bearer ") {
+			return nil, status.Error(codes.Unauthenticated, "missing or invalid authorization metadata")
+		}
+
+		claims, err := ParseUserClaims(strings.TrimPrefix(values[0], "Bearer "))
+		if err != nil {
+			slog.Error("Error validating token", "err", err)
+			return nil, status.Error(codes.Unauthenticated, "Invalid token")
+		}
+
+		if !claims.HasPermission(permission) {
+			slog.Warn("Permission denied", "sub", claims.Subject, "permission", permission, "method", info.FullMethod)
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// WithBearerToken forwards a user token to a downstream gRPC call.
+func WithBearerToken(ctx context.Context, token string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
@@ -1,9 +1,11 @@
 package shared
 
 import (
+	"context"
 	"fmt"
 	"github.com/redis/go-redis/v9"
 	"os"
+	"time"
 )
 
 func NewRedis() *redis.Client {
@@ -15,3 +17,26 @@ func NewRedis() *redis.Client {
 		DB:   0,
 	})
 }
+
+// AllowRequest is a fixed window rate limiter. It reports whether the caller
+// identified by key made at most limit requests in the current window.
+func AllowRequest(ctx context.Context, client *redis.Client, key string, limit int64, window time.Duration) (bool, error) {
+	key = "rate-limit:" + key
+
+	count, err := client.Incr(ctx, key).Result()
+	if err != nil {
+		return false, err
+	}
+
+	if count == 1 {
+		if err := client.Expire(ctx, key, window).Err(); err != nil {
+			return false, err
+		}
+	}
+
+	return count <= limit, nil
+}
+
+func ResetRateLimit(ctx context.Context, client *redis.Client, key string) error {
+	return client.Del(ctx, "rate-limit:"+key).Err()
+}
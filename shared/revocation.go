@@ -0,0 +1,55 @@
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// UserTokenMaxLifetime is the longest an access token can be valid, a
+// revocation is kept that long.
+const UserTokenMaxLifetime = 24 * time.Hour
+
+var ErrTokenRevoked = errors.New("token revoked")
+
+// revocationClient is shared by every user token check of the process.
+var revocationClient = sync.OnceValue(NewRedis)
+
+func tokensRevokedKey(userId int) string {
+	return fmt.Sprintf("user-tokens-revoked:%d", userId)
+}
+
+// RevokeUserTokens rejects every token issued to the user until now. Roles,
+// permissions and status are copied into tokens at login, changes to them
+// call this so they apply at once instead of on the next login.
+func RevokeUserTokens(ctx context.Context, userId int) error {
+	return revocationClient().Set(ctx, tokensRevokedKey(userId), time.Now().Unix(), UserTokenMaxLifetime).Err()
+}
+
+// checkRevoked fails for tokens issued in or before the second their user's
+// tokens were revoked. It fails closed when Redis cannot be read.
+func checkRevoked(ctx context.Context, claims *UserClaims) error {
+	userId, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return fmt.Errorf("invalid subject %q", claims.Subject)
+	}
+
+	revokedAt, err := revocationClient().Get(ctx, tokensRevokedKey(userId)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if claims.IssuedAt == nil || claims.IssuedAt.Unix() <= revokedAt {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
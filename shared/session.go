@@ -0,0 +1,65 @@
+package shared
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const maxSessions = 20
+
+// Session is a login recorded by auth service. Access tokens are stateless,
+// so this is only a history for the user to look at, not a revocation list.
+type Session struct {
+	Id        string    `json:"id"`
+	IpAddress string    `json:"ip_address"`
+	Device    string    `json:"device"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func sessionsKey(userId int) string {
+	return fmt.Sprintf("user-sessions:%d", userId)
+}
+
+func RecordSession(ctx context.Context, client *redis.Client, userId int, session *Session) error {
+	value, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	key := sessionsKey(userId)
+	_, err = client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, key, value)
+		pipe.LTrim(ctx, key, 0, maxSessions-1)
+		pipe.ExpireAt(ctx, key, session.ExpiresAt)
+		return nil
+	})
+
+	return err
+}
+
+func GetSessions(ctx context.Context, client *redis.Client, userId int) ([]*Session, error) {
+	values, err := client.LRange(ctx, sessionsKey(userId), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(values))
+	for _, value := range values {
+		var session Session
+		if err := json.Unmarshal([]byte(value), &session); err != nil {
+			continue
+		}
+		sessions = append(sessions, &session)
+	}
+
+	return sessions, nil
+}
+
+func DeleteSessions(ctx context.Context, client *redis.Client, userId int) error {
+	return client.Del(ctx, sessionsKey(userId)).Err()
+}
@@ -9,6 +9,7 @@ package upb
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
@@ -28,10 +29,13 @@ type User struct {
 	Name            string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
 	Email           string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
 	PhoneNumber     string                 `protobuf:"bytes,4,opt,name=phone_number,json=phoneNumber,proto3" json:"phone_number,omitempty"`
-	Password        string                 `protobuf:"bytes,5,opt,name=password,proto3" json:"password,omitempty"`
 	EmailVerifiedAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=email_verified_at,json=emailVerifiedAt,proto3,oneof" json:"email_verified_at,omitempty"`
 	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	UpdatedAt       *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	Roles           []string               `protobuf:"bytes,9,rep,name=roles,proto3" json:"roles,omitempty"`
+	Permissions     []string               `protobuf:"bytes,10,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	Status          string                 `protobuf:"bytes,11,opt,name=status,proto3" json:"status,omitempty"` // "active", "suspended" or "banned"
+	Tier            string                 `protobuf:"bytes,12,opt,name=tier,proto3" json:"tier,omitempty"`     // "retail", "silver", "gold" or "reseller"
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
@@ -94,13 +98,6 @@ func (x *User) GetPhoneNumber() string {
 	return ""
 }
 
-func (x *User) GetPassword() string {
-	if x != nil {
-		return x.Password
-	}
-	return ""
-}
-
 func (x *User) GetEmailVerifiedAt() *timestamppb.Timestamp {
 	if x != nil {
 		return x.EmailVerifiedAt
@@ -122,15 +119,47 @@ func (x *User) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *User) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *User) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *User) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *User) GetTier() string {
+	if x != nil {
+		return x.Tier
+	}
+	return ""
+}
+
 type CreateUserReq struct {
-	state                  protoimpl.MessageState `protogen:"open.v1"`
-	Name                   string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Email                  string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
-	Password               string                 `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"` // only in request
-	PhoneNumber            string                 `protobuf:"bytes,4,opt,name=phone_number,json=phoneNumber,proto3" json:"phone_number,omitempty"`
-	EmailVerificationToken string                 `protobuf:"bytes,5,opt,name=email_verification_token,json=emailVerificationToken,proto3" json:"email_verification_token,omitempty"`
-	unknownFields          protoimpl.UnknownFields
-	sizeCache              protoimpl.SizeCache
+	state                      protoimpl.MessageState `protogen:"open.v1"`
+	Name                       string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Email                      string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Password                   string                 `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"` // only in request
+	PhoneNumber                string                 `protobuf:"bytes,4,opt,name=phone_number,json=phoneNumber,proto3" json:"phone_number,omitempty"`
+	EmailVerificationToken     string                 `protobuf:"bytes,5,opt,name=email_verification_token,json=emailVerificationToken,proto3" json:"email_verification_token,omitempty"` // sha256 of the token sent to the user
+	EmailVerificationExpiresAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=email_verification_expires_at,json=emailVerificationExpiresAt,proto3" json:"email_verification_expires_at,omitempty"`
+	ReferralCode               string                 `protobuf:"bytes,7,opt,name=referral_code,json=referralCode,proto3" json:"referral_code,omitempty"` // optional, code of the user who invited this one
+	SignupIp                   string                 `protobuf:"bytes,8,opt,name=signup_ip,json=signupIp,proto3" json:"signup_ip,omitempty"`
+	SignupDevice               string                 `protobuf:"bytes,9,opt,name=signup_device,json=signupDevice,proto3" json:"signup_device,omitempty"`
+	unknownFields              protoimpl.UnknownFields
+	sizeCache                  protoimpl.SizeCache
 }
 
 func (x *CreateUserReq) Reset() {
@@ -198,6 +227,34 @@ func (x *CreateUserReq) GetEmailVerificationToken() string {
 	return ""
 }
 
+func (x *CreateUserReq) GetEmailVerificationExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EmailVerificationExpiresAt
+	}
+	return nil
+}
+
+func (x *CreateUserReq) GetReferralCode() string {
+	if x != nil {
+		return x.ReferralCode
+	}
+	return ""
+}
+
+func (x *CreateUserReq) GetSignupIp() string {
+	if x != nil {
+		return x.SignupIp
+	}
+	return ""
+}
+
+func (x *CreateUserReq) GetSignupDevice() string {
+	if x != nil {
+		return x.SignupDevice
+	}
+	return ""
+}
+
 type CreateUserRes struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Msg           string                 `protobuf:"bytes,1,opt,name=msg,proto3" json:"msg,omitempty"`
@@ -374,27 +431,28 @@ func (x *GetUserRes) GetUser() *User {
 	return nil
 }
 
-type ResetPasswordRes struct {
+type VerifyCredentialsReq struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Msg           string                 `protobuf:"bytes,1,opt,name=msg,proto3" json:"msg,omitempty"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ResetPasswordRes) Reset() {
-	*x = ResetPasswordRes{}
+func (x *VerifyCredentialsReq) Reset() {
+	*x = VerifyCredentialsReq{}
 	mi := &file_user_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ResetPasswordRes) String() string {
+func (x *VerifyCredentialsReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResetPasswordRes) ProtoMessage() {}
+func (*VerifyCredentialsReq) ProtoMessage() {}
 
-func (x *ResetPasswordRes) ProtoReflect() protoreflect.Message {
+func (x *VerifyCredentialsReq) ProtoReflect() protoreflect.Message {
 	mi := &file_user_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -406,40 +464,46 @@ func (x *ResetPasswordRes) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResetPasswordRes.ProtoReflect.Descriptor instead.
-func (*ResetPasswordRes) Descriptor() ([]byte, []int) {
+// Deprecated: Use VerifyCredentialsReq.ProtoReflect.Descriptor instead.
+func (*VerifyCredentialsReq) Descriptor() ([]byte, []int) {
 	return file_user_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *ResetPasswordRes) GetMsg() string {
+func (x *VerifyCredentialsReq) GetEmail() string {
 	if x != nil {
-		return x.Msg
+		return x.Email
 	}
 	return ""
 }
 
-type ResetPasswordByEmailReq struct {
+func (x *VerifyCredentialsReq) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type GetUsersByIdsReq struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
-	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	Ids           []int32                `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"` // at most 100, unknown ids are skipped
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ResetPasswordByEmailReq) Reset() {
-	*x = ResetPasswordByEmailReq{}
+func (x *GetUsersByIdsReq) Reset() {
+	*x = GetUsersByIdsReq{}
 	mi := &file_user_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ResetPasswordByEmailReq) String() string {
+func (x *GetUsersByIdsReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ResetPasswordByEmailReq) ProtoMessage() {}
+func (*GetUsersByIdsReq) ProtoMessage() {}
 
-func (x *ResetPasswordByEmailReq) ProtoReflect() protoreflect.Message {
+func (x *GetUsersByIdsReq) ProtoReflect() protoreflect.Message {
 	mi := &file_user_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -451,46 +515,39 @@ func (x *ResetPasswordByEmailReq) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ResetPasswordByEmailReq.ProtoReflect.Descriptor instead.
-func (*ResetPasswordByEmailReq) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetUsersByIdsReq.ProtoReflect.Descriptor instead.
+func (*GetUsersByIdsReq) Descriptor() ([]byte, []int) {
 	return file_user_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *ResetPasswordByEmailReq) GetEmail() string {
-	if x != nil {
-		return x.Email
-	}
-	return ""
-}
-
-func (x *ResetPasswordByEmailReq) GetPassword() string {
+func (x *GetUsersByIdsReq) GetIds() []int32 {
 	if x != nil {
-		return x.Password
+		return x.Ids
 	}
-	return ""
+	return nil
 }
 
-type VerifyEmailReq struct {
-	state                  protoimpl.MessageState `protogen:"open.v1"`
-	EmailVerificationToken string                 `protobuf:"bytes,1,opt,name=email_verification_token,json=emailVerificationToken,proto3" json:"email_verification_token,omitempty"`
-	unknownFields          protoimpl.UnknownFields
-	sizeCache              protoimpl.SizeCache
+type GetUsersByIdsRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *VerifyEmailReq) Reset() {
-	*x = VerifyEmailReq{}
+func (x *GetUsersByIdsRes) Reset() {
+	*x = GetUsersByIdsRes{}
 	mi := &file_user_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *VerifyEmailReq) String() string {
+func (x *GetUsersByIdsRes) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*VerifyEmailReq) ProtoMessage() {}
+func (*GetUsersByIdsRes) ProtoMessage() {}
 
-func (x *VerifyEmailReq) ProtoReflect() protoreflect.Message {
+func (x *GetUsersByIdsRes) ProtoReflect() protoreflect.Message {
 	mi := &file_user_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -502,39 +559,45 @@ func (x *VerifyEmailReq) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use VerifyEmailReq.ProtoReflect.Descriptor instead.
-func (*VerifyEmailReq) Descriptor() ([]byte, []int) {
+// Deprecated: Use GetUsersByIdsRes.ProtoReflect.Descriptor instead.
+func (*GetUsersByIdsRes) Descriptor() ([]byte, []int) {
 	return file_user_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *VerifyEmailReq) GetEmailVerificationToken() string {
+func (x *GetUsersByIdsRes) GetUsers() []*User {
 	if x != nil {
-		return x.EmailVerificationToken
+		return x.Users
 	}
-	return ""
+	return nil
 }
 
-type VerifyEmailRes struct {
+type ListUsersReq struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Msg           string                 `protobuf:"bytes,1,opt,name=msg,proto3" json:"msg,omitempty"`
+	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"` // defaults to 20, at most 100
+	Cursor        string                 `protobuf:"bytes,2,opt,name=cursor,proto3" json:"cursor,omitempty"`                      // next_cursor of the previous page
+	Verified      *bool                  `protobuf:"varint,3,opt,name=verified,proto3,oneof" json:"verified,omitempty"`
+	CreatedFrom   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"`
+	CreatedTo     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`
+	Query         string                 `protobuf:"bytes,6,opt,name=query,proto3" json:"query,omitempty"` // matches name, email or phone number
+	Status        string                 `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *VerifyEmailRes) Reset() {
-	*x = VerifyEmailRes{}
+func (x *ListUsersReq) Reset() {
+	*x = ListUsersReq{}
 	mi := &file_user_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *VerifyEmailRes) String() string {
+func (x *ListUsersReq) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*VerifyEmailRes) ProtoMessage() {}
+func (*ListUsersReq) ProtoMessage() {}
 
-func (x *VerifyEmailRes) ProtoReflect() protoreflect.Message {
+func (x *ListUsersReq) ProtoReflect() protoreflect.Message {
 	mi := &file_user_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -546,67 +609,1534 @@ func (x *VerifyEmailRes) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use VerifyEmailRes.ProtoReflect.Descriptor instead.
-func (*VerifyEmailRes) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListUsersReq.ProtoReflect.Descriptor instead.
+func (*ListUsersReq) Descriptor() ([]byte, []int) {
 	return file_user_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *VerifyEmailRes) GetMsg() string {
+func (x *ListUsersReq) GetPageSize() int32 {
 	if x != nil {
-		return x.Msg
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListUsersReq) GetCursor() string {
+	if x != nil {
+		return x.Cursor
 	}
 	return ""
 }
 
-var File_user_proto protoreflect.FileDescriptor
+func (x *ListUsersReq) GetVerified() bool {
+	if x != nil && x.Verified != nil {
+		return *x.Verified
+	}
+	return false
+}
 
-const file_user_proto_rawDesc = "" +
-	"\n" +
-	"\n" +
-	"user.proto\x12\auser.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd8\x02\n" +
-	"\x04User\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
-	"\x05email\x18\x03 \x01(\tR\x05email\x12!\n" +
-	"\fphone_number\x18\x04 \x01(\tR\vphoneNumber\x12\x1a\n" +
-	"\bpassword\x18\x05 \x01(\tR\bpassword\x12K\n" +
-	"\x11email_verified_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampH\x00R\x0femailVerifiedAt\x88\x01\x01\x129\n" +
-	"\n" +
-	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
-	"\n" +
-	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\x14\n" +
-	"\x12_email_verified_at\"\xb2\x01\n" +
-	"\rCreateUserReq\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
-	"\x05email\x18\x02 \x01(\tR\x05email\x12\x1a\n" +
-	"\bpassword\x18\x03 \x01(\tR\bpassword\x12!\n" +
-	"\fphone_number\x18\x04 \x01(\tR\vphoneNumber\x128\n" +
-	"\x18email_verification_token\x18\x05 \x01(\tR\x16emailVerificationToken\"!\n" +
-	"\rCreateUserRes\x12\x10\n" +
-	"\x03msg\x18\x01 \x01(\tR\x03msg\" \n" +
-	"\x0eGetUserByIdReq\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\")\n" +
-	"\x11GetUserByEmailReq\x12\x14\n" +
-	"\x05email\x18\x02 \x01(\tR\x05email\"/\n" +
-	"\n" +
-	"GetUserRes\x12!\n" +
-	"\x04user\x18\x01 \x01(\v2\r.user.v1.UserR\x04user\"$\n" +
-	"\x10ResetPasswordRes\x12\x10\n" +
-	"\x03msg\x18\x01 \x01(\tR\x03msg\"K\n" +
-	"\x17ResetPasswordByEmailReq\x12\x14\n" +
-	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
-	"\bpassword\x18\x02 \x01(\tR\bpassword\"J\n" +
-	"\x0eVerifyEmailReq\x128\n" +
-	"\x18email_verification_token\x18\x01 \x01(\tR\x16emailVerificationToken\"\"\n" +
-	"\x0eVerifyEmailRes\x12\x10\n" +
-	"\x03msg\x18\x01 \x01(\tR\x03msg2\xe1\x02\n" +
-	"\vUserService\x12<\n" +
-	"\n" +
-	"CreateUser\x12\x16.user.v1.CreateUserReq\x1a\x16.user.v1.CreateUserRes\x12;\n" +
-	"\vGetUserById\x12\x17.user.v1.GetUserByIdReq\x1a\x13.user.v1.GetUserRes\x12A\n" +
-	"\x0eGetUserByEmail\x12\x1a.user.v1.GetUserByEmailReq\x1a\x13.user.v1.GetUserRes\x12S\n" +
+func (x *ListUsersReq) GetCreatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return nil
+}
+
+func (x *ListUsersReq) GetCreatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return nil
+}
+
+func (x *ListUsersReq) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *ListUsersReq) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type ListUsersRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"` // empty on the last page
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsersRes) Reset() {
+	*x = ListUsersRes{}
+	mi := &file_user_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersRes) ProtoMessage() {}
+
+func (x *ListUsersRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersRes.ProtoReflect.Descriptor instead.
+func (*ListUsersRes) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ListUsersRes) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+func (x *ListUsersRes) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type UpdateUserReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	User          *User                  `protobuf:"bytes,2,opt,name=user,proto3" json:"user,omitempty"`
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,3,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"` // name, email, phone_number or tier
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateUserReq) Reset() {
+	*x = UpdateUserReq{}
+	mi := &file_user_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateUserReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateUserReq) ProtoMessage() {}
+
+func (x *UpdateUserReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateUserReq.ProtoReflect.Descriptor instead.
+func (*UpdateUserReq) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UpdateUserReq) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateUserReq) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *UpdateUserReq) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+type SetUserStatusReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetUserStatusReq) Reset() {
+	*x = SetUserStatusReq{}
+	mi := &file_user_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetUserStatusReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetUserStatusReq) ProtoMessage() {}
+
+func (x *SetUserStatusReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetUserStatusReq.ProtoReflect.Descriptor instead.
+func (*SetUserStatusReq) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SetUserStatusReq) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SetUserStatusReq) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SetUserStatusReq) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type ResetPasswordRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Msg           string                 `protobuf:"bytes,1,opt,name=msg,proto3" json:"msg,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetPasswordRes) Reset() {
+	*x = ResetPasswordRes{}
+	mi := &file_user_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetPasswordRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetPasswordRes) ProtoMessage() {}
+
+func (x *ResetPasswordRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetPasswordRes.ProtoReflect.Descriptor instead.
+func (*ResetPasswordRes) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ResetPasswordRes) GetMsg() string {
+	if x != nil {
+		return x.Msg
+	}
+	return ""
+}
+
+type ResetPasswordByEmailReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetPasswordByEmailReq) Reset() {
+	*x = ResetPasswordByEmailReq{}
+	mi := &file_user_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetPasswordByEmailReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetPasswordByEmailReq) ProtoMessage() {}
+
+func (x *ResetPasswordByEmailReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetPasswordByEmailReq.ProtoReflect.Descriptor instead.
+func (*ResetPasswordByEmailReq) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ResetPasswordByEmailReq) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *ResetPasswordByEmailReq) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type VerifyEmailReq struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	EmailVerificationToken string                 `protobuf:"bytes,1,opt,name=email_verification_token,json=emailVerificationToken,proto3" json:"email_verification_token,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *VerifyEmailReq) Reset() {
+	*x = VerifyEmailReq{}
+	mi := &file_user_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyEmailReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyEmailReq) ProtoMessage() {}
+
+func (x *VerifyEmailReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyEmailReq.ProtoReflect.Descriptor instead.
+func (*VerifyEmailReq) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *VerifyEmailReq) GetEmailVerificationToken() string {
+	if x != nil {
+		return x.EmailVerificationToken
+	}
+	return ""
+}
+
+type VerifyEmailRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Msg           string                 `protobuf:"bytes,1,opt,name=msg,proto3" json:"msg,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyEmailRes) Reset() {
+	*x = VerifyEmailRes{}
+	mi := &file_user_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyEmailRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyEmailRes) ProtoMessage() {}
+
+func (x *VerifyEmailRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyEmailRes.ProtoReflect.Descriptor instead.
+func (*VerifyEmailRes) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *VerifyEmailRes) GetMsg() string {
+	if x != nil {
+		return x.Msg
+	}
+	return ""
+}
+
+type SetEmailVerificationTokenReq struct {
+	state                  protoimpl.MessageState `protogen:"open.v1"`
+	Email                  string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	EmailVerificationToken string                 `protobuf:"bytes,2,opt,name=email_verification_token,json=emailVerificationToken,proto3" json:"email_verification_token,omitempty"` // sha256 of the token sent to the user
+	ExpiresAt              *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields          protoimpl.UnknownFields
+	sizeCache              protoimpl.SizeCache
+}
+
+func (x *SetEmailVerificationTokenReq) Reset() {
+	*x = SetEmailVerificationTokenReq{}
+	mi := &file_user_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetEmailVerificationTokenReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetEmailVerificationTokenReq) ProtoMessage() {}
+
+func (x *SetEmailVerificationTokenReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetEmailVerificationTokenReq.ProtoReflect.Descriptor instead.
+func (*SetEmailVerificationTokenReq) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SetEmailVerificationTokenReq) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *SetEmailVerificationTokenReq) GetEmailVerificationToken() string {
+	if x != nil {
+		return x.EmailVerificationToken
+	}
+	return ""
+}
+
+func (x *SetEmailVerificationTokenReq) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type SetEmailVerificationTokenRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Msg           string                 `protobuf:"bytes,1,opt,name=msg,proto3" json:"msg,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetEmailVerificationTokenRes) Reset() {
+	*x = SetEmailVerificationTokenRes{}
+	mi := &file_user_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetEmailVerificationTokenRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetEmailVerificationTokenRes) ProtoMessage() {}
+
+func (x *SetEmailVerificationTokenRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetEmailVerificationTokenRes.ProtoReflect.Descriptor instead.
+func (*SetEmailVerificationTokenRes) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SetEmailVerificationTokenRes) GetMsg() string {
+	if x != nil {
+		return x.Msg
+	}
+	return ""
+}
+
+type RequestContactChangeReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"` // "email" or "phone_number"
+	NewValue      string                 `protobuf:"bytes,3,opt,name=new_value,json=newValue,proto3" json:"new_value,omitempty"`
+	TokenHash     string                 `protobuf:"bytes,4,opt,name=token_hash,json=tokenHash,proto3" json:"token_hash,omitempty"` // sha256 of the link token or OTP sent to the new value
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestContactChangeReq) Reset() {
+	*x = RequestContactChangeReq{}
+	mi := &file_user_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestContactChangeReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestContactChangeReq) ProtoMessage() {}
+
+func (x *RequestContactChangeReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestContactChangeReq.ProtoReflect.Descriptor instead.
+func (*RequestContactChangeReq) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RequestContactChangeReq) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *RequestContactChangeReq) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *RequestContactChangeReq) GetNewValue() string {
+	if x != nil {
+		return x.NewValue
+	}
+	return ""
+}
+
+func (x *RequestContactChangeReq) GetTokenHash() string {
+	if x != nil {
+		return x.TokenHash
+	}
+	return ""
+}
+
+func (x *RequestContactChangeReq) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+type RequestContactChangeRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Msg           string                 `protobuf:"bytes,1,opt,name=msg,proto3" json:"msg,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RequestContactChangeRes) Reset() {
+	*x = RequestContactChangeRes{}
+	mi := &file_user_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RequestContactChangeRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RequestContactChangeRes) ProtoMessage() {}
+
+func (x *RequestContactChangeRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RequestContactChangeRes.ProtoReflect.Descriptor instead.
+func (*RequestContactChangeRes) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *RequestContactChangeRes) GetMsg() string {
+	if x != nil {
+		return x.Msg
+	}
+	return ""
+}
+
+type ConfirmContactChangeReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	TokenHash     string                 `protobuf:"bytes,2,opt,name=token_hash,json=tokenHash,proto3" json:"token_hash,omitempty"`
+	UserId        string                 `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // required for phone_number, OTPs are only unique per user
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfirmContactChangeReq) Reset() {
+	*x = ConfirmContactChangeReq{}
+	mi := &file_user_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfirmContactChangeReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfirmContactChangeReq) ProtoMessage() {}
+
+func (x *ConfirmContactChangeReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfirmContactChangeReq.ProtoReflect.Descriptor instead.
+func (*ConfirmContactChangeReq) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ConfirmContactChangeReq) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ConfirmContactChangeReq) GetTokenHash() string {
+	if x != nil {
+		return x.TokenHash
+	}
+	return ""
+}
+
+func (x *ConfirmContactChangeReq) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+type ConfirmContactChangeRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          *User                  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	OldValue      string                 `protobuf:"bytes,2,opt,name=old_value,json=oldValue,proto3" json:"old_value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfirmContactChangeRes) Reset() {
+	*x = ConfirmContactChangeRes{}
+	mi := &file_user_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfirmContactChangeRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfirmContactChangeRes) ProtoMessage() {}
+
+func (x *ConfirmContactChangeRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfirmContactChangeRes.ProtoReflect.Descriptor instead.
+func (*ConfirmContactChangeRes) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ConfirmContactChangeRes) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+func (x *ConfirmContactChangeRes) GetOldValue() string {
+	if x != nil {
+		return x.OldValue
+	}
+	return ""
+}
+
+type SavedDestination struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        int32                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Label         string                 `protobuf:"bytes,3,opt,name=label,proto3" json:"label,omitempty"`
+	OperatorId    int32                  `protobuf:"varint,4,opt,name=operator_id,json=operatorId,proto3" json:"operator_id,omitempty"`            // zero when tied to a product type only
+	ProductTypeId int32                  `protobuf:"varint,5,opt,name=product_type_id,json=productTypeId,proto3" json:"product_type_id,omitempty"` // zero when tied to an operator only
+	Destination   string                 `protobuf:"bytes,6,opt,name=destination,proto3" json:"destination,omitempty"`
+	ServerId      string                 `protobuf:"bytes,7,opt,name=server_id,json=serverId,proto3" json:"server_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SavedDestination) Reset() {
+	*x = SavedDestination{}
+	mi := &file_user_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SavedDestination) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SavedDestination) ProtoMessage() {}
+
+func (x *SavedDestination) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SavedDestination.ProtoReflect.Descriptor instead.
+func (*SavedDestination) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *SavedDestination) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SavedDestination) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *SavedDestination) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *SavedDestination) GetOperatorId() int32 {
+	if x != nil {
+		return x.OperatorId
+	}
+	return 0
+}
+
+func (x *SavedDestination) GetProductTypeId() int32 {
+	if x != nil {
+		return x.ProductTypeId
+	}
+	return 0
+}
+
+func (x *SavedDestination) GetDestination() string {
+	if x != nil {
+		return x.Destination
+	}
+	return ""
+}
+
+func (x *SavedDestination) GetServerId() string {
+	if x != nil {
+		return x.ServerId
+	}
+	return ""
+}
+
+type GetSavedDestinationReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId        int32                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"` // the owner, other users get NotFound
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSavedDestinationReq) Reset() {
+	*x = GetSavedDestinationReq{}
+	mi := &file_user_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSavedDestinationReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSavedDestinationReq) ProtoMessage() {}
+
+func (x *GetSavedDestinationReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSavedDestinationReq.ProtoReflect.Descriptor instead.
+func (*GetSavedDestinationReq) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetSavedDestinationReq) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *GetSavedDestinationReq) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type GetSavedDestinationRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Destination   *SavedDestination      `protobuf:"bytes,1,opt,name=destination,proto3" json:"destination,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSavedDestinationRes) Reset() {
+	*x = GetSavedDestinationRes{}
+	mi := &file_user_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSavedDestinationRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSavedDestinationRes) ProtoMessage() {}
+
+func (x *GetSavedDestinationRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSavedDestinationRes.ProtoReflect.Descriptor instead.
+func (*GetSavedDestinationRes) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GetSavedDestinationRes) GetDestination() *SavedDestination {
+	if x != nil {
+		return x.Destination
+	}
+	return nil
+}
+
+type Referral struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	ReferrerId     int32                  `protobuf:"varint,2,opt,name=referrer_id,json=referrerId,proto3" json:"referrer_id,omitempty"`
+	ReferredId     int32                  `protobuf:"varint,3,opt,name=referred_id,json=referredId,proto3" json:"referred_id,omitempty"`
+	Status         string                 `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`                  // "pending", "qualified", "rewarded", "rejected" or "revoked"
+	OrderId        string                 `protobuf:"bytes,5,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"` // the order that qualified the referral
+	ReferrerReward int32                  `protobuf:"varint,6,opt,name=referrer_reward,json=referrerReward,proto3" json:"referrer_reward,omitempty"`
+	ReferredReward int32                  `protobuf:"varint,7,opt,name=referred_reward,json=referredReward,proto3" json:"referred_reward,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Referral) Reset() {
+	*x = Referral{}
+	mi := &file_user_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Referral) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Referral) ProtoMessage() {}
+
+func (x *Referral) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Referral.ProtoReflect.Descriptor instead.
+func (*Referral) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *Referral) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Referral) GetReferrerId() int32 {
+	if x != nil {
+		return x.ReferrerId
+	}
+	return 0
+}
+
+func (x *Referral) GetReferredId() int32 {
+	if x != nil {
+		return x.ReferredId
+	}
+	return 0
+}
+
+func (x *Referral) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Referral) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *Referral) GetReferrerReward() int32 {
+	if x != nil {
+		return x.ReferrerReward
+	}
+	return 0
+}
+
+func (x *Referral) GetReferredReward() int32 {
+	if x != nil {
+		return x.ReferredReward
+	}
+	return 0
+}
+
+type QualifyReferralReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`   // the referred user
+	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"` // first successful order of the referred user
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QualifyReferralReq) Reset() {
+	*x = QualifyReferralReq{}
+	mi := &file_user_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QualifyReferralReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QualifyReferralReq) ProtoMessage() {}
+
+func (x *QualifyReferralReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QualifyReferralReq.ProtoReflect.Descriptor instead.
+func (*QualifyReferralReq) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *QualifyReferralReq) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *QualifyReferralReq) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type CompleteReferralReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int32                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CompleteReferralReq) Reset() {
+	*x = CompleteReferralReq{}
+	mi := &file_user_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CompleteReferralReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CompleteReferralReq) ProtoMessage() {}
+
+func (x *CompleteReferralReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CompleteReferralReq.ProtoReflect.Descriptor instead.
+func (*CompleteReferralReq) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *CompleteReferralReq) GetId() int32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type RevokeReferralReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"` // the qualifying order, refunded
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeReferralReq) Reset() {
+	*x = RevokeReferralReq{}
+	mi := &file_user_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeReferralReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeReferralReq) ProtoMessage() {}
+
+func (x *RevokeReferralReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeReferralReq.ProtoReflect.Descriptor instead.
+func (*RevokeReferralReq) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *RevokeReferralReq) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type ReferralRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Referral      *Referral              `protobuf:"bytes,1,opt,name=referral,proto3" json:"referral,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReferralRes) Reset() {
+	*x = ReferralRes{}
+	mi := &file_user_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReferralRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReferralRes) ProtoMessage() {}
+
+func (x *ReferralRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReferralRes.ProtoReflect.Descriptor instead.
+func (*ReferralRes) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ReferralRes) GetReferral() *Referral {
+	if x != nil {
+		return x.Referral
+	}
+	return nil
+}
+
+type RedeemPointsReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int32                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Points        int32                  `protobuf:"varint,3,opt,name=points,proto3" json:"points,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RedeemPointsReq) Reset() {
+	*x = RedeemPointsReq{}
+	mi := &file_user_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RedeemPointsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RedeemPointsReq) ProtoMessage() {}
+
+func (x *RedeemPointsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RedeemPointsReq.ProtoReflect.Descriptor instead.
+func (*RedeemPointsReq) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *RedeemPointsReq) GetUserId() int32 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *RedeemPointsReq) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *RedeemPointsReq) GetPoints() int32 {
+	if x != nil {
+		return x.Points
+	}
+	return 0
+}
+
+// RestorePointsReq gives back the points redeemed for an order that was not
+// paid, restoring twice has no effect.
+type RestorePointsReq struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestorePointsReq) Reset() {
+	*x = RestorePointsReq{}
+	mi := &file_user_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestorePointsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestorePointsReq) ProtoMessage() {}
+
+func (x *RestorePointsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestorePointsReq.ProtoReflect.Descriptor instead.
+func (*RestorePointsReq) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *RestorePointsReq) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type PointsBalanceRes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Balance       int32                  `protobuf:"varint,1,opt,name=balance,proto3" json:"balance,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PointsBalanceRes) Reset() {
+	*x = PointsBalanceRes{}
+	mi := &file_user_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PointsBalanceRes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PointsBalanceRes) ProtoMessage() {}
+
+func (x *PointsBalanceRes) ProtoReflect() protoreflect.Message {
+	mi := &file_user_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PointsBalanceRes.ProtoReflect.Descriptor instead.
+func (*PointsBalanceRes) Descriptor() ([]byte, []int) {
+	return file_user_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *PointsBalanceRes) GetBalance() int32 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+var File_user_proto protoreflect.FileDescriptor
+
+const file_user_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"user.proto\x12\auser.v1\x1a google/protobuf/field_mask.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb0\x03\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12!\n" +
+	"\fphone_number\x18\x04 \x01(\tR\vphoneNumber\x12K\n" +
+	"\x11email_verified_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampH\x00R\x0femailVerifiedAt\x88\x01\x01\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x14\n" +
+	"\x05roles\x18\t \x03(\tR\x05roles\x12 \n" +
+	"\vpermissions\x18\n" +
+	" \x03(\tR\vpermissions\x12\x16\n" +
+	"\x06status\x18\v \x01(\tR\x06status\x12\x12\n" +
+	"\x04tier\x18\f \x01(\tR\x04tierB\x14\n" +
+	"\x12_email_verified_atJ\x04\b\x05\x10\x06R\bpassword\"\xf8\x02\n" +
+	"\rCreateUserReq\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x1a\n" +
+	"\bpassword\x18\x03 \x01(\tR\bpassword\x12!\n" +
+	"\fphone_number\x18\x04 \x01(\tR\vphoneNumber\x128\n" +
+	"\x18email_verification_token\x18\x05 \x01(\tR\x16emailVerificationToken\x12]\n" +
+	"\x1demail_verification_expires_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x1aemailVerificationExpiresAt\x12#\n" +
+	"\rreferral_code\x18\a \x01(\tR\freferralCode\x12\x1b\n" +
+	"\tsignup_ip\x18\b \x01(\tR\bsignupIp\x12#\n" +
+	"\rsignup_device\x18\t \x01(\tR\fsignupDevice\"!\n" +
+	"\rCreateUserRes\x12\x10\n" +
+	"\x03msg\x18\x01 \x01(\tR\x03msg\" \n" +
+	"\x0eGetUserByIdReq\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\")\n" +
+	"\x11GetUserByEmailReq\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\"/\n" +
+	"\n" +
+	"GetUserRes\x12!\n" +
+	"\x04user\x18\x01 \x01(\v2\r.user.v1.UserR\x04user\"H\n" +
+	"\x14VerifyCredentialsReq\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"$\n" +
+	"\x10GetUsersByIdsReq\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\x05R\x03ids\"7\n" +
+	"\x10GetUsersByIdsRes\x12#\n" +
+	"\x05users\x18\x01 \x03(\v2\r.user.v1.UserR\x05users\"\x99\x02\n" +
+	"\fListUsersReq\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x16\n" +
+	"\x06cursor\x18\x02 \x01(\tR\x06cursor\x12\x1f\n" +
+	"\bverified\x18\x03 \x01(\bH\x00R\bverified\x88\x01\x01\x12=\n" +
+	"\fcreated_from\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vcreatedFrom\x129\n" +
+	"\n" +
+	"created_to\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedTo\x12\x14\n" +
+	"\x05query\x18\x06 \x01(\tR\x05query\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06statusB\v\n" +
+	"\t_verified\"T\n" +
+	"\fListUsersRes\x12#\n" +
+	"\x05users\x18\x01 \x03(\v2\r.user.v1.UserR\x05users\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"\x7f\n" +
+	"\rUpdateUserReq\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12!\n" +
+	"\x04user\x18\x02 \x01(\v2\r.user.v1.UserR\x04user\x12;\n" +
+	"\vupdate_mask\x18\x03 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"R\n" +
+	"\x10SetUserStatusReq\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"$\n" +
+	"\x10ResetPasswordRes\x12\x10\n" +
+	"\x03msg\x18\x01 \x01(\tR\x03msg\"K\n" +
+	"\x17ResetPasswordByEmailReq\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"J\n" +
+	"\x0eVerifyEmailReq\x128\n" +
+	"\x18email_verification_token\x18\x01 \x01(\tR\x16emailVerificationToken\"\"\n" +
+	"\x0eVerifyEmailRes\x12\x10\n" +
+	"\x03msg\x18\x01 \x01(\tR\x03msg\"\xa9\x01\n" +
+	"\x1cSetEmailVerificationTokenReq\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x128\n" +
+	"\x18email_verification_token\x18\x02 \x01(\tR\x16emailVerificationToken\x129\n" +
+	"\n" +
+	"expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"0\n" +
+	"\x1cSetEmailVerificationTokenRes\x12\x10\n" +
+	"\x03msg\x18\x01 \x01(\tR\x03msg\"\xbd\x01\n" +
+	"\x17RequestContactChangeReq\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x1b\n" +
+	"\tnew_value\x18\x03 \x01(\tR\bnewValue\x12\x1d\n" +
+	"\n" +
+	"token_hash\x18\x04 \x01(\tR\ttokenHash\x129\n" +
+	"\n" +
+	"expires_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"+\n" +
+	"\x17RequestContactChangeRes\x12\x10\n" +
+	"\x03msg\x18\x01 \x01(\tR\x03msg\"e\n" +
+	"\x17ConfirmContactChangeReq\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x1d\n" +
+	"\n" +
+	"token_hash\x18\x02 \x01(\tR\ttokenHash\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\"Y\n" +
+	"\x17ConfirmContactChangeRes\x12!\n" +
+	"\x04user\x18\x01 \x01(\v2\r.user.v1.UserR\x04user\x12\x1b\n" +
+	"\told_value\x18\x02 \x01(\tR\boldValue\"\xd9\x01\n" +
+	"\x10SavedDestination\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x05R\x06userId\x12\x14\n" +
+	"\x05label\x18\x03 \x01(\tR\x05label\x12\x1f\n" +
+	"\voperator_id\x18\x04 \x01(\x05R\n" +
+	"operatorId\x12&\n" +
+	"\x0fproduct_type_id\x18\x05 \x01(\x05R\rproductTypeId\x12 \n" +
+	"\vdestination\x18\x06 \x01(\tR\vdestination\x12\x1b\n" +
+	"\tserver_id\x18\a \x01(\tR\bserverId\"A\n" +
+	"\x16GetSavedDestinationReq\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x05R\x06userId\"U\n" +
+	"\x16GetSavedDestinationRes\x12;\n" +
+	"\vdestination\x18\x01 \x01(\v2\x19.user.v1.SavedDestinationR\vdestination\"\xe1\x01\n" +
+	"\bReferral\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\x12\x1f\n" +
+	"\vreferrer_id\x18\x02 \x01(\x05R\n" +
+	"referrerId\x12\x1f\n" +
+	"\vreferred_id\x18\x03 \x01(\x05R\n" +
+	"referredId\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x19\n" +
+	"\border_id\x18\x05 \x01(\tR\aorderId\x12'\n" +
+	"\x0freferrer_reward\x18\x06 \x01(\x05R\x0ereferrerReward\x12'\n" +
+	"\x0freferred_reward\x18\a \x01(\x05R\x0ereferredReward\"H\n" +
+	"\x12QualifyReferralReq\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\"%\n" +
+	"\x13CompleteReferralReq\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x05R\x02id\".\n" +
+	"\x11RevokeReferralReq\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\"<\n" +
+	"\vReferralRes\x12-\n" +
+	"\breferral\x18\x01 \x01(\v2\x11.user.v1.ReferralR\breferral\"]\n" +
+	"\x0fRedeemPointsReq\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x05R\x06userId\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\x12\x16\n" +
+	"\x06points\x18\x03 \x01(\x05R\x06points\"-\n" +
+	"\x10RestorePointsReq\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\",\n" +
+	"\x10PointsBalanceRes\x12\x18\n" +
+	"\abalance\x18\x01 \x01(\x05R\abalance2\x82\v\n" +
+	"\vUserService\x12<\n" +
+	"\n" +
+	"CreateUser\x12\x16.user.v1.CreateUserReq\x1a\x16.user.v1.CreateUserRes\x12;\n" +
+	"\vGetUserById\x12\x17.user.v1.GetUserByIdReq\x1a\x13.user.v1.GetUserRes\x12A\n" +
+	"\x0eGetUserByEmail\x12\x1a.user.v1.GetUserByEmailReq\x1a\x13.user.v1.GetUserRes\x12G\n" +
+	"\x11VerifyCredentials\x12\x1d.user.v1.VerifyCredentialsReq\x1a\x13.user.v1.GetUserRes\x12E\n" +
+	"\rGetUsersByIds\x12\x19.user.v1.GetUsersByIdsReq\x1a\x19.user.v1.GetUsersByIdsRes\x129\n" +
+	"\tListUsers\x12\x15.user.v1.ListUsersReq\x1a\x15.user.v1.ListUsersRes\x129\n" +
+	"\n" +
+	"UpdateUser\x12\x16.user.v1.UpdateUserReq\x1a\x13.user.v1.GetUserRes\x12?\n" +
+	"\rSetUserStatus\x12\x19.user.v1.SetUserStatusReq\x1a\x13.user.v1.GetUserRes\x12S\n" +
 	"\x14ResetPasswordByEmail\x12 .user.v1.ResetPasswordByEmailReq\x1a\x19.user.v1.ResetPasswordRes\x12?\n" +
-	"\vVerifyEmail\x12\x17.user.v1.VerifyEmailReq\x1a\x17.user.v1.VerifyEmailResB?Z=github.com/akmmp241/topupstore-microservice/user-proto/v1;upbb\x06proto3"
+	"\vVerifyEmail\x12\x17.user.v1.VerifyEmailReq\x1a\x17.user.v1.VerifyEmailRes\x12i\n" +
+	"\x19SetEmailVerificationToken\x12%.user.v1.SetEmailVerificationTokenReq\x1a%.user.v1.SetEmailVerificationTokenRes\x12Z\n" +
+	"\x14RequestContactChange\x12 .user.v1.RequestContactChangeReq\x1a .user.v1.RequestContactChangeRes\x12Z\n" +
+	"\x14ConfirmContactChange\x12 .user.v1.ConfirmContactChangeReq\x1a .user.v1.ConfirmContactChangeRes\x12W\n" +
+	"\x13GetSavedDestination\x12\x1f.user.v1.GetSavedDestinationReq\x1a\x1f.user.v1.GetSavedDestinationRes\x12D\n" +
+	"\x0fQualifyReferral\x12\x1b.user.v1.QualifyReferralReq\x1a\x14.user.v1.ReferralRes\x12F\n" +
+	"\x10CompleteReferral\x12\x1c.user.v1.CompleteReferralReq\x1a\x14.user.v1.ReferralRes\x12B\n" +
+	"\x0eRevokeReferral\x12\x1a.user.v1.RevokeReferralReq\x1a\x14.user.v1.ReferralRes\x12C\n" +
+	"\fRedeemPoints\x12\x18.user.v1.RedeemPointsReq\x1a\x19.user.v1.PointsBalanceRes\x12E\n" +
+	"\rRestorePoints\x12\x19.user.v1.RestorePointsReq\x1a\x19.user.v1.PointsBalanceResB?Z=github.com/akmmp241/topupstore-microservice/user-proto/v1;upbb\x06proto3"
 
 var (
 	file_user_proto_rawDescOnce sync.Once
@@ -620,40 +2150,105 @@ func file_user_proto_rawDescGZIP() []byte {
 	return file_user_proto_rawDescData
 }
 
-var file_user_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_user_proto_msgTypes = make([]protoimpl.MessageInfo, 34)
 var file_user_proto_goTypes = []any{
-	(*User)(nil),                    // 0: user.v1.User
-	(*CreateUserReq)(nil),           // 1: user.v1.CreateUserReq
-	(*CreateUserRes)(nil),           // 2: user.v1.CreateUserRes
-	(*GetUserByIdReq)(nil),          // 3: user.v1.GetUserByIdReq
-	(*GetUserByEmailReq)(nil),       // 4: user.v1.GetUserByEmailReq
-	(*GetUserRes)(nil),              // 5: user.v1.GetUserRes
-	(*ResetPasswordRes)(nil),        // 6: user.v1.ResetPasswordRes
-	(*ResetPasswordByEmailReq)(nil), // 7: user.v1.ResetPasswordByEmailReq
-	(*VerifyEmailReq)(nil),          // 8: user.v1.VerifyEmailReq
-	(*VerifyEmailRes)(nil),          // 9: user.v1.VerifyEmailRes
-	(*timestamppb.Timestamp)(nil),   // 10: google.protobuf.Timestamp
+	(*User)(nil),                         // 0: user.v1.User
+	(*CreateUserReq)(nil),                // 1: user.v1.CreateUserReq
+	(*CreateUserRes)(nil),                // 2: user.v1.CreateUserRes
+	(*GetUserByIdReq)(nil),               // 3: user.v1.GetUserByIdReq
+	(*GetUserByEmailReq)(nil),            // 4: user.v1.GetUserByEmailReq
+	(*GetUserRes)(nil),                   // 5: user.v1.GetUserRes
+	(*VerifyCredentialsReq)(nil),         // 6: user.v1.VerifyCredentialsReq
+	(*GetUsersByIdsReq)(nil),             // 7: user.v1.GetUsersByIdsReq
+	(*GetUsersByIdsRes)(nil),             // 8: user.v1.GetUsersByIdsRes
+	(*ListUsersReq)(nil),                 // 9: user.v1.ListUsersReq
+	(*ListUsersRes)(nil),                 // 10: user.v1.ListUsersRes
+	(*UpdateUserReq)(nil),                // 11: user.v1.UpdateUserReq
+	(*SetUserStatusReq)(nil),             // 12: user.v1.SetUserStatusReq
+	(*ResetPasswordRes)(nil),             // 13: user.v1.ResetPasswordRes
+	(*ResetPasswordByEmailReq)(nil),      // 14: user.v1.ResetPasswordByEmailReq
+	(*VerifyEmailReq)(nil),               // 15: user.v1.VerifyEmailReq
+	(*VerifyEmailRes)(nil),               // 16: user.v1.VerifyEmailRes
+	(*SetEmailVerificationTokenReq)(nil), // 17: user.v1.SetEmailVerificationTokenReq
+	(*SetEmailVerificationTokenRes)(nil), // 18: user.v1.SetEmailVerificationTokenRes
+	(*RequestContactChangeReq)(nil),      // 19: user.v1.RequestContactChangeReq
+	(*RequestContactChangeRes)(nil),      // 20: user.v1.RequestContactChangeRes
+	(*ConfirmContactChangeReq)(nil),      // 21: user.v1.ConfirmContactChangeReq
+	(*ConfirmContactChangeRes)(nil),      // 22: user.v1.ConfirmContactChangeRes
+	(*SavedDestination)(nil),             // 23: user.v1.SavedDestination
+	(*GetSavedDestinationReq)(nil),       // 24: user.v1.GetSavedDestinationReq
+	(*GetSavedDestinationRes)(nil),       // 25: user.v1.GetSavedDestinationRes
+	(*Referral)(nil),                     // 26: user.v1.Referral
+	(*QualifyReferralReq)(nil),           // 27: user.v1.QualifyReferralReq
+	(*CompleteReferralReq)(nil),          // 28: user.v1.CompleteReferralReq
+	(*RevokeReferralReq)(nil),            // 29: user.v1.RevokeReferralReq
+	(*ReferralRes)(nil),                  // 30: user.v1.ReferralRes
+	(*RedeemPointsReq)(nil),              // 31: user.v1.RedeemPointsReq
+	(*RestorePointsReq)(nil),             // 32: user.v1.RestorePointsReq
+	(*PointsBalanceRes)(nil),             // 33: user.v1.PointsBalanceRes
+	(*timestamppb.Timestamp)(nil),        // 34: google.protobuf.Timestamp
+	(*fieldmaskpb.FieldMask)(nil),        // 35: google.protobuf.FieldMask
 }
 var file_user_proto_depIdxs = []int32{
-	10, // 0: user.v1.User.email_verified_at:type_name -> google.protobuf.Timestamp
-	10, // 1: user.v1.User.created_at:type_name -> google.protobuf.Timestamp
-	10, // 2: user.v1.User.updated_at:type_name -> google.protobuf.Timestamp
-	0,  // 3: user.v1.GetUserRes.user:type_name -> user.v1.User
-	1,  // 4: user.v1.UserService.CreateUser:input_type -> user.v1.CreateUserReq
-	3,  // 5: user.v1.UserService.GetUserById:input_type -> user.v1.GetUserByIdReq
-	4,  // 6: user.v1.UserService.GetUserByEmail:input_type -> user.v1.GetUserByEmailReq
-	7,  // 7: user.v1.UserService.ResetPasswordByEmail:input_type -> user.v1.ResetPasswordByEmailReq
-	8,  // 8: user.v1.UserService.VerifyEmail:input_type -> user.v1.VerifyEmailReq
-	2,  // 9: user.v1.UserService.CreateUser:output_type -> user.v1.CreateUserRes
-	5,  // 10: user.v1.UserService.GetUserById:output_type -> user.v1.GetUserRes
-	5,  // 11: user.v1.UserService.GetUserByEmail:output_type -> user.v1.GetUserRes
-	6,  // 12: user.v1.UserService.ResetPasswordByEmail:output_type -> user.v1.ResetPasswordRes
-	9,  // 13: user.v1.UserService.VerifyEmail:output_type -> user.v1.VerifyEmailRes
-	9,  // [9:14] is the sub-list for method output_type
-	4,  // [4:9] is the sub-list for method input_type
-	4,  // [4:4] is the sub-list for extension type_name
-	4,  // [4:4] is the sub-list for extension extendee
-	0,  // [0:4] is the sub-list for field type_name
+	34, // 0: user.v1.User.email_verified_at:type_name -> google.protobuf.Timestamp
+	34, // 1: user.v1.User.created_at:type_name -> google.protobuf.Timestamp
+	34, // 2: user.v1.User.updated_at:type_name -> google.protobuf.Timestamp
+	34, // 3: user.v1.CreateUserReq.email_verification_expires_at:type_name -> google.protobuf.Timestamp
+	0,  // 4: user.v1.GetUserRes.user:type_name -> user.v1.User
+	0,  // 5: user.v1.GetUsersByIdsRes.users:type_name -> user.v1.User
+	34, // 6: user.v1.ListUsersReq.created_from:type_name -> google.protobuf.Timestamp
+	34, // 7: user.v1.ListUsersReq.created_to:type_name -> google.protobuf.Timestamp
+	0,  // 8: user.v1.ListUsersRes.users:type_name -> user.v1.User
+	0,  // 9: user.v1.UpdateUserReq.user:type_name -> user.v1.User
+	35, // 10: user.v1.UpdateUserReq.update_mask:type_name -> google.protobuf.FieldMask
+	34, // 11: user.v1.SetEmailVerificationTokenReq.expires_at:type_name -> google.protobuf.Timestamp
+	34, // 12: user.v1.RequestContactChangeReq.expires_at:type_name -> google.protobuf.Timestamp
+	0,  // 13: user.v1.ConfirmContactChangeRes.user:type_name -> user.v1.User
+	23, // 14: user.v1.GetSavedDestinationRes.destination:type_name -> user.v1.SavedDestination
+	26, // 15: user.v1.ReferralRes.referral:type_name -> user.v1.Referral
+	1,  // 16: user.v1.UserService.CreateUser:input_type -> user.v1.CreateUserReq
+	3,  // 17: user.v1.UserService.GetUserById:input_type -> user.v1.GetUserByIdReq
+	4,  // 18: user.v1.UserService.GetUserByEmail:input_type -> user.v1.GetUserByEmailReq
+	6,  // 19: user.v1.UserService.VerifyCredentials:input_type -> user.v1.VerifyCredentialsReq
+	7,  // 20: user.v1.UserService.GetUsersByIds:input_type -> user.v1.GetUsersByIdsReq
+	9,  // 21: user.v1.UserService.ListUsers:input_type -> user.v1.ListUsersReq
+	11, // 22: user.v1.UserService.UpdateUser:input_type -> user.v1.UpdateUserReq
+	12, // 23: user.v1.UserService.SetUserStatus:input_type -> user.v1.SetUserStatusReq
+	14, // 24: user.v1.UserService.ResetPasswordByEmail:input_type -> user.v1.ResetPasswordByEmailReq
+	15, // 25: user.v1.UserService.VerifyEmail:input_type -> user.v1.VerifyEmailReq
+	17, // 26: user.v1.UserService.SetEmailVerificationToken:input_type -> user.v1.SetEmailVerificationTokenReq
+	19, // 27: user.v1.UserService.RequestContactChange:input_type -> user.v1.RequestContactChangeReq
+	21, // 28: user.v1.UserService.ConfirmContactChange:input_type -> user.v1.ConfirmContactChangeReq
+	24, // 29: user.v1.UserService.GetSavedDestination:input_type -> user.v1.GetSavedDestinationReq
+	27, // 30: user.v1.UserService.QualifyReferral:input_type -> user.v1.QualifyReferralReq
+	28, // 31: user.v1.UserService.CompleteReferral:input_type -> user.v1.CompleteReferralReq
+	29, // 32: user.v1.UserService.RevokeReferral:input_type -> user.v1.RevokeReferralReq
+	31, // 33: user.v1.UserService.RedeemPoints:input_type -> user.v1.RedeemPointsReq
+	32, // 34: user.v1.UserService.RestorePoints:input_type -> user.v1.RestorePointsReq
+	2,  // 35: user.v1.UserService.CreateUser:output_type -> user.v1.CreateUserRes
+	5,  // 36: user.v1.UserService.GetUserById:output_type -> user.v1.GetUserRes
+	5,  // 37: user.v1.UserService.GetUserByEmail:output_type -> user.v1.GetUserRes
+	5,  // 38: user.v1.UserService.VerifyCredentials:output_type -> user.v1.GetUserRes
+	8,  // 39: user.v1.UserService.GetUsersByIds:output_type -> user.v1.GetUsersByIdsRes
+	10, // 40: user.v1.UserService.ListUsers:output_type -> user.v1.ListUsersRes
+	5,  // 41: user.v1.UserService.UpdateUser:output_type -> user.v1.GetUserRes
+	5,  // 42: user.v1.UserService.SetUserStatus:output_type -> user.v1.GetUserRes
+	13, // 43: user.v1.UserService.ResetPasswordByEmail:output_type -> user.v1.ResetPasswordRes
+	16, // 44: user.v1.UserService.VerifyEmail:output_type -> user.v1.VerifyEmailRes
+	18, // 45: user.v1.UserService.SetEmailVerificationToken:output_type -> user.v1.SetEmailVerificationTokenRes
+	20, // 46: user.v1.UserService.RequestContactChange:output_type -> user.v1.RequestContactChangeRes
+	22, // 47: user.v1.UserService.ConfirmContactChange:output_type -> user.v1.ConfirmContactChangeRes
+	25, // 48: user.v1.UserService.GetSavedDestination:output_type -> user.v1.GetSavedDestinationRes
+	30, // 49: user.v1.UserService.QualifyReferral:output_type -> user.v1.ReferralRes
+	30, // 50: user.v1.UserService.CompleteReferral:output_type -> user.v1.ReferralRes
+	30, // 51: user.v1.UserService.RevokeReferral:output_type -> user.v1.ReferralRes
+	33, // 52: user.v1.UserService.RedeemPoints:output_type -> user.v1.PointsBalanceRes
+	33, // 53: user.v1.UserService.RestorePoints:output_type -> user.v1.PointsBalanceRes
+	35, // [35:54] is the sub-list for method output_type
+	16, // [16:35] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
 }
 
 func init() { file_user_proto_init() }
@@ -662,13 +2257,14 @@ func file_user_proto_init() {
 		return
 	}
 	file_user_proto_msgTypes[0].OneofWrappers = []any{}
+	file_user_proto_msgTypes[9].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_user_proto_rawDesc), len(file_user_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   10,
+			NumMessages:   34,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
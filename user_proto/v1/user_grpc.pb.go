@@ -19,11 +19,25 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	UserService_CreateUser_FullMethodName           = "/user.v1.UserService/CreateUser"
-	UserService_GetUserById_FullMethodName          = "/user.v1.UserService/GetUserById"
-	UserService_GetUserByEmail_FullMethodName       = "/user.v1.UserService/GetUserByEmail"
-	UserService_ResetPasswordByEmail_FullMethodName = "/user.v1.UserService/ResetPasswordByEmail"
-	UserService_VerifyEmail_FullMethodName          = "/user.v1.UserService/VerifyEmail"
+	UserService_CreateUser_FullMethodName                = "/user.v1.UserService/CreateUser"
+	UserService_GetUserById_FullMethodName               = "/user.v1.UserService/GetUserById"
+	UserService_GetUserByEmail_FullMethodName            = "/user.v1.UserService/GetUserByEmail"
+	UserService_VerifyCredentials_FullMethodName         = "/user.v1.UserService/VerifyCredentials"
+	UserService_GetUsersByIds_FullMethodName             = "/user.v1.UserService/GetUsersByIds"
+	UserService_ListUsers_FullMethodName                 = "/user.v1.UserService/ListUsers"
+	UserService_UpdateUser_FullMethodName                = "/user.v1.UserService/UpdateUser"
+	UserService_SetUserStatus_FullMethodName             = "/user.v1.UserService/SetUserStatus"
+	UserService_ResetPasswordByEmail_FullMethodName      = "/user.v1.UserService/ResetPasswordByEmail"
+	UserService_VerifyEmail_FullMethodName               = "/user.v1.UserService/VerifyEmail"
+	UserService_SetEmailVerificationToken_FullMethodName = "/user.v1.UserService/SetEmailVerificationToken"
+	UserService_RequestContactChange_FullMethodName      = "/user.v1.UserService/RequestContactChange"
+	UserService_ConfirmContactChange_FullMethodName      = "/user.v1.UserService/ConfirmContactChange"
+	UserService_GetSavedDestination_FullMethodName       = "/user.v1.UserService/GetSavedDestination"
+	UserService_QualifyReferral_FullMethodName           = "/user.v1.UserService/QualifyReferral"
+	UserService_CompleteReferral_FullMethodName          = "/user.v1.UserService/CompleteReferral"
+	UserService_RevokeReferral_FullMethodName            = "/user.v1.UserService/RevokeReferral"
+	UserService_RedeemPoints_FullMethodName              = "/user.v1.UserService/RedeemPoints"
+	UserService_RestorePoints_FullMethodName             = "/user.v1.UserService/RestorePoints"
 )
 
 // UserServiceClient is the client API for UserService service.
@@ -33,8 +47,22 @@ type UserServiceClient interface {
 	CreateUser(ctx context.Context, in *CreateUserReq, opts ...grpc.CallOption) (*CreateUserRes, error)
 	GetUserById(ctx context.Context, in *GetUserByIdReq, opts ...grpc.CallOption) (*GetUserRes, error)
 	GetUserByEmail(ctx context.Context, in *GetUserByEmailReq, opts ...grpc.CallOption) (*GetUserRes, error)
+	VerifyCredentials(ctx context.Context, in *VerifyCredentialsReq, opts ...grpc.CallOption) (*GetUserRes, error)
+	GetUsersByIds(ctx context.Context, in *GetUsersByIdsReq, opts ...grpc.CallOption) (*GetUsersByIdsRes, error)
+	ListUsers(ctx context.Context, in *ListUsersReq, opts ...grpc.CallOption) (*ListUsersRes, error)
+	UpdateUser(ctx context.Context, in *UpdateUserReq, opts ...grpc.CallOption) (*GetUserRes, error)
+	SetUserStatus(ctx context.Context, in *SetUserStatusReq, opts ...grpc.CallOption) (*GetUserRes, error)
 	ResetPasswordByEmail(ctx context.Context, in *ResetPasswordByEmailReq, opts ...grpc.CallOption) (*ResetPasswordRes, error)
 	VerifyEmail(ctx context.Context, in *VerifyEmailReq, opts ...grpc.CallOption) (*VerifyEmailRes, error)
+	SetEmailVerificationToken(ctx context.Context, in *SetEmailVerificationTokenReq, opts ...grpc.CallOption) (*SetEmailVerificationTokenRes, error)
+	RequestContactChange(ctx context.Context, in *RequestContactChangeReq, opts ...grpc.CallOption) (*RequestContactChangeRes, error)
+	ConfirmContactChange(ctx context.Context, in *ConfirmContactChangeReq, opts ...grpc.CallOption) (*ConfirmContactChangeRes, error)
+	GetSavedDestination(ctx context.Context, in *GetSavedDestinationReq, opts ...grpc.CallOption) (*GetSavedDestinationRes, error)
+	QualifyReferral(ctx context.Context, in *QualifyReferralReq, opts ...grpc.CallOption) (*ReferralRes, error)
+	CompleteReferral(ctx context.Context, in *CompleteReferralReq, opts ...grpc.CallOption) (*ReferralRes, error)
+	RevokeReferral(ctx context.Context, in *RevokeReferralReq, opts ...grpc.CallOption) (*ReferralRes, error)
+	RedeemPoints(ctx context.Context, in *RedeemPointsReq, opts ...grpc.CallOption) (*PointsBalanceRes, error)
+	RestorePoints(ctx context.Context, in *RestorePointsReq, opts ...grpc.CallOption) (*PointsBalanceRes, error)
 }
 
 type userServiceClient struct {
@@ -75,6 +103,56 @@ func (c *userServiceClient) GetUserByEmail(ctx context.Context, in *GetUserByEma
 	return out, nil
 }
 
+func (c *userServiceClient) VerifyCredentials(ctx context.Context, in *VerifyCredentialsReq, opts ...grpc.CallOption) (*GetUserRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUserRes)
+	err := c.cc.Invoke(ctx, UserService_VerifyCredentials_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetUsersByIds(ctx context.Context, in *GetUsersByIdsReq, opts ...grpc.CallOption) (*GetUsersByIdsRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUsersByIdsRes)
+	err := c.cc.Invoke(ctx, UserService_GetUsersByIds_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ListUsers(ctx context.Context, in *ListUsersReq, opts ...grpc.CallOption) (*ListUsersRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListUsersRes)
+	err := c.cc.Invoke(ctx, UserService_ListUsers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) UpdateUser(ctx context.Context, in *UpdateUserReq, opts ...grpc.CallOption) (*GetUserRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUserRes)
+	err := c.cc.Invoke(ctx, UserService_UpdateUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) SetUserStatus(ctx context.Context, in *SetUserStatusReq, opts ...grpc.CallOption) (*GetUserRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUserRes)
+	err := c.cc.Invoke(ctx, UserService_SetUserStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *userServiceClient) ResetPasswordByEmail(ctx context.Context, in *ResetPasswordByEmailReq, opts ...grpc.CallOption) (*ResetPasswordRes, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(ResetPasswordRes)
@@ -95,6 +173,96 @@ func (c *userServiceClient) VerifyEmail(ctx context.Context, in *VerifyEmailReq,
 	return out, nil
 }
 
+func (c *userServiceClient) SetEmailVerificationToken(ctx context.Context, in *SetEmailVerificationTokenReq, opts ...grpc.CallOption) (*SetEmailVerificationTokenRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetEmailVerificationTokenRes)
+	err := c.cc.Invoke(ctx, UserService_SetEmailVerificationToken_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) RequestContactChange(ctx context.Context, in *RequestContactChangeReq, opts ...grpc.CallOption) (*RequestContactChangeRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RequestContactChangeRes)
+	err := c.cc.Invoke(ctx, UserService_RequestContactChange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) ConfirmContactChange(ctx context.Context, in *ConfirmContactChangeReq, opts ...grpc.CallOption) (*ConfirmContactChangeRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConfirmContactChangeRes)
+	err := c.cc.Invoke(ctx, UserService_ConfirmContactChange_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) GetSavedDestination(ctx context.Context, in *GetSavedDestinationReq, opts ...grpc.CallOption) (*GetSavedDestinationRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSavedDestinationRes)
+	err := c.cc.Invoke(ctx, UserService_GetSavedDestination_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) QualifyReferral(ctx context.Context, in *QualifyReferralReq, opts ...grpc.CallOption) (*ReferralRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReferralRes)
+	err := c.cc.Invoke(ctx, UserService_QualifyReferral_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) CompleteReferral(ctx context.Context, in *CompleteReferralReq, opts ...grpc.CallOption) (*ReferralRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReferralRes)
+	err := c.cc.Invoke(ctx, UserService_CompleteReferral_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) RevokeReferral(ctx context.Context, in *RevokeReferralReq, opts ...grpc.CallOption) (*ReferralRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReferralRes)
+	err := c.cc.Invoke(ctx, UserService_RevokeReferral_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) RedeemPoints(ctx context.Context, in *RedeemPointsReq, opts ...grpc.CallOption) (*PointsBalanceRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PointsBalanceRes)
+	err := c.cc.Invoke(ctx, UserService_RedeemPoints_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) RestorePoints(ctx context.Context, in *RestorePointsReq, opts ...grpc.CallOption) (*PointsBalanceRes, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PointsBalanceRes)
+	err := c.cc.Invoke(ctx, UserService_RestorePoints_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UserServiceServer is the server API for UserService service.
 // All implementations must embed UnimplementedUserServiceServer
 // for forward compatibility.
@@ -102,8 +270,22 @@ type UserServiceServer interface {
 	CreateUser(context.Context, *CreateUserReq) (*CreateUserRes, error)
 	GetUserById(context.Context, *GetUserByIdReq) (*GetUserRes, error)
 	GetUserByEmail(context.Context, *GetUserByEmailReq) (*GetUserRes, error)
+	VerifyCredentials(context.Context, *VerifyCredentialsReq) (*GetUserRes, error)
+	GetUsersByIds(context.Context, *GetUsersByIdsReq) (*GetUsersByIdsRes, error)
+	ListUsers(context.Context, *ListUsersReq) (*ListUsersRes, error)
+	UpdateUser(context.Context, *UpdateUserReq) (*GetUserRes, error)
+	SetUserStatus(context.Context, *SetUserStatusReq) (*GetUserRes, error)
 	ResetPasswordByEmail(context.Context, *ResetPasswordByEmailReq) (*ResetPasswordRes, error)
 	VerifyEmail(context.Context, *VerifyEmailReq) (*VerifyEmailRes, error)
+	SetEmailVerificationToken(context.Context, *SetEmailVerificationTokenReq) (*SetEmailVerificationTokenRes, error)
+	RequestContactChange(context.Context, *RequestContactChangeReq) (*RequestContactChangeRes, error)
+	ConfirmContactChange(context.Context, *ConfirmContactChangeReq) (*ConfirmContactChangeRes, error)
+	GetSavedDestination(context.Context, *GetSavedDestinationReq) (*GetSavedDestinationRes, error)
+	QualifyReferral(context.Context, *QualifyReferralReq) (*ReferralRes, error)
+	CompleteReferral(context.Context, *CompleteReferralReq) (*ReferralRes, error)
+	RevokeReferral(context.Context, *RevokeReferralReq) (*ReferralRes, error)
+	RedeemPoints(context.Context, *RedeemPointsReq) (*PointsBalanceRes, error)
+	RestorePoints(context.Context, *RestorePointsReq) (*PointsBalanceRes, error)
 	mustEmbedUnimplementedUserServiceServer()
 }
 
@@ -123,12 +305,54 @@ func (UnimplementedUserServiceServer) GetUserById(context.Context, *GetUserByIdR
 func (UnimplementedUserServiceServer) GetUserByEmail(context.Context, *GetUserByEmailReq) (*GetUserRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetUserByEmail not implemented")
 }
+func (UnimplementedUserServiceServer) VerifyCredentials(context.Context, *VerifyCredentialsReq) (*GetUserRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyCredentials not implemented")
+}
+func (UnimplementedUserServiceServer) GetUsersByIds(context.Context, *GetUsersByIdsReq) (*GetUsersByIdsRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUsersByIds not implemented")
+}
+func (UnimplementedUserServiceServer) ListUsers(context.Context, *ListUsersReq) (*ListUsersRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
+}
+func (UnimplementedUserServiceServer) UpdateUser(context.Context, *UpdateUserReq) (*GetUserRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateUser not implemented")
+}
+func (UnimplementedUserServiceServer) SetUserStatus(context.Context, *SetUserStatusReq) (*GetUserRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetUserStatus not implemented")
+}
 func (UnimplementedUserServiceServer) ResetPasswordByEmail(context.Context, *ResetPasswordByEmailReq) (*ResetPasswordRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ResetPasswordByEmail not implemented")
 }
 func (UnimplementedUserServiceServer) VerifyEmail(context.Context, *VerifyEmailReq) (*VerifyEmailRes, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method VerifyEmail not implemented")
 }
+func (UnimplementedUserServiceServer) SetEmailVerificationToken(context.Context, *SetEmailVerificationTokenReq) (*SetEmailVerificationTokenRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetEmailVerificationToken not implemented")
+}
+func (UnimplementedUserServiceServer) RequestContactChange(context.Context, *RequestContactChangeReq) (*RequestContactChangeRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestContactChange not implemented")
+}
+func (UnimplementedUserServiceServer) ConfirmContactChange(context.Context, *ConfirmContactChangeReq) (*ConfirmContactChangeRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmContactChange not implemented")
+}
+func (UnimplementedUserServiceServer) GetSavedDestination(context.Context, *GetSavedDestinationReq) (*GetSavedDestinationRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSavedDestination not implemented")
+}
+func (UnimplementedUserServiceServer) QualifyReferral(context.Context, *QualifyReferralReq) (*ReferralRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QualifyReferral not implemented")
+}
+func (UnimplementedUserServiceServer) CompleteReferral(context.Context, *CompleteReferralReq) (*ReferralRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompleteReferral not implemented")
+}
+func (UnimplementedUserServiceServer) RevokeReferral(context.Context, *RevokeReferralReq) (*ReferralRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeReferral not implemented")
+}
+func (UnimplementedUserServiceServer) RedeemPoints(context.Context, *RedeemPointsReq) (*PointsBalanceRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RedeemPoints not implemented")
+}
+func (UnimplementedUserServiceServer) RestorePoints(context.Context, *RestorePointsReq) (*PointsBalanceRes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestorePoints not implemented")
+}
 func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
 func (UnimplementedUserServiceServer) testEmbeddedByValue()                     {}
 
@@ -204,6 +428,96 @@ func _UserService_GetUserByEmail_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserService_VerifyCredentials_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyCredentialsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).VerifyCredentials(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_VerifyCredentials_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).VerifyCredentials(ctx, req.(*VerifyCredentialsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetUsersByIds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUsersByIdsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetUsersByIds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetUsersByIds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetUsersByIds(ctx, req.(*GetUsersByIdsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUsersReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ListUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ListUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ListUsers(ctx, req.(*ListUsersReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_UpdateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateUserReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).UpdateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_UpdateUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).UpdateUser(ctx, req.(*UpdateUserReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_SetUserStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetUserStatusReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).SetUserStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_SetUserStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).SetUserStatus(ctx, req.(*SetUserStatusReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _UserService_ResetPasswordByEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ResetPasswordByEmailReq)
 	if err := dec(in); err != nil {
@@ -240,6 +554,168 @@ func _UserService_VerifyEmail_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserService_SetEmailVerificationToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetEmailVerificationTokenReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).SetEmailVerificationToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_SetEmailVerificationToken_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).SetEmailVerificationToken(ctx, req.(*SetEmailVerificationTokenReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_RequestContactChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestContactChangeReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RequestContactChange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_RequestContactChange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RequestContactChange(ctx, req.(*RequestContactChangeReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_ConfirmContactChange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConfirmContactChangeReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ConfirmContactChange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ConfirmContactChange_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ConfirmContactChange(ctx, req.(*ConfirmContactChangeReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_GetSavedDestination_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSavedDestinationReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetSavedDestination(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetSavedDestination_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetSavedDestination(ctx, req.(*GetSavedDestinationReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_QualifyReferral_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QualifyReferralReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).QualifyReferral(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_QualifyReferral_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).QualifyReferral(ctx, req.(*QualifyReferralReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_CompleteReferral_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompleteReferralReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).CompleteReferral(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_CompleteReferral_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).CompleteReferral(ctx, req.(*CompleteReferralReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_RevokeReferral_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeReferralReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RevokeReferral(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_RevokeReferral_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RevokeReferral(ctx, req.(*RevokeReferralReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_RedeemPoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RedeemPointsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RedeemPoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_RedeemPoints_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RedeemPoints(ctx, req.(*RedeemPointsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_RestorePoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestorePointsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).RestorePoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_RestorePoints_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).RestorePoints(ctx, req.(*RestorePointsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -259,6 +735,26 @@ var UserService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetUserByEmail",
 			Handler:    _UserService_GetUserByEmail_Handler,
 		},
+		{
+			MethodName: "VerifyCredentials",
+			Handler:    _UserService_VerifyCredentials_Handler,
+		},
+		{
+			MethodName: "GetUsersByIds",
+			Handler:    _UserService_GetUsersByIds_Handler,
+		},
+		{
+			MethodName: "ListUsers",
+			Handler:    _UserService_ListUsers_Handler,
+		},
+		{
+			MethodName: "UpdateUser",
+			Handler:    _UserService_UpdateUser_Handler,
+		},
+		{
+			MethodName: "SetUserStatus",
+			Handler:    _UserService_SetUserStatus_Handler,
+		},
 		{
 			MethodName: "ResetPasswordByEmail",
 			Handler:    _UserService_ResetPasswordByEmail_Handler,
@@ -267,6 +763,42 @@ var UserService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "VerifyEmail",
 			Handler:    _UserService_VerifyEmail_Handler,
 		},
+		{
+			MethodName: "SetEmailVerificationToken",
+			Handler:    _UserService_SetEmailVerificationToken_Handler,
+		},
+		{
+			MethodName: "RequestContactChange",
+			Handler:    _UserService_RequestContactChange_Handler,
+		},
+		{
+			MethodName: "ConfirmContactChange",
+			Handler:    _UserService_ConfirmContactChange_Handler,
+		},
+		{
+			MethodName: "GetSavedDestination",
+			Handler:    _UserService_GetSavedDestination_Handler,
+		},
+		{
+			MethodName: "QualifyReferral",
+			Handler:    _UserService_QualifyReferral_Handler,
+		},
+		{
+			MethodName: "CompleteReferral",
+			Handler:    _UserService_CompleteReferral_Handler,
+		},
+		{
+			MethodName: "RevokeReferral",
+			Handler:    _UserService_RevokeReferral_Handler,
+		},
+		{
+			MethodName: "RedeemPoints",
+			Handler:    _UserService_RedeemPoints_Handler,
+		},
+		{
+			MethodName: "RestorePoints",
+			Handler:    _UserService_RestorePoints_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "user.proto",
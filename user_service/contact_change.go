@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	upb "github.com/akmmp241/topupstore-microservice/user-proto/v1"
+	"github.com/go-sql-driver/mysql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	ContactChangeEmail       = "email"
+	ContactChangePhoneNumber = "phone_number"
+)
+
+func isContactChangeType(changeType string) bool {
+	return changeType == ContactChangeEmail || changeType == ContactChangePhoneNumber
+}
+
+// RequestContactChange stores a pending change of email or phone number. The
+// users row is only touched once the change is confirmed, and a new request
+// replaces any pending one of the same type.
+func (s *GrpcServer) RequestContactChange(ctx context.Context, req *upb.RequestContactChangeReq) (*upb.RequestContactChangeRes, error) {
+	if !isContactChangeType(req.GetType()) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid contact change type")
+	}
+	if req.GetNewValue() == "" || req.GetTokenHash() == "" || req.GetExpiresAt() == nil {
+		return nil, status.Error(codes.InvalidArgument, "New value, token and expiry are required")
+	}
+
+	user, err := s.getUser(ctx, req.GetUserId(), "id")
+	if err != nil {
+		return nil, err
+	}
+
+	current := user.GetEmail()
+	if req.GetType() == ContactChangePhoneNumber {
+		current = user.GetPhoneNumber()
+	}
+	if current == req.GetNewValue() {
+		return nil, status.Error(codes.InvalidArgument, "New value is the same as the current one")
+	}
+
+	// the column name comes from the whitelist above
+	var taken bool
+	row := s.DB.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE "+req.GetType()+" = ? AND id <> ?)", req.GetNewValue(), user.GetId())
+	if err := row.Scan(&taken); err != nil {
+		slog.Error("Error occurred while querying user", "err", err)
+		return nil, err
+	}
+	if taken {
+		return nil, status.Error(codes.AlreadyExists, "Duplicate entry: "+req.GetType()+" already exists")
+	}
+
+	if err := s.replacePendingContactChange(ctx, user.GetId(), req); err != nil {
+		return nil, err
+	}
+
+	return &upb.RequestContactChangeRes{Msg: "successfully requested contact change"}, nil
+}
+
+func (s *GrpcServer) replacePendingContactChange(ctx context.Context, userId int32, req *upb.RequestContactChangeReq) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM pending_contact_changes WHERE user_id = ? AND type = ? AND confirmed_at IS NULL", userId, req.GetType())
+	if err != nil {
+		slog.Error("Error occurred while deleting pending contact changes", "err", err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO pending_contact_changes (user_id, type, new_value, token_hash, expires_at) VALUES (?, ?, ?, ?, ?)",
+		userId, req.GetType(), req.GetNewValue(), req.GetTokenHash(), req.GetExpiresAt().AsTime())
+	if err != nil {
+		slog.Error("Error occurred while inserting pending contact change", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// ConfirmContactChange applies a pending change. A confirmed email change also
+// marks the new address as verified since the user just proved owning it.
+func (s *GrpcServer) ConfirmContactChange(ctx context.Context, req *upb.ConfirmContactChangeReq) (*upb.ConfirmContactChangeRes, error) {
+	if !isContactChangeType(req.GetType()) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid contact change type")
+	}
+	if req.GetTokenHash() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Token is required")
+	}
+	if req.GetType() == ContactChangePhoneNumber && req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "User ID is required")
+	}
+
+	userId, oldValue, err := s.applyContactChange(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	publishUserUpdated(ctx, s.Producer, int(userId), req.GetType())
+
+	user, err := s.getUser(ctx, strconv.FormatInt(userId, 10), "id")
+	if err != nil {
+		return nil, err
+	}
+
+	return &upb.ConfirmContactChangeRes{User: user, OldValue: oldValue}, nil
+}
+
+func (s *GrpcServer) applyContactChange(ctx context.Context, req *upb.ConfirmContactChangeReq) (userId int64, oldValue string, err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return 0, "", err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	now := time.Now()
+
+	query := "SELECT id, user_id, new_value FROM pending_contact_changes WHERE token_hash = ? AND type = ? AND confirmed_at IS NULL AND expires_at > ?"
+	args := []any{req.GetTokenHash(), req.GetType(), now}
+	if req.GetUserId() != "" {
+		query += " AND user_id = ?"
+		args = append(args, req.GetUserId())
+	}
+
+	var changeId int64
+	var newValue string
+	err = tx.QueryRowContext(ctx, query+" FOR UPDATE", args...).Scan(&changeId, &userId, &newValue)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, "", status.Error(codes.NotFound, "Change request not found or expired")
+		}
+		slog.Error("Error occurred while querying pending contact change", "err", err)
+		return 0, "", err
+	}
+
+	err = tx.QueryRowContext(ctx, "SELECT "+req.GetType()+" FROM users WHERE id = ? FOR UPDATE", userId).Scan(&oldValue)
+	if err != nil {
+		slog.Error("Error occurred while querying user", "err", err)
+		return 0, "", err
+	}
+
+	if req.GetType() == ContactChangeEmail {
+		_, err = tx.ExecContext(ctx, "UPDATE users SET email = ?, email_verified_at = ?, email_verification_token = NULL, email_verification_expires_at = NULL WHERE id = ?",
+			newValue, now, userId)
+	} else {
+		_, err = tx.ExecContext(ctx, "UPDATE users SET phone_number = ? WHERE id = ?", newValue, userId)
+	}
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return 0, "", status.Error(codes.AlreadyExists, "Duplicate entry: "+req.GetType()+" already exists")
+		}
+		slog.Error("Error occurred while updating user", "err", err)
+		return 0, "", err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE pending_contact_changes SET confirmed_at = ? WHERE id = ?", now, changeId)
+	if err != nil {
+		slog.Error("Error occurred while confirming pending contact change", "err", err)
+		return 0, "", err
+	}
+
+	return userId, oldValue, nil
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	deletionConfirmationExpiry = time.Hour
+	deletionCheckInterval      = 10 * time.Minute
+	deletionBatchSize          = 100
+
+	defaultDeletionGracePeriod = 30 * 24 * time.Hour
+)
+
+func getDeletionGracePeriod() time.Duration {
+	gracePeriod, err := time.ParseDuration(os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD"))
+	if err != nil || gracePeriod < 0 {
+		return defaultDeletionGracePeriod
+	}
+
+	return gracePeriod
+}
+
+func anonymizedEmail(userId int) string {
+	return fmt.Sprintf("deleted-%d@deleted.invalid", userId)
+}
+
+// anonymizedPhoneNumber keeps phone numbers unique, deleted users cannot
+// all share an empty one.
+func anonymizedPhoneNumber(userId int) string {
+	return fmt.Sprintf("deleted-%d", userId)
+}
+
+func (s *UserService) handleRequestDeletion(c *fiber.Ctx) error {
+	deletionRequest := &AccountDeletionRequest{}
+	if err := c.BodyParser(deletionRequest); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err := s.Validator.Struct(deletionRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*deletionRequest, err.(validator.ValidationErrors))
+	}
+
+	user, err := s.getUser(c.Context(), shared.GetUserClaims(c).Subject, "id")
+	if err != nil {
+		return err
+	}
+
+	if match, _ := s.Hasher.Compare(user.Password, deletionRequest.Password); !match {
+		return fiber.NewError(fiber.StatusUnauthorized, "Password is incorrect")
+	}
+
+	var scheduled bool
+	row := s.DB.QueryRowContext(c.Context(), "SELECT EXISTS(SELECT 1 FROM account_deletions WHERE user_id = ? AND confirmed_at IS NOT NULL AND executed_at IS NULL AND cancelled_at IS NULL)", user.Id)
+	if err := row.Scan(&scheduled); err != nil {
+		slog.Error("Error occurred while querying account deletions", "err", err)
+		return err
+	}
+	if scheduled {
+		return fiber.NewError(fiber.StatusConflict, "Account deletion is already scheduled")
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	token := hex.EncodeToString(raw)
+	expiresAt := time.Now().Add(deletionConfirmationExpiry)
+
+	if err := s.createDeletionRequest(c.Context(), user.Id, hashToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	err = publishUserEvent(c.Context(), s.Producer, AccountDeletionRequested, &AccountDeletionMessage{
+		Name:            user.Name,
+		Email:           user.Email,
+		ConfirmationUrl: fmt.Sprintf("%s/api/users/deletion/%s", os.Getenv("APP_URL"), token),
+		GraceDays:       int(s.DeletionGracePeriod.Hours() / 24),
+		ExpiresAt:       expiresAt,
+	})
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to send confirmation email")
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "A confirmation link has been sent to your email address",
+		"data":    fiber.Map{"expired_at": expiresAt},
+		"errors":  nil,
+	})
+}
+
+func (s *UserService) createDeletionRequest(ctx context.Context, userId int, tokenHash string, expiresAt time.Time) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	// only the latest confirmation link works
+	_, err = tx.ExecContext(ctx, "UPDATE account_deletions SET cancelled_at = ? WHERE user_id = ? AND confirmed_at IS NULL AND cancelled_at IS NULL", time.Now(), userId)
+	if err != nil {
+		slog.Error("Error occurred while cancelling account deletions", "err", err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO account_deletions (user_id, token_hash, expires_at) VALUES (?, ?, ?)", userId, tokenHash, expiresAt)
+	if err != nil {
+		slog.Error("Error occurred while inserting account deletion", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserService) handleConfirmDeletion(c *fiber.Ctx) error {
+	token := c.Params("token")
+	if token == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid token")
+	}
+
+	now := time.Now()
+	scheduledFor := now.Add(s.DeletionGracePeriod)
+
+	result, err := s.DB.ExecContext(c.Context(), "UPDATE account_deletions SET confirmed_at = ?, scheduled_for = ? WHERE token_hash = ? AND confirmed_at IS NULL AND cancelled_at IS NULL AND expires_at > ?",
+		now, scheduledFor, hashToken(token), now)
+	if err != nil {
+		slog.Error("Error occurred while confirming account deletion", "err", err)
+		return err
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid or expired token")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Account deletion confirmed, it can be cancelled until it is executed",
+		"data":    fiber.Map{"scheduled_for": scheduledFor},
+		"errors":  nil,
+	})
+}
+
+func (s *UserService) handleCancelDeletion(c *fiber.Ctx) error {
+	result, err := s.DB.ExecContext(c.Context(), "UPDATE account_deletions SET cancelled_at = ? WHERE user_id = ? AND executed_at IS NULL AND cancelled_at IS NULL",
+		time.Now(), shared.GetUserClaims(c).Subject)
+	if err != nil {
+		slog.Error("Error occurred while cancelling account deletion", "err", err)
+		return err
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "No pending account deletion")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Account deletion cancelled",
+		"data":    nil,
+		"errors":  nil,
+	})
+}
+
+func (s *UserService) handleExport(c *fiber.Ctx) error {
+	profile, err := s.getProfile(c)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := shared.GetSessions(c.Context(), s.RedisClient, profile.Id)
+	if err != nil {
+		slog.Error("Error occurred while getting sessions", "err", err)
+		return err
+	}
+
+	destinations, err := s.getSavedDestinations(c.Context(), strconv.Itoa(profile.Id))
+	if err != nil {
+		return err
+	}
+
+	orders, err := getOrderHistory(profile.Id)
+	if err != nil {
+		slog.Error("Error occurred while getting order history", "err", err)
+		return fiber.NewError(fiber.StatusBadGateway, "Failed to collect order history")
+	}
+
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="account-export.json"`)
+	return c.JSON(fiber.Map{
+		"message": "Account data exported successfully",
+		"data": &AccountExport{
+			Profile:      profile,
+			Sessions:     sessions,
+			Destinations: destinations,
+			Orders:       orders,
+			ExportedAt:   time.Now(),
+		},
+		"errors": nil,
+	})
+}
+
+// RunDeletions executes confirmed deletions once their grace period is over.
+// It blocks until ctx is done.
+func (s *UserService) RunDeletions(ctx context.Context) {
+	ticker := time.NewTicker(deletionCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.executeDueDeletions(ctx); err != nil {
+				slog.Error("Error occurred while executing account deletions", "err", err)
+			}
+		}
+	}
+}
+
+func (s *UserService) executeDueDeletions(ctx context.Context) error {
+	rows, err := s.DB.QueryContext(ctx, "SELECT id, user_id FROM account_deletions WHERE confirmed_at IS NOT NULL AND executed_at IS NULL AND cancelled_at IS NULL AND scheduled_for <= ? LIMIT ?",
+		time.Now(), deletionBatchSize)
+	if err != nil {
+		return err
+	}
+
+	due := map[int]int{}
+	for rows.Next() {
+		var id, userId int
+		if err := rows.Scan(&id, &userId); err != nil {
+			rows.Close()
+			return err
+		}
+		due[id] = userId
+	}
+	rows.Close()
+
+	for id, userId := range due {
+		if err := s.executeDeletion(ctx, id, userId); err != nil {
+			slog.Error("Error occurred while deleting account", "user-id", userId, "err", err)
+			continue
+		}
+		slog.Info("Account deleted", "user-id", userId)
+	}
+
+	return nil
+}
+
+// executeDeletion anonymises the user in place instead of deleting the row,
+// orders keep referencing it for bookkeeping.
+func (s *UserService) executeDeletion(ctx context.Context, deletionId int, userId int) (err error) {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	// a cancellation may have landed since the batch was selected
+	var pending bool
+	err = tx.QueryRowContext(ctx, "SELECT executed_at IS NULL AND cancelled_at IS NULL FROM account_deletions WHERE id = ? FOR UPDATE", deletionId).Scan(&pending)
+	if err != nil {
+		return err
+	}
+	if !pending {
+		return nil
+	}
+
+	now := time.Now()
+	email := anonymizedEmail(userId)
+
+	_, err = tx.ExecContext(ctx, `UPDATE users SET name = 'Deleted User', email = ?, phone_number = ?, password = '',
+				email_verification_token = NULL, email_verification_expires_at = NULL, email_verified_at = NULL,
+				signup_ip = NULL, signup_device = NULL, deleted_at = ?
+			WHERE id = ?`, email, anonymizedPhoneNumber(userId), now, userId)
+	if err != nil {
+		return err
+	}
+
+	// the referral keeps its status for the referrer, not where the user signed up from
+	_, err = tx.ExecContext(ctx, "UPDATE referrals SET signup_ip = NULL, signup_device = NULL WHERE referred_id = ?", userId)
+	if err != nil {
+		return err
+	}
+
+	for _, query := range []string{
+		"DELETE FROM user_roles WHERE user_id = ?",
+		"DELETE FROM pending_contact_changes WHERE user_id = ?",
+		"DELETE FROM saved_destinations WHERE user_id = ?",
+	} {
+		if _, err = tx.ExecContext(ctx, query, userId); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE account_deletions SET executed_at = ? WHERE id = ?", now, deletionId)
+	if err != nil {
+		return err
+	}
+
+	// published before commit: if the commit fails the next run publishes
+	// again, and consumers handle the event idempotently
+	err = publishUserEvent(ctx, s.Producer, UserDeleted, &UserDeletedMessage{
+		UserId:          userId,
+		AnonymizedEmail: email,
+		DeletedAt:       now,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := shared.DeleteSessions(ctx, s.RedisClient, userId); err != nil {
+		slog.Warn("Error occurred while deleting sessions", "user-id", userId, "err", err)
+	}
+
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	upb "github.com/akmmp241/topupstore-microservice/user-proto/v1"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxSavedDestinations = 50
+
+// SavedDestination is a phone number or game account a user tops up often.
+// It is tied to an operator, a product type or both, and order service checks
+// the ordered product against them.
+type SavedDestination struct {
+	Id            int       `json:"id"`
+	UserId        int       `json:"user_id"`
+	Label         string    `json:"label"`
+	OperatorId    *int      `json:"operator_id"`
+	ProductTypeId *int      `json:"product_type_id"`
+	Destination   string    `json:"destination"`
+	ServerId      string    `json:"server_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+const savedDestinationColumns = "id, user_id, label, operator_id, product_type_id, destination, server_id, created_at, updated_at"
+
+func scanSavedDestination(row interface{ Scan(...any) error }) (*SavedDestination, error) {
+	var destination SavedDestination
+	var operatorId, productTypeId sql.NullInt64
+
+	err := row.Scan(&destination.Id, &destination.UserId, &destination.Label, &operatorId, &productTypeId,
+		&destination.Destination, &destination.ServerId, &destination.CreatedAt, &destination.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if operatorId.Valid {
+		id := int(operatorId.Int64)
+		destination.OperatorId = &id
+	}
+	if productTypeId.Valid {
+		id := int(productTypeId.Int64)
+		destination.ProductTypeId = &id
+	}
+
+	return &destination, nil
+}
+
+func (s *UserService) handleGetDestinations(c *fiber.Ctx) error {
+	destinations, err := s.getSavedDestinations(c.Context(), shared.GetUserClaims(c).Subject)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Saved destinations retrieved successfully",
+		"data":    destinations,
+		"errors":  nil,
+	})
+}
+
+func (s *UserService) handleCreateDestination(c *fiber.Ctx) error {
+	saveRequest, err := s.parseSaveDestinationRequest(c)
+	if err != nil {
+		return err
+	}
+
+	userId := shared.GetUserClaims(c).Subject
+
+	var count int
+	row := s.DB.QueryRowContext(c.Context(), "SELECT COUNT(*) FROM saved_destinations WHERE user_id = ?", userId)
+	if err := row.Scan(&count); err != nil {
+		slog.Error("Error occurred while counting saved destinations", "err", err)
+		return err
+	}
+	if count >= maxSavedDestinations {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "You can save at most "+strconv.Itoa(maxSavedDestinations)+" destinations")
+	}
+
+	result, err := s.DB.ExecContext(c.Context(), "INSERT INTO saved_destinations (user_id, label, operator_id, product_type_id, destination, server_id) VALUES (?, ?, ?, ?, ?, ?)",
+		userId, saveRequest.Label, saveRequest.OperatorId, saveRequest.ProductTypeId, saveRequest.Destination, saveRequest.ServerId)
+	if err != nil {
+		return saveDestinationError(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	destination, err := s.getSavedDestination(c.Context(), int(id), userId)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Destination saved successfully",
+		"data":    destination,
+		"errors":  nil,
+	})
+}
+
+func (s *UserService) handleUpdateDestination(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid destination id")
+	}
+
+	saveRequest, err := s.parseSaveDestinationRequest(c)
+	if err != nil {
+		return err
+	}
+
+	userId := shared.GetUserClaims(c).Subject
+
+	if _, err := s.getSavedDestination(c.Context(), id, userId); err != nil {
+		return err
+	}
+
+	_, err = s.DB.ExecContext(c.Context(), "UPDATE saved_destinations SET label = ?, operator_id = ?, product_type_id = ?, destination = ?, server_id = ? WHERE id = ? AND user_id = ?",
+		saveRequest.Label, saveRequest.OperatorId, saveRequest.ProductTypeId, saveRequest.Destination, saveRequest.ServerId, id, userId)
+	if err != nil {
+		return saveDestinationError(err)
+	}
+
+	destination, err := s.getSavedDestination(c.Context(), id, userId)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Destination updated successfully",
+		"data":    destination,
+		"errors":  nil,
+	})
+}
+
+func (s *UserService) handleDeleteDestination(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid destination id")
+	}
+
+	result, err := s.DB.ExecContext(c.Context(), "DELETE FROM saved_destinations WHERE id = ? AND user_id = ?", id, shared.GetUserClaims(c).Subject)
+	if err != nil {
+		slog.Error("Error occurred while deleting saved destination", "err", err)
+		return err
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "Destination not found")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Destination deleted successfully",
+		"data":    nil,
+		"errors":  nil,
+	})
+}
+
+func (s *UserService) parseSaveDestinationRequest(c *fiber.Ctx) (*SaveDestinationRequest, error) {
+	saveRequest := &SaveDestinationRequest{}
+	if err := c.BodyParser(saveRequest); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err := s.Validator.Struct(saveRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return nil, shared.NewFailedValidationError(*saveRequest, err.(validator.ValidationErrors))
+	}
+
+	return saveRequest, nil
+}
+
+func saveDestinationError(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case 1062:
+			return fiber.NewError(fiber.StatusConflict, "A destination with this label already exists")
+		case 1452:
+			return fiber.NewError(fiber.StatusUnprocessableEntity, "Operator or product type not found")
+		}
+	}
+
+	slog.Error("Error occurred while saving destination", "err", err)
+	return fiber.NewError(fiber.StatusInternalServerError, "Failed to save destination")
+}
+
+func (s *UserService) getSavedDestinations(ctx context.Context, userId string) ([]*SavedDestination, error) {
+	rows, err := s.DB.QueryContext(ctx, "SELECT "+savedDestinationColumns+" FROM saved_destinations WHERE user_id = ? ORDER BY label", userId)
+	if err != nil {
+		slog.Error("Error occurred while querying saved destinations", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	destinations := []*SavedDestination{}
+	for rows.Next() {
+		destination, err := scanSavedDestination(rows)
+		if err != nil {
+			slog.Error("Error occurred while scanning saved destination", "err", err)
+			return nil, err
+		}
+		destinations = append(destinations, destination)
+	}
+
+	return destinations, nil
+}
+
+func (s *UserService) getSavedDestination(ctx context.Context, id int, userId string) (*SavedDestination, error) {
+	row := s.DB.QueryRowContext(ctx, "SELECT "+savedDestinationColumns+" FROM saved_destinations WHERE id = ? AND user_id = ?", id, userId)
+
+	destination, err := scanSavedDestination(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fiber.NewError(fiber.StatusNotFound, "Destination not found")
+		}
+		slog.Error("Error occurred while querying saved destination", "err", err)
+		return nil, err
+	}
+
+	return destination, nil
+}
+
+// GetSavedDestination lets order service resolve a saved destination of the
+// buyer, destinations of other users are reported as missing.
+func (s *GrpcServer) GetSavedDestination(ctx context.Context, req *upb.GetSavedDestinationReq) (*upb.GetSavedDestinationRes, error) {
+	row := s.DB.QueryRowContext(ctx, "SELECT "+savedDestinationColumns+" FROM saved_destinations WHERE id = ? AND user_id = ?", req.GetId(), req.GetUserId())
+
+	destination, err := scanSavedDestination(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "Saved destination not found")
+		}
+		slog.Error("Error occurred while querying saved destination", "err", err)
+		return nil, err
+	}
+
+	res := &upb.SavedDestination{
+		Id:          int32(destination.Id),
+		UserId:      int32(destination.UserId),
+		Label:       destination.Label,
+		Destination: destination.Destination,
+		ServerId:    destination.ServerId,
+	}
+	if destination.OperatorId != nil {
+		res.OperatorId = int32(*destination.OperatorId)
+	}
+	if destination.ProductTypeId != nil {
+		res.ProductTypeId = int32(*destination.ProductTypeId)
+	}
+
+	return &upb.GetSavedDestinationRes{Destination: res}, nil
+}
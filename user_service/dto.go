@@ -1,17 +1,68 @@
 package main
 
-type RegisterRequest struct {
-	Email                  string `json:"email" validate:"required,email"`
-	Password               string `json:"password" validate:"required,min=8,max=255"`
-	Name                   string `json:"name" validate:"required,min=2,max=255"`
-	PhoneNumber            string `json:"phone_number" validate:"required,min=10,max=15"`
-	PasswordConfirmation   string `json:"password_confirmation" validate:"required,eqfield=Password"`
-	EmailVerificationToken string `json:"email_verification_token" validate:"required"`
-}
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+)
 
+// UpdateUserRequest cannot change the email or phone number, those go
+// through the confirmed change flow in auth service.
 type UpdateUserRequest struct {
-	Email       string `json:"email" validate:"required,email"`
-	Name        string `json:"name" validate:"required,min=2,max=255"`
-	PhoneNumber string `json:"phone_number" validate:"required,min=10,max=15"`
-	Password    string `json:"password" validate:"omitempty,min=8,max=255"`
+	Name     string `json:"name" validate:"required,min=2,max=255"`
+	Password string `json:"password" validate:"omitempty,min=8,max=255"`
+}
+
+type UpdateProfileRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=255"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword      string `json:"current_password" validate:"required"`
+	Password             string `json:"password" validate:"required,min=8,max=255"`
+	PasswordConfirmation string `json:"password_confirmation" validate:"required,eqfield=Password"`
+}
+
+type AccountDeletionRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// SaveDestinationRequest ties the destination to an operator, a product type
+// or both.
+type SaveDestinationRequest struct {
+	Label         string `json:"label" validate:"required,max=100"`
+	OperatorId    *int   `json:"operator_id" validate:"required_without=ProductTypeId,omitempty,min=1"`
+	ProductTypeId *int   `json:"product_type_id" validate:"required_without=OperatorId,omitempty,min=1"`
+	Destination   string `json:"destination" validate:"required,max=255"`
+	ServerId      string `json:"server_id" validate:"omitempty,max=255"`
+}
+
+type AccountExport struct {
+	Profile      *ProfileResponse    `json:"profile"`
+	Sessions     []*shared.Session   `json:"sessions"`
+	Destinations []*SavedDestination `json:"saved_destinations"`
+	Orders       []json.RawMessage   `json:"orders"`
+	ExportedAt   time.Time           `json:"exported_at"`
+}
+
+// ProfileResponse is what end users get to see about themselves.
+type ProfileResponse struct {
+	Id              int        `json:"id"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	PhoneNumber     string     `json:"phone_number"`
+	Tier            string     `json:"tier"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Roles           []string   `json:"roles"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type UpdateUserTierRequest struct {
+	Tier string `json:"tier" validate:"required,oneof=retail silver gold reseller"`
+}
+
+type UpdateUserRolesRequest struct {
+	Roles []string `json:"roles" validate:"required,min=1,dive,required"`
 }
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+const UserTopic = "user-events"
+
+const (
+	UserUpdated              = "user-updated"
+	UserDeleted              = "user-deleted"
+	AccountDeletionRequested = "account-deletion-requested"
+	ReferralRewarded         = "referral-rewarded"
+)
+
+const (
+	ReferralRoleReferrer = "referrer"
+	ReferralRoleReferred = "referred"
+)
+
+type UserEvent[T UserUpdatedMessage | UserDeletedMessage | AccountDeletionMessage | ReferralRewardedMessage] struct {
+	EventType string `json:"event_type"`
+	Data      *T     `json:"data"`
+}
+
+// UserUpdatedMessage only names the changed fields, consumers that need the
+// new values fetch the user themselves.
+type UserUpdatedMessage struct {
+	UserId    int       `json:"user_id"`
+	Changes   []string  `json:"changes"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserDeletedMessage tells other services to purge or anonymise what they
+// keep about the user.
+type UserDeletedMessage struct {
+	UserId          int       `json:"user_id"`
+	AnonymizedEmail string    `json:"anonymized_email"`
+	DeletedAt       time.Time `json:"deleted_at"`
+}
+
+type AccountDeletionMessage struct {
+	Name            string    `json:"name"`
+	Email           string    `json:"email"`
+	ConfirmationUrl string    `json:"confirmation_url"`
+	GraceDays       int       `json:"grace_days"`
+	ExpiresAt       time.Time `json:"expired_at"`
+}
+
+// ReferralRewardedMessage is sent to each side of a referral once the
+// reward has been credited to their balance.
+type ReferralRewardedMessage struct {
+	UserId int    `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	Amount int    `json:"amount"`
+}
+
+func publishUserEvent[T UserUpdatedMessage | UserDeletedMessage | AccountDeletionMessage | ReferralRewardedMessage](ctx context.Context, producer *KafkaProducer, eventType string, data *T) error {
+	eventBytes, err := json.Marshal(UserEvent[T]{EventType: eventType, Data: data})
+	if err != nil {
+		slog.Error("Error occurred while marshalling message", "err", err)
+		return err
+	}
+
+	msg := [2]string{"", string(eventBytes)}
+	if err := producer.Write(ctx, UserTopic, msg); err != nil {
+		slog.Error("Error occurred while sending message to Kafka", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func publishUserUpdated(ctx context.Context, producer *KafkaProducer, userId int, changes ...string) {
+	_ = publishUserEvent(ctx, producer, UserUpdated, &UserUpdatedMessage{
+		UserId:    userId,
+		Changes:   changes,
+		UpdatedAt: time.Now(),
+	})
+}
@@ -13,22 +13,31 @@ import (
 	"github.com/akmmp241/topupstore-microservice/shared"
 	upb "github.com/akmmp241/topupstore-microservice/user-proto/v1"
 	"github.com/go-sql-driver/mysql"
-	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// grpcPermissions lists the methods only staff may call, the other methods
+// are used by the services themselves.
+var grpcPermissions = map[string]string{
+	upb.UserService_ListUsers_FullMethodName:     shared.PermUsersRead,
+	upb.UserService_UpdateUser_FullMethodName:    shared.PermUsersWrite,
+	upb.UserService_SetUserStatus_FullMethodName: shared.PermUsersWrite,
+}
+
 type GrpcServer struct {
 	ListenAddr  string
 	DB          *sql.DB
+	Producer    *KafkaProducer
+	Hasher      *PasswordHasher
 	Server      *grpc.Server
 	NetListener net.Listener
 	upb.UnimplementedUserServiceServer
 }
 
-func NewGrpcServer(listenAddr string, DB *sql.DB) *GrpcServer {
+func NewGrpcServer(listenAddr string, DB *sql.DB, producer *KafkaProducer, hasher *PasswordHasher) *GrpcServer {
 	listener, err := net.Listen("tcp", listenAddr)
 	if err != nil {
 		slog.Error("Error occurred while creating listener", "err", err)
@@ -38,7 +47,9 @@ func NewGrpcServer(listenAddr string, DB *sql.DB) *GrpcServer {
 	return &GrpcServer{
 		ListenAddr:  listenAddr,
 		DB:          DB,
-		Server:      grpc.NewServer(),
+		Producer:    producer,
+		Hasher:      hasher,
+		Server:      grpc.NewServer(grpc.UnaryInterceptor(shared.PermissionUnaryInterceptor(grpcPermissions))),
 		NetListener: listener,
 	}
 }
@@ -55,7 +66,7 @@ func (s *GrpcServer) Run() {
 }
 
 func (s *GrpcServer) CreateUser(ctx context.Context, req *upb.CreateUserReq) (*upb.CreateUserRes, error) {
-	password, err := bcrypt.GenerateFromPassword([]byte(req.GetPassword()), bcrypt.DefaultCost)
+	password, err := s.Hasher.Hash(req.GetPassword())
 	if err != nil {
 		slog.Error("Error occurred while hashing password", "err", err)
 		return nil, err
@@ -65,10 +76,15 @@ func (s *GrpcServer) CreateUser(ctx context.Context, req *upb.CreateUserReq) (*u
 	if err != nil {
 		return nil, err
 	}
-	defer shared.CommitOrRollback(tx, err)
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	var emailVerificationExpiresAt sql.NullTime
+	if req.GetEmailVerificationExpiresAt() != nil {
+		emailVerificationExpiresAt = sql.NullTime{Time: req.GetEmailVerificationExpiresAt().AsTime(), Valid: true}
+	}
 
-	result, err := tx.ExecContext(ctx, "INSERT INTO users (id, name, email, password, phone_number, email_verification_token) VALUES (NULL, ?, ?, ?, ?, ?)",
-		req.Name, req.Email, string(password), req.PhoneNumber, req.EmailVerificationToken)
+	result, err := tx.ExecContext(ctx, "INSERT INTO users (id, name, email, password, phone_number, email_verification_token, email_verification_expires_at, signup_ip, signup_device) VALUES (NULL, ?, ?, ?, ?, ?, ?, ?, ?)",
+		req.Name, req.Email, password, req.PhoneNumber, req.EmailVerificationToken, emailVerificationExpiresAt, req.GetSignupIp(), req.GetSignupDevice())
 
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
@@ -96,6 +112,30 @@ func (s *GrpcServer) CreateUser(ctx context.Context, req *upb.CreateUserReq) (*u
 		return nil, err
 	}
 
+	userId, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	// every new account starts as a plain customer
+	_, err = tx.ExecContext(ctx, "INSERT INTO user_roles (user_id, role_id) SELECT ?, id FROM roles WHERE name = ?", userId, shared.RoleCustomer)
+	if err != nil {
+		slog.Error("Error occurred while assigning default role", "err", err)
+		return nil, err
+	}
+
+	err = assignReferralCode(ctx, tx, userId)
+	if err != nil {
+		slog.Error("Error occurred while assigning referral code", "err", err)
+		return nil, err
+	}
+
+	if req.GetReferralCode() != "" {
+		if err = createReferral(ctx, tx, userId, req); err != nil {
+			return nil, err
+		}
+	}
+
 	return &upb.CreateUserRes{Msg: "Success create user"}, nil
 }
 
@@ -125,8 +165,55 @@ func (s *GrpcServer) GetUserByEmail(ctx context.Context, req *upb.GetUserByEmail
 	return &getUserRes, nil
 }
 
+// VerifyCredentials checks a password without the hash ever leaving this
+// service. Outdated hashes are replaced with the current settings on the way.
+func (s *GrpcServer) VerifyCredentials(ctx context.Context, req *upb.VerifyCredentialsReq) (*upb.GetUserRes, error) {
+	var hash string
+	row := s.DB.QueryRowContext(ctx, "SELECT password FROM users WHERE email = ? AND deleted_at IS NULL", req.GetEmail())
+	if err := row.Scan(&hash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.Hasher.CompareDummy(req.GetPassword())
+			return nil, status.Error(codes.Unauthenticated, "Invalid credentials")
+		}
+		slog.Error("Error occurred while querying user", "err", err)
+		return nil, err
+	}
+
+	match, outdated := s.Hasher.Compare(hash, req.GetPassword())
+	if !match {
+		return nil, status.Error(codes.Unauthenticated, "Invalid credentials")
+	}
+
+	if outdated {
+		s.rehashPassword(ctx, req.GetEmail(), hash, req.GetPassword())
+	}
+
+	user, err := s.getUser(ctx, req.GetEmail(), "email")
+	if err != nil {
+		return nil, err
+	}
+
+	return &upb.GetUserRes{User: user}, nil
+}
+
+// rehashPassword is best effort, a failure only means trying again on the
+// next login.
+func (s *GrpcServer) rehashPassword(ctx context.Context, email string, oldHash string, password string) {
+	hash, err := s.Hasher.Hash(password)
+	if err != nil {
+		slog.Error("Error occurred while hashing password", "err", err)
+		return
+	}
+
+	// the old hash in the condition keeps a concurrent password change intact
+	_, err = s.DB.ExecContext(ctx, "UPDATE users SET password = ? WHERE email = ? AND password = ?", hash, email, oldHash)
+	if err != nil {
+		slog.Error("Error occurred while rehashing password", "err", err)
+	}
+}
+
 func (s *GrpcServer) ResetPasswordByEmail(ctx context.Context, req *upb.ResetPasswordByEmailReq) (*upb.ResetPasswordRes, error) {
-	password, err := bcrypt.GenerateFromPassword([]byte(req.GetPassword()), bcrypt.DefaultCost)
+	password, err := s.Hasher.Hash(req.GetPassword())
 	if err != nil {
 		slog.Error("Error occurred while hashing password", "err", err)
 		return nil, err
@@ -140,7 +227,7 @@ func (s *GrpcServer) ResetPasswordByEmail(ctx context.Context, req *upb.ResetPas
 
 	query := "UPDATE users SET password = ?, updated_at = CURRENT_TIMESTAMP WHERE email = ?"
 
-	result, err := tx.ExecContext(ctx, query, string(password), req.GetEmail())
+	result, err := tx.ExecContext(ctx, query, password, req.GetEmail())
 	if err != nil {
 		slog.Info("Internal server error", "err", err)
 		return nil, err
@@ -159,8 +246,8 @@ func (s *GrpcServer) ResetPasswordByEmail(ctx context.Context, req *upb.ResetPas
 func (s *GrpcServer) VerifyEmail(ctx context.Context, req *upb.VerifyEmailReq) (*upb.VerifyEmailRes, error) {
 	emailVerifiedAt := time.Now()
 
-	query := "UPDATE users SET email_verification_token = NULL, email_verified_at = ? WHERE email_verification_token = ? AND email_verified_at IS NULL"
-	result, err := s.DB.ExecContext(ctx, query, emailVerifiedAt, req.GetEmailVerificationToken())
+	query := "UPDATE users SET email_verification_token = NULL, email_verification_expires_at = NULL, email_verified_at = ? WHERE email_verification_token = ? AND email_verification_expires_at > ? AND email_verified_at IS NULL"
+	result, err := s.DB.ExecContext(ctx, query, emailVerifiedAt, req.GetEmailVerificationToken(), emailVerifiedAt)
 	if err != nil {
 		slog.Error("Error occurred while updating user", "err", err)
 		return nil, err
@@ -176,35 +263,95 @@ func (s *GrpcServer) VerifyEmail(ctx context.Context, req *upb.VerifyEmailReq) (
 	return &upb.VerifyEmailRes{Msg: "successfully verified email"}, nil
 }
 
+func (s *GrpcServer) SetEmailVerificationToken(ctx context.Context, req *upb.SetEmailVerificationTokenReq) (*upb.SetEmailVerificationTokenRes, error) {
+	if req.GetEmailVerificationToken() == "" || req.GetExpiresAt() == nil {
+		return nil, status.Error(codes.InvalidArgument, "Verification token and expiry are required")
+	}
+
+	var emailVerifiedAt sql.NullTime
+	row := s.DB.QueryRowContext(ctx, "SELECT email_verified_at FROM users WHERE email = ?", req.GetEmail())
+	if err := row.Scan(&emailVerifiedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "User not found")
+		}
+		slog.Error("Error occurred while querying user", "err", err)
+		return nil, err
+	}
+
+	if emailVerifiedAt.Valid {
+		return nil, status.Error(codes.FailedPrecondition, "Email already verified")
+	}
+
+	// replacing the hash invalidates every previously sent link
+	query := "UPDATE users SET email_verification_token = ?, email_verification_expires_at = ? WHERE email = ? AND email_verified_at IS NULL"
+	_, err := s.DB.ExecContext(ctx, query, req.GetEmailVerificationToken(), req.GetExpiresAt().AsTime(), req.GetEmail())
+	if err != nil {
+		slog.Error("Error occurred while updating user", "err", err)
+		return nil, err
+	}
+
+	return &upb.SetEmailVerificationTokenRes{Msg: "successfully set email verification token"}, nil
+}
+
+const userColumns = "id, name, email, phone_number, status, tier, email_verified_at, created_at, updated_at"
+
 func (s *GrpcServer) getUser(ctx context.Context, target string, column string) (*upb.User, error) {
-	query := fmt.Sprintf("SELECT id, name, email, password, phone_number, email_verified_at, created_at, updated_at FROM users WHERE %s = ?", column)
+	query := fmt.Sprintf("SELECT %s FROM users WHERE %s = ?", userColumns, column)
 
-	rows, err := s.DB.QueryContext(ctx, query, target)
+	users, err := s.queryUsers(ctx, query, target)
 	if err != nil {
-		slog.Error("Error occurred while querying user", "err", err)
 		return nil, err
 	}
-	defer rows.Close()
 
-	var user upb.User
-	if !rows.Next() {
+	if len(users) == 0 {
 		return nil, status.Error(codes.NotFound, "User not found")
 	}
 
-	var emailVerifiedAt sql.NullTime
-	var createdAt time.Time
-	var updatedAt time.Time
-	err = rows.Scan(&user.Id, &user.Name, &user.Email, &user.Password, &user.PhoneNumber, &emailVerifiedAt, &createdAt, &updatedAt)
+	return users[0], nil
+}
+
+// queryUsers runs a query selecting userColumns and loads the roles of every
+// returned user.
+func (s *GrpcServer) queryUsers(ctx context.Context, query string, args ...any) ([]*upb.User, error) {
+	rows, err := s.DB.QueryContext(ctx, query, args...)
 	if err != nil {
-		slog.Error("Error occurred while scanning user", "err", err)
+		slog.Error("Error occurred while querying user", "err", err)
 		return nil, err
 	}
+	defer rows.Close()
 
-	if emailVerifiedAt.Valid {
-		user.EmailVerifiedAt = timestamppb.New(emailVerifiedAt.Time)
+	users := []*upb.User{}
+	for rows.Next() {
+		var user upb.User
+		var emailVerifiedAt sql.NullTime
+		var createdAt time.Time
+		var updatedAt time.Time
+		err = rows.Scan(&user.Id, &user.Name, &user.Email, &user.PhoneNumber, &user.Status, &user.Tier, &emailVerifiedAt, &createdAt, &updatedAt)
+		if err != nil {
+			slog.Error("Error occurred while scanning user", "err", err)
+			return nil, err
+		}
+
+		if emailVerifiedAt.Valid {
+			user.EmailVerifiedAt = timestamppb.New(emailVerifiedAt.Time)
+		}
+		user.CreatedAt = timestamppb.New(createdAt)
+		user.UpdatedAt = timestamppb.New(updatedAt)
+
+		users = append(users, &user)
+	}
+	if err := rows.Err(); err != nil {
+		slog.Error("Error occurred while iterating users", "err", err)
+		return nil, err
+	}
+	rows.Close()
+
+	for _, user := range users {
+		user.Roles, user.Permissions, err = getRolesAndPermissions(ctx, s.DB, int(user.Id))
+		if err != nil {
+			return nil, err
+		}
 	}
-	user.CreatedAt = timestamppb.New(createdAt)
-	user.UpdatedAt = timestamppb.New(updatedAt)
 
-	return &user, nil
+	return users, nil
 }
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	upb "github.com/akmmp241/topupstore-microservice/user-proto/v1"
+	"github.com/go-sql-driver/mysql"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxUsersPerBatch   = 100
+	defaultUsersPage   = 20
+	maxUsersPerPage    = 100
+	userSearchMinChars = 2
+)
+
+// updatableUserFields maps the field mask paths accepted by UpdateUser to
+// their columns.
+var updatableUserFields = map[string]string{
+	"name":         "name",
+	"email":        "email",
+	"phone_number": "phone_number",
+	"tier":         "tier",
+}
+
+func isUserStatus(userStatus string) bool {
+	return userStatus == shared.UserStatusActive || userStatus == shared.UserStatusSuspended || userStatus == shared.UserStatusBanned
+}
+
+func (s *GrpcServer) GetUsersByIds(ctx context.Context, req *upb.GetUsersByIdsReq) (*upb.GetUsersByIdsRes, error) {
+	if len(req.GetIds()) == 0 {
+		return &upb.GetUsersByIdsRes{Users: []*upb.User{}}, nil
+	}
+	if len(req.GetIds()) > maxUsersPerBatch {
+		return nil, status.Errorf(codes.InvalidArgument, "At most %d ids per request", maxUsersPerBatch)
+	}
+
+	placeholders := make([]string, len(req.GetIds()))
+	args := make([]any, len(req.GetIds()))
+	for i, id := range req.GetIds() {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM users WHERE id IN (%s) ORDER BY id", userColumns, strings.Join(placeholders, ", "))
+	users, err := s.queryUsers(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &upb.GetUsersByIdsRes{Users: users}, nil
+}
+
+// ListUsers pages through users newest first. The cursor is the id of the
+// last user of the previous page, so pages stay stable while users sign up.
+func (s *GrpcServer) ListUsers(ctx context.Context, req *upb.ListUsersReq) (*upb.ListUsersRes, error) {
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		pageSize = defaultUsersPage
+	}
+	if pageSize > maxUsersPerPage {
+		pageSize = maxUsersPerPage
+	}
+
+	conditions := []string{"deleted_at IS NULL"}
+	args := []any{}
+
+	if req.GetCursor() != "" {
+		cursor, err := strconv.ParseInt(req.GetCursor(), 10, 64)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "Invalid cursor")
+		}
+		conditions = append(conditions, "id < ?")
+		args = append(args, cursor)
+	}
+
+	if req.Verified != nil {
+		if req.GetVerified() {
+			conditions = append(conditions, "email_verified_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "email_verified_at IS NULL")
+		}
+	}
+
+	if req.GetCreatedFrom() != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, req.GetCreatedFrom().AsTime())
+	}
+	if req.GetCreatedTo() != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, req.GetCreatedTo().AsTime())
+	}
+
+	if req.GetStatus() != "" {
+		if !isUserStatus(req.GetStatus()) {
+			return nil, status.Error(codes.InvalidArgument, "Invalid status")
+		}
+		conditions = append(conditions, "status = ?")
+		args = append(args, req.GetStatus())
+	}
+
+	if search := strings.TrimSpace(req.GetQuery()); search != "" {
+		if len(search) < userSearchMinChars {
+			return nil, status.Errorf(codes.InvalidArgument, "Query needs at least %d characters", userSearchMinChars)
+		}
+		pattern := "%" + escapeLike(search) + "%"
+		conditions = append(conditions, "(name LIKE ? OR email LIKE ? OR phone_number LIKE ?)")
+		args = append(args, pattern, pattern, pattern)
+	}
+
+	// one extra row tells whether there is a next page
+	query := fmt.Sprintf("SELECT %s FROM users WHERE %s ORDER BY id DESC LIMIT ?", userColumns, strings.Join(conditions, " AND "))
+	args = append(args, pageSize+1)
+
+	users, err := s.queryUsers(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &upb.ListUsersRes{Users: users}
+	if len(users) > pageSize {
+		res.Users = users[:pageSize]
+		res.NextCursor = strconv.Itoa(int(res.Users[pageSize-1].GetId()))
+	}
+
+	return res, nil
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// UpdateUser changes the profile fields and tier named in the update mask. Email and
+// phone number bypass the confirmation flows, it is meant for staff only.
+func (s *GrpcServer) UpdateUser(ctx context.Context, req *upb.UpdateUserReq) (*upb.GetUserRes, error) {
+	if req.GetUser() == nil || len(req.GetUpdateMask().GetPaths()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "User and update mask are required")
+	}
+
+	values := map[string]string{
+		"name":         req.GetUser().GetName(),
+		"email":        req.GetUser().GetEmail(),
+		"phone_number": req.GetUser().GetPhoneNumber(),
+		"tier":         req.GetUser().GetTier(),
+	}
+
+	if slices.Contains(req.GetUpdateMask().GetPaths(), "tier") && !shared.IsTier(values["tier"]) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid tier")
+	}
+
+	sets := []string{}
+	args := []any{}
+	changes := []string{}
+	for _, path := range req.GetUpdateMask().GetPaths() {
+		column, ok := updatableUserFields[path]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "Field %q cannot be updated", path)
+		}
+		if values[path] == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "Field %q cannot be empty", path)
+		}
+
+		sets = append(sets, column+" = ?")
+		args = append(args, values[path])
+		changes = append(changes, path)
+	}
+
+	// a changed email has not been proven by the user
+	if slices.Contains(changes, "email") {
+		sets = append(sets, "email_verified_at = NULL")
+	}
+
+	query := fmt.Sprintf("UPDATE users SET %s WHERE id = ? AND deleted_at IS NULL", strings.Join(sets, ", "))
+	args = append(args, req.GetId())
+
+	_, err := s.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return nil, status.Error(codes.AlreadyExists, "Duplicate entry: email or phone number already exists")
+		}
+		slog.Error("Error occurred while updating user", "err", err)
+		return nil, err
+	}
+
+	// MySQL reports no affected rows when nothing changed, so the lookup is
+	// what tells a missing user apart
+	user, err := s.getUser(ctx, strconv.Itoa(int(req.GetId())), "id")
+	if err != nil {
+		return nil, err
+	}
+
+	publishUserUpdated(ctx, s.Producer, int(req.GetId()), changes...)
+
+	return &upb.GetUserRes{User: user}, nil
+}
+
+// SetUserStatus suspends, bans or reactivates a user. Only active users can
+// log in, suspending or banning also ends the sessions the user has.
+func (s *GrpcServer) SetUserStatus(ctx context.Context, req *upb.SetUserStatusReq) (*upb.GetUserRes, error) {
+	if !isUserStatus(req.GetStatus()) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid status")
+	}
+
+	var reason any
+	if req.GetStatus() != shared.UserStatusActive {
+		if req.GetReason() == "" {
+			return nil, status.Error(codes.InvalidArgument, "Reason is required")
+		}
+		reason = req.GetReason()
+	}
+
+	user, err := s.getUser(ctx, strconv.Itoa(int(req.GetId())), "id")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.DB.ExecContext(ctx, "UPDATE users SET status = ?, status_reason = ? WHERE id = ?", req.GetStatus(), reason, req.GetId())
+	if err != nil {
+		slog.Error("Error occurred while updating user status", "err", err)
+		return nil, err
+	}
+
+	if req.GetStatus() != shared.UserStatusActive {
+		if err := shared.RevokeUserTokens(ctx, int(req.GetId())); err != nil {
+			slog.Error("Error occurred while revoking user tokens", "user-id", req.GetId(), "err", err)
+			return nil, status.Error(codes.Internal, "Status was updated but existing sessions could not be ended")
+		}
+	}
+
+	if user.GetStatus() != req.GetStatus() {
+		slog.Info("User status changed", "user-id", req.GetId(), "from", user.GetStatus(), "to", req.GetStatus())
+		publishUserUpdated(ctx, s.Producer, int(req.GetId()), "status")
+	}
+	user.Status = req.GetStatus()
+
+	return &upb.GetUserRes{User: user}, nil
+}
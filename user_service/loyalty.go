@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	upb "github.com/akmmp241/topupstore-microservice/user-proto/v1"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Points are earned in lots that expire on their own date. Redemptions take
+// from the lots expiring first, the allocations remember which lots so a
+// restore puts the points back where they came from.
+const (
+	PointsEarn    = "earn"
+	PointsRedeem  = "redeem"
+	PointsRestore = "restore"
+	PointsExpire  = "expire"
+)
+
+const (
+	defaultPointsExpiryMonths = 12
+	pointsExpiryInterval      = time.Hour
+	pointsExpiryBatchSize     = 100
+
+	defaultPointsHistoryLimit = 20
+	maxPointsHistoryLimit     = 100
+)
+
+type PointsEntry struct {
+	Id          int        `json:"id"`
+	Type        string     `json:"type"`
+	Points      int        `json:"points"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	ReferenceId string     `json:"reference_id"`
+	Description string     `json:"description"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func getPointsExpiryMonths() int {
+	months, err := strconv.Atoi(os.Getenv("LOYALTY_POINTS_EXPIRY_MONTHS"))
+	if err != nil || months <= 0 {
+		return defaultPointsExpiryMonths
+	}
+
+	return months
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func getPointsBalance(ctx context.Context, db queryRower, userId int) (int, error) {
+	var balance int
+	err := db.QueryRowContext(ctx, "SELECT COALESCE(SUM(remaining), 0) FROM loyalty_point_entries WHERE user_id = ? AND type = ? AND remaining > 0 AND expires_at > ?",
+		userId, PointsEarn, time.Now()).Scan(&balance)
+	if err != nil {
+		slog.Error("Error occurred while querying points balance", "err", err)
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+func (s *UserService) handleGetPoints(c *fiber.Ctx) error {
+	userId, err := strconv.Atoi(shared.GetUserClaims(c).Subject)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	limit := c.QueryInt("limit", defaultPointsHistoryLimit)
+	if limit <= 0 || limit > maxPointsHistoryLimit {
+		limit = maxPointsHistoryLimit
+	}
+
+	balance, err := getPointsBalance(c.Context(), s.DB, userId)
+	if err != nil {
+		return err
+	}
+
+	query := "SELECT id, type, points, expires_at, reference_id, description, created_at FROM loyalty_point_entries WHERE user_id = ?"
+	args := []any{userId}
+	if before := c.QueryInt("before"); before > 0 {
+		query += " AND id < ?"
+		args = append(args, before)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.DB.QueryContext(c.Context(), query, args...)
+	if err != nil {
+		slog.Error("Error occurred while querying points history", "err", err)
+		return err
+	}
+	defer rows.Close()
+
+	entries := []*PointsEntry{}
+	for rows.Next() {
+		var entry PointsEntry
+		var expiresAt sql.NullTime
+		err := rows.Scan(&entry.Id, &entry.Type, &entry.Points, &expiresAt, &entry.ReferenceId, &entry.Description, &entry.CreatedAt)
+		if err != nil {
+			slog.Error("Error occurred while scanning points entry", "err", err)
+			return err
+		}
+		if expiresAt.Valid {
+			entry.ExpiresAt = &expiresAt.Time
+		}
+		entries = append(entries, &entry)
+	}
+
+	var nextBefore *int
+	if len(entries) == limit {
+		nextBefore = &entries[len(entries)-1].Id
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Points retrieved successfully",
+		"data": fiber.Map{
+			"balance":     balance,
+			"entries":     entries,
+			"next_before": nextBefore,
+		},
+		"errors": nil,
+	})
+}
+
+// earnPoints credits the points of a successful order. Every order earns
+// once, a repeated success event is ignored.
+func (s *UserService) earnPoints(ctx context.Context, userId int, orderId string, points int, productName string) error {
+	expiresAt := time.Now().AddDate(0, getPointsExpiryMonths(), 0)
+
+	_, err := s.DB.ExecContext(ctx, `INSERT INTO loyalty_point_entries (user_id, type, points, remaining, expires_at, reference_id, description)
+				VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		userId, PointsEarn, points, points, expiresAt, orderId, "Earned from "+productName)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			slog.Info("Points already earned for order", "order-id", orderId)
+			return nil
+		}
+		slog.Error("Error occurred while inserting earned points", "err", err)
+		return err
+	}
+
+	slog.Info("Points earned", "user-id", userId, "order-id", orderId, "points", points)
+
+	return nil
+}
+
+// RedeemPoints takes points of a user for an order being created, the lots
+// expiring first are used first.
+func (s *GrpcServer) RedeemPoints(ctx context.Context, req *upb.RedeemPointsReq) (res *upb.PointsBalanceRes, err error) {
+	if req.GetPoints() <= 0 || req.GetOrderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Points and order id are required")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, remaining FROM loyalty_point_entries
+				WHERE user_id = ? AND type = ? AND remaining > 0 AND expires_at > ? ORDER BY expires_at, id FOR UPDATE`,
+		req.GetUserId(), PointsEarn, time.Now())
+	if err != nil {
+		slog.Error("Error occurred while locking point lots", "err", err)
+		return nil, err
+	}
+
+	type lot struct{ id, remaining int }
+	lots := []lot{}
+	available := 0
+	for rows.Next() {
+		var l lot
+		if err = rows.Scan(&l.id, &l.remaining); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lots = append(lots, l)
+		available += l.remaining
+	}
+	rows.Close()
+
+	points := int(req.GetPoints())
+	if available < points {
+		return nil, status.Errorf(codes.FailedPrecondition, "Insufficient points, %d available", available)
+	}
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO loyalty_point_entries (user_id, type, points, reference_id, description) VALUES (?, ?, ?, ?, ?)",
+		req.GetUserId(), PointsRedeem, -points, req.GetOrderId(), "Redeemed for order "+req.GetOrderId())
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return nil, status.Error(codes.AlreadyExists, "Points already redeemed for this order")
+		}
+		slog.Error("Error occurred while inserting redeemed points", "err", err)
+		return nil, err
+	}
+
+	redeemId, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	left := points
+	for _, l := range lots {
+		if left == 0 {
+			break
+		}
+		taken := min(l.remaining, left)
+		left -= taken
+
+		if _, err = tx.ExecContext(ctx, "UPDATE loyalty_point_entries SET remaining = remaining - ? WHERE id = ?", taken, l.id); err != nil {
+			return nil, err
+		}
+		if _, err = tx.ExecContext(ctx, "INSERT INTO loyalty_point_allocations (redeem_entry_id, lot_entry_id, points) VALUES (?, ?, ?)", redeemId, l.id, taken); err != nil {
+			return nil, err
+		}
+	}
+
+	return &upb.PointsBalanceRes{Balance: int32(available - points)}, nil
+}
+
+func (s *GrpcServer) RestorePoints(ctx context.Context, req *upb.RestorePointsReq) (res *upb.PointsBalanceRes, err error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	var redeemId, userId int
+	err = tx.QueryRowContext(ctx, "SELECT id, user_id FROM loyalty_point_entries WHERE type = ? AND reference_id = ? FOR UPDATE",
+		PointsRedeem, req.GetOrderId()).Scan(&redeemId, &userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "No points were redeemed for this order")
+		}
+		slog.Error("Error occurred while querying redeemed points", "err", err)
+		return nil, err
+	}
+
+	// points of lots that expired since the redemption are not given back
+	now := time.Now()
+	var restorable int
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(a.points), 0) FROM loyalty_point_allocations a JOIN loyalty_point_entries l ON l.id = a.lot_entry_id
+				WHERE a.redeem_entry_id = ? AND l.expires_at > ?`, redeemId, now).Scan(&restorable)
+	if err != nil {
+		slog.Error("Error occurred while querying point allocations", "err", err)
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO loyalty_point_entries (user_id, type, points, reference_id, description) VALUES (?, ?, ?, ?, ?)",
+		userId, PointsRestore, restorable, req.GetOrderId(), "Restored from order "+req.GetOrderId())
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if !errors.As(err, &mysqlErr) || mysqlErr.Number != 1062 {
+			slog.Error("Error occurred while inserting restored points", "err", err)
+			return nil, err
+		}
+		// restored before, nothing to give back
+		err = nil
+	} else {
+		_, err = tx.ExecContext(ctx, `UPDATE loyalty_point_entries l JOIN loyalty_point_allocations a ON a.lot_entry_id = l.id
+					SET l.remaining = l.remaining + a.points WHERE a.redeem_entry_id = ? AND l.expires_at > ?`, redeemId, now)
+		if err != nil {
+			slog.Error("Error occurred while restoring point lots", "err", err)
+			return nil, err
+		}
+	}
+
+	balance, err := getPointsBalance(ctx, tx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &upb.PointsBalanceRes{Balance: int32(balance)}, nil
+}
+
+// RunPointExpiry books the expiry of lots past their date, so the history
+// tells where the points went. It blocks until ctx is done.
+func (s *UserService) RunPointExpiry(ctx context.Context) {
+	ticker := time.NewTicker(pointsExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.expirePoints(ctx); err != nil {
+				slog.Error("Error occurred while expiring points", "err", err)
+			}
+		}
+	}
+}
+
+func (s *UserService) expirePoints(ctx context.Context) error {
+	rows, err := s.DB.QueryContext(ctx, "SELECT id FROM loyalty_point_entries WHERE type = ? AND remaining > 0 AND expires_at <= ? LIMIT ?",
+		PointsEarn, time.Now(), pointsExpiryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	lotIds := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		lotIds = append(lotIds, id)
+	}
+	rows.Close()
+
+	for _, id := range lotIds {
+		if err := s.expireLot(ctx, id); err != nil {
+			slog.Error("Error occurred while expiring point lot", "lot-id", id, "err", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *UserService) expireLot(ctx context.Context, lotId int) (err error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	var userId, remaining int
+	err = tx.QueryRowContext(ctx, "SELECT user_id, remaining FROM loyalty_point_entries WHERE id = ? AND remaining > 0 FOR UPDATE", lotId).Scan(&userId, &remaining)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// redeemed in the meantime
+			return nil
+		}
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO loyalty_point_entries (user_id, type, points, reference_id, description) VALUES (?, ?, ?, ?, ?)",
+		userId, PointsExpire, -remaining, fmt.Sprintf("lot:%d", lotId), "Points expired")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE loyalty_point_entries SET remaining = 0 WHERE id = ?", lotId)
+	return err
+}
@@ -18,8 +18,13 @@ func main() {
 
 	db := shared.GetConnection()
 
-	app := NewAppServer(db)
-	grpcApp := NewGrpcServer(":"+grpcPort, db)
+	bootstrapServer := os.Getenv("KAFKA_HOST") + ":" + os.Getenv("KAFKA_PORT")
+	producer := NewKafkaProducer(bootstrapServer)
+
+	hasher := NewPasswordHasher()
+
+	app := NewAppServer(db, producer, hasher)
+	grpcApp := NewGrpcServer(":"+grpcPort, db, producer, hasher)
 
 	go app.RunHttpServer(port)
 	go grpcApp.Run()
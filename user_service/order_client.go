@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/gofiber/fiber/v2"
+)
+
+// getOrderHistory fetches the orders of a buyer from order service. The
+// orders are passed through as is, user service does not own their shape.
+func getOrderHistory(userId int) ([]json.RawMessage, error) {
+	token, err := shared.GenerateJWTForService("user-service")
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("http://%s:%s/api/internal/users/%d/orders",
+		os.Getenv("ORDER_SERVICE_HOST"), os.Getenv("ORDER_SERVICE_PORT"), userId)
+
+	statusCode, body, errs := fiber.Get(url).
+		Set(fiber.HeaderAuthorization, "Bearer "+token).
+		Timeout(10 * time.Second).
+		Bytes()
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	if statusCode != fiber.StatusOK {
+		return nil, fmt.Errorf("order service returned status %d", statusCode)
+	}
+
+	var res struct {
+		Data []json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Data, nil
+}
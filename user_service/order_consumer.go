@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/segmentio/kafka-go"
+)
+
+const OrderTopic = "order-mail-service"
+
+const OrderSucceeded = "order-succeeded"
+
+const GroupId = "user-service-group"
+
+type OrderEvent struct {
+	EventType string          `json:"event_type"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// OrderSucceededMessage holds the fields of the order event user service
+// needs, the event carries more for the mails.
+type OrderSucceededMessage struct {
+	Id           string `json:"id"`
+	BuyerId      int    `json:"buyer_id"`
+	ProductName  string `json:"product_name"`
+	PointsEarned int    `json:"points_earned"`
+}
+
+// HandleOrder credits loyalty points of successful orders. Success events
+// are sent again on webhook retries, earnPoints ignores the repeats.
+func (s *UserService) HandleOrder(msg *kafka.Message) error {
+	var event OrderEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		slog.Error("Error unmarshalling message", "error", err)
+		return err
+	}
+
+	if event.EventType != OrderSucceeded {
+		return nil
+	}
+
+	var data OrderSucceededMessage
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		slog.Error("Error unmarshalling message", "error", err)
+		return err
+	}
+
+	if data.BuyerId == 0 || data.PointsEarned <= 0 {
+		return nil
+	}
+
+	return s.earnPoints(context.Background(), data.BuyerId, data.Id, data.PointsEarned, data.ProductName)
+}
+
+func StartOrderConsumer(handler func(msg *kafka.Message) error) {
+	reader := shared.NewKafkaConsumer(GroupId, OrderTopic)
+	defer reader.Close()
+
+	slog.Info("Kafka Consumer created with", "topic:", OrderTopic, "group-id:", GroupId)
+
+	for {
+		message, err := reader.ReadMessage(context.Background())
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				slog.Warn("Reached EOF, possibly no messages yet.")
+				continue
+			}
+			slog.Error("Error while reading", "error:", err)
+			break
+		}
+
+		if err := handler(&message); err != nil {
+			slog.Error("Error while handling message", "error:", err)
+		}
+	}
+}
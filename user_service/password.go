@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordHasherBcrypt   = "bcrypt"
+	PasswordHasherArgon2id = "argon2id"
+)
+
+const (
+	defaultBcryptCost = 12
+
+	argon2Memory      = 64 * 1024
+	argon2Iterations  = 3
+	argon2Parallelism = 2
+	argon2SaltLength  = 16
+	argon2KeyLength   = 32
+)
+
+var errInvalidHash = errors.New("invalid password hash")
+
+// PasswordHasher hashes new passwords with the configured algorithm and still
+// accepts hashes made with older settings, reporting them as outdated.
+type PasswordHasher struct {
+	Algorithm  string
+	BcryptCost int
+
+	// compared against when the user does not exist, so unknown emails take
+	// as long as wrong passwords
+	dummyHash string
+}
+
+func NewPasswordHasher() *PasswordHasher {
+	algorithm := os.Getenv("PASSWORD_HASHER")
+	if algorithm != PasswordHasherArgon2id {
+		algorithm = PasswordHasherBcrypt
+	}
+
+	cost, err := strconv.Atoi(os.Getenv("BCRYPT_COST"))
+	if err != nil || cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = defaultBcryptCost
+	}
+
+	hasher := &PasswordHasher{Algorithm: algorithm, BcryptCost: cost}
+
+	hasher.dummyHash, err = hasher.Hash("dummy-password")
+	if err != nil {
+		panic(err)
+	}
+
+	return hasher
+}
+
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	if h.Algorithm == PasswordHasherArgon2id {
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+
+		key := argon2.IDKey([]byte(password), salt, argon2Iterations, argon2Memory, argon2Parallelism, argon2KeyLength)
+
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, argon2Memory, argon2Iterations, argon2Parallelism,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Compare tells whether password matches hash, and whether hash should be
+// replaced because it was made with another algorithm or weaker settings.
+func (h *PasswordHasher) Compare(hash string, password string) (match bool, outdated bool) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := decodeArgon2Hash(hash)
+		if err != nil {
+			return false, false
+		}
+
+		actual := argon2.IDKey([]byte(password), salt, params.iterations, params.memory, params.parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return false, false
+		}
+
+		outdated = h.Algorithm != PasswordHasherArgon2id ||
+			params.memory < argon2Memory || params.iterations < argon2Iterations || params.parallelism < argon2Parallelism
+
+		return true, outdated
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return false, false
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	outdated = h.Algorithm != PasswordHasherBcrypt || err != nil || cost < h.BcryptCost
+
+	return true, outdated
+}
+
+// CompareDummy burns the time of a comparison without a real hash.
+func (h *PasswordHasher) CompareDummy(password string) {
+	h.Compare(h.dummyHash, password)
+}
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+func decodeArgon2Hash(hash string) (*argon2Params, []byte, []byte, error) {
+	// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return nil, nil, nil, errInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, nil, nil, errInvalidHash
+	}
+
+	params := &argon2Params{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, nil, nil, errInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, errInvalidHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return nil, nil, nil, errInvalidHash
+	}
+
+	return params, salt, key, nil
+}
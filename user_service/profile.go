@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	changePasswordAttemptsPerUser = 5
+	changePasswordAttemptWindow   = 15 * time.Minute
+)
+
+func (s *UserService) handleGetMe(c *fiber.Ctx) error {
+	profile, err := s.getProfile(c)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Profile retrieved successfully",
+		"data":    profile,
+		"errors":  nil,
+	})
+}
+
+func (s *UserService) handleUpdateMe(c *fiber.Ctx) error {
+	updateProfileRequest := &UpdateProfileRequest{}
+	if err := c.BodyParser(updateProfileRequest); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err := s.Validator.Struct(updateProfileRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*updateProfileRequest, err.(validator.ValidationErrors))
+	}
+
+	userId := shared.GetUserClaims(c).Subject
+
+	_, err = s.DB.ExecContext(c.Context(), "UPDATE users SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", updateProfileRequest.Name, userId)
+	if err != nil {
+		slog.Error("Error occurred while updating user", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to update profile")
+	}
+
+	// no changed rows is also what an unchanged name gives, the lookup of
+	// the profile tells a missing user apart
+	profile, err := s.getProfile(c)
+	if err != nil {
+		return err
+	}
+
+	publishUserUpdated(c.Context(), s.Producer, profile.Id, "name")
+
+	return c.JSON(fiber.Map{
+		"message": "Profile updated successfully",
+		"data":    profile,
+		"errors":  nil,
+	})
+}
+
+func (s *UserService) handleChangePassword(c *fiber.Ctx) error {
+	changePasswordRequest := &ChangePasswordRequest{}
+	if err := c.BodyParser(changePasswordRequest); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err := s.Validator.Struct(changePasswordRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*changePasswordRequest, err.(validator.ValidationErrors))
+	}
+
+	user, err := s.getUser(c.Context(), shared.GetUserClaims(c).Subject, "id")
+	if err != nil {
+		return err
+	}
+
+	// a stolen token must not be able to guess the current password
+	attemptsKey := fmt.Sprintf("change-password:%d", user.Id)
+	allowed, err := shared.AllowRequest(c.Context(), s.RedisClient, attemptsKey, changePasswordAttemptsPerUser, changePasswordAttemptWindow)
+	if err != nil {
+		slog.Error("Error occurred while checking rate limit", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+	}
+	if !allowed {
+		return fiber.NewError(fiber.StatusTooManyRequests, "Too many attempts, please try again later")
+	}
+
+	if match, _ := s.Hasher.Compare(user.Password, changePasswordRequest.CurrentPassword); !match {
+		return fiber.NewError(fiber.StatusUnauthorized, "Current password is incorrect")
+	}
+
+	password, err := s.Hasher.Hash(changePasswordRequest.Password)
+	if err != nil {
+		slog.Error("Error occurred while hashing password", "err", err)
+		return err
+	}
+
+	_, err = s.DB.ExecContext(c.Context(), "UPDATE users SET password = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", password, user.Id)
+	if err != nil {
+		slog.Error("Error occurred while updating user", "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to change password")
+	}
+
+	if err := shared.ResetRateLimit(c.Context(), s.RedisClient, attemptsKey); err != nil {
+		slog.Warn("Error occurred while resetting rate limit", "err", err)
+	}
+
+	publishUserUpdated(c.Context(), s.Producer, user.Id, "password")
+
+	return c.JSON(fiber.Map{
+		"message": "Password changed successfully",
+		"data":    nil,
+		"errors":  nil,
+	})
+}
+
+func (s *UserService) getProfile(c *fiber.Ctx) (*ProfileResponse, error) {
+	user, err := s.getUser(c.Context(), shared.GetUserClaims(c).Subject, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	roles, _, err := getRolesAndPermissions(c.Context(), s.DB, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &ProfileResponse{
+		Id:          user.Id,
+		Name:        user.Name,
+		Email:       user.Email,
+		PhoneNumber: user.PhoneNumber,
+		Tier:        user.Tier,
+		Roles:       roles,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+	}
+	if !user.EmailVerifiedAt.IsZero() {
+		profile.EmailVerifiedAt = &user.EmailVerifiedAt
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/akmmp241/topupstore-microservice/shared"
+	upb "github.com/akmmp241/topupstore-microservice/user-proto/v1"
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	ReferralStatusPending   = "pending"
+	ReferralStatusQualified = "qualified"
+	ReferralStatusRewarded  = "rewarded"
+	ReferralStatusRejected  = "rejected"
+	ReferralStatusRevoked   = "revoked"
+)
+
+// reasons a referral is rejected at sign up, the account is still created
+const (
+	ReferralRejectSamePhone  = "same-phone"
+	ReferralRejectSameIp     = "same-ip"
+	ReferralRejectSameDevice = "same-device"
+	ReferralRejectIpVelocity = "ip-velocity"
+)
+
+const (
+	referralCodeLength   = 8
+	referralCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	referralCodeAttempts = 5
+
+	referralsPerIpWindow = 24 * time.Hour
+	maxReferralsPerIp    = 3
+
+	defaultReferrerReward = 10000
+	defaultReferredReward = 5000
+)
+
+type Referral struct {
+	Id             int        `json:"id"`
+	ReferredName   string     `json:"referred_name"`
+	Status         string     `json:"status"`
+	ReferrerReward int        `json:"reward"`
+	CreatedAt      time.Time  `json:"created_at"`
+	RewardedAt     *time.Time `json:"rewarded_at"`
+}
+
+type ReferralStats struct {
+	Total    int `json:"total"`
+	Pending  int `json:"pending"`
+	Rewarded int `json:"rewarded"`
+	Rejected int `json:"rejected"`
+	Earned   int `json:"earned"`
+}
+
+func getReferralReward(key string, fallback int) int {
+	reward, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || reward < 0 {
+		return fallback
+	}
+
+	return reward
+}
+
+func newReferralCode() (string, error) {
+	code := make([]byte, referralCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(referralCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = referralCodeAlphabet[n.Int64()]
+	}
+
+	return string(code), nil
+}
+
+// assignReferralCode gives the user a code unless it has one, retrying on the
+// rare collision with the code of another user.
+func assignReferralCode(ctx context.Context, db interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}, userId int64) error {
+	for range referralCodeAttempts {
+		code, err := newReferralCode()
+		if err != nil {
+			return err
+		}
+
+		_, err = db.ExecContext(ctx, "UPDATE users SET referral_code = ? WHERE id = ? AND referral_code IS NULL", code, userId)
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			continue
+		}
+
+		return err
+	}
+
+	return errors.New("failed to generate a unique referral code")
+}
+
+// createReferral links a new user to the owner of referralCode. Referrals that
+// look like the referrer signing up themselves are stored as rejected, so
+// they never pay out but stay visible to support.
+func createReferral(ctx context.Context, tx *sql.Tx, referredId int64, req *upb.CreateUserReq) error {
+	var referrerId int64
+	var referrerPhone string
+	var referrerIp, referrerDevice sql.NullString
+	err := tx.QueryRowContext(ctx, "SELECT id, phone_number, signup_ip, signup_device FROM users WHERE referral_code = ? AND status = ? AND deleted_at IS NULL",
+		req.GetReferralCode(), shared.UserStatusActive).Scan(&referrerId, &referrerPhone, &referrerIp, &referrerDevice)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return status.Error(codes.InvalidArgument, "Invalid referral code")
+		}
+		slog.Error("Error occurred while querying referrer", "err", err)
+		return err
+	}
+
+	var recentFromIp int
+	if req.GetSignupIp() != "" {
+		err = tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM referrals WHERE signup_ip = ? AND created_at > ?",
+			req.GetSignupIp(), time.Now().Add(-referralsPerIpWindow)).Scan(&recentFromIp)
+		if err != nil {
+			slog.Error("Error occurred while counting referrals by ip", "err", err)
+			return err
+		}
+	}
+
+	referralStatus := ReferralStatusPending
+	var rejectReason any
+	switch {
+	case referrerPhone == req.GetPhoneNumber():
+		rejectReason = ReferralRejectSamePhone
+	case req.GetSignupIp() != "" && referrerIp.String == req.GetSignupIp():
+		rejectReason = ReferralRejectSameIp
+	case req.GetSignupDevice() != "" && referrerDevice.String == req.GetSignupDevice():
+		rejectReason = ReferralRejectSameDevice
+	case recentFromIp >= maxReferralsPerIp:
+		rejectReason = ReferralRejectIpVelocity
+	}
+	if rejectReason != nil {
+		referralStatus = ReferralStatusRejected
+		slog.Warn("Referral rejected", "referrer-id", referrerId, "referred-id", referredId, "reason", rejectReason)
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO referrals (referrer_id, referred_id, status, reject_reason, signup_ip, signup_device, referrer_reward, referred_reward)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		referrerId, referredId, referralStatus, rejectReason, req.GetSignupIp(), req.GetSignupDevice(),
+		getReferralReward("REFERRAL_REFERRER_REWARD", defaultReferrerReward), getReferralReward("REFERRAL_REFERRED_REWARD", defaultReferredReward))
+	if err != nil {
+		slog.Error("Error occurred while inserting referral", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *UserService) handleGetReferrals(c *fiber.Ctx) error {
+	userId, err := strconv.Atoi(shared.GetUserClaims(c).Subject)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "Invalid token")
+	}
+
+	// accounts created before referrals existed get their code on first visit
+	var code sql.NullString
+	if err := s.DB.QueryRowContext(c.Context(), "SELECT referral_code FROM users WHERE id = ?", userId).Scan(&code); err != nil {
+		slog.Error("Error occurred while querying referral code", "err", err)
+		return err
+	}
+	if !code.Valid {
+		if err := assignReferralCode(c.Context(), s.DB, int64(userId)); err != nil {
+			slog.Error("Error occurred while assigning referral code", "err", err)
+			return err
+		}
+		if err := s.DB.QueryRowContext(c.Context(), "SELECT referral_code FROM users WHERE id = ?", userId).Scan(&code); err != nil {
+			return err
+		}
+	}
+
+	rows, err := s.DB.QueryContext(c.Context(), `SELECT r.id, u.name, r.status, r.referrer_reward, r.created_at, r.rewarded_at
+				FROM referrals r JOIN users u ON u.id = r.referred_id WHERE r.referrer_id = ? ORDER BY r.id DESC`, userId)
+	if err != nil {
+		slog.Error("Error occurred while querying referrals", "err", err)
+		return err
+	}
+	defer rows.Close()
+
+	stats := ReferralStats{}
+	referrals := []*Referral{}
+	for rows.Next() {
+		var referral Referral
+		var rewardedAt sql.NullTime
+		if err := rows.Scan(&referral.Id, &referral.ReferredName, &referral.Status, &referral.ReferrerReward, &referral.CreatedAt, &rewardedAt); err != nil {
+			slog.Error("Error occurred while scanning referral", "err", err)
+			return err
+		}
+		if rewardedAt.Valid {
+			referral.RewardedAt = &rewardedAt.Time
+		}
+		referral.ReferredName = maskName(referral.ReferredName)
+
+		stats.Total++
+		switch referral.Status {
+		case ReferralStatusPending, ReferralStatusQualified:
+			stats.Pending++
+		case ReferralStatusRewarded:
+			stats.Rewarded++
+			stats.Earned += referral.ReferrerReward
+		case ReferralStatusRejected, ReferralStatusRevoked:
+			stats.Rejected++
+		}
+
+		referrals = append(referrals, &referral)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Referrals retrieved successfully",
+		"data": fiber.Map{
+			"referral_code": code.String,
+			"stats":         stats,
+			"referrals":     referrals,
+		},
+		"errors": nil,
+	})
+}
+
+// maskName keeps the first letter, referrers should recognise their friends
+// without the list leaking full names.
+func maskName(name string) string {
+	runes := []rune(name)
+	if len(runes) <= 1 {
+		return name
+	}
+
+	return string(runes[0]) + "***"
+}
+
+func scanReferral(row *sql.Row) (*upb.Referral, error) {
+	var referral upb.Referral
+	var orderId sql.NullString
+	err := row.Scan(&referral.Id, &referral.ReferrerId, &referral.ReferredId, &referral.Status, &orderId,
+		&referral.ReferrerReward, &referral.ReferredReward)
+	if err != nil {
+		return nil, err
+	}
+	referral.OrderId = orderId.String
+
+	return &referral, nil
+}
+
+const referralColumns = "id, referrer_id, referred_id, status, order_id, referrer_reward, referred_reward"
+
+// QualifyReferral is called by order service when the first order of a
+// referred user succeeds. Calling it again for the same order returns the
+// referral again, so order service can retry paying the rewards.
+func (s *GrpcServer) QualifyReferral(ctx context.Context, req *upb.QualifyReferralReq) (res *upb.ReferralRes, err error) {
+	if req.GetOrderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Order id is required")
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	referral, err := scanReferral(tx.QueryRowContext(ctx, "SELECT "+referralColumns+" FROM referrals WHERE referred_id = ? FOR UPDATE", req.GetUserId()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "User was not referred")
+		}
+		slog.Error("Error occurred while querying referral", "err", err)
+		return nil, err
+	}
+
+	switch referral.GetStatus() {
+	case ReferralStatusPending:
+	case ReferralStatusQualified:
+		if referral.GetOrderId() == req.GetOrderId() {
+			return &upb.ReferralRes{Referral: referral}, nil
+		}
+		return nil, status.Error(codes.FailedPrecondition, "Referral was qualified by another order")
+	default:
+		return nil, status.Errorf(codes.FailedPrecondition, "Referral is %s", referral.GetStatus())
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE referrals SET status = ?, order_id = ?, qualified_at = ? WHERE id = ?",
+		ReferralStatusQualified, req.GetOrderId(), time.Now(), referral.GetId())
+	if err != nil {
+		slog.Error("Error occurred while qualifying referral", "err", err)
+		return nil, err
+	}
+
+	referral.Status = ReferralStatusQualified
+	referral.OrderId = req.GetOrderId()
+
+	return &upb.ReferralRes{Referral: referral}, nil
+}
+
+// CompleteReferral marks the rewards of a qualified referral as paid and lets
+// both users know.
+func (s *GrpcServer) CompleteReferral(ctx context.Context, req *upb.CompleteReferralReq) (*upb.ReferralRes, error) {
+	result, err := s.DB.ExecContext(ctx, "UPDATE referrals SET status = ?, rewarded_at = ? WHERE id = ? AND status = ?",
+		ReferralStatusRewarded, time.Now(), req.GetId(), ReferralStatusQualified)
+	if err != nil {
+		slog.Error("Error occurred while completing referral", "err", err)
+		return nil, err
+	}
+
+	referral, err := scanReferral(s.DB.QueryRowContext(ctx, "SELECT "+referralColumns+" FROM referrals WHERE id = ?", req.GetId()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "Referral not found")
+		}
+		slog.Error("Error occurred while querying referral", "err", err)
+		return nil, err
+	}
+
+	if referral.GetStatus() != ReferralStatusRewarded {
+		return nil, status.Errorf(codes.FailedPrecondition, "Referral is %s", referral.GetStatus())
+	}
+
+	// only the call that changed the status sends the mails
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected > 0 {
+		s.publishReferralRewarded(ctx, int(referral.GetReferrerId()), int(referral.GetReferrerReward()), ReferralRoleReferrer)
+		s.publishReferralRewarded(ctx, int(referral.GetReferredId()), int(referral.GetReferredReward()), ReferralRoleReferred)
+	}
+
+	return &upb.ReferralRes{Referral: referral}, nil
+}
+
+// RevokeReferral is called by order service when the order that qualified a
+// referral is refunded. A revoked referral cannot qualify again, the rewards
+// it paid are clawed back by order service.
+func (s *GrpcServer) RevokeReferral(ctx context.Context, req *upb.RevokeReferralReq) (*upb.ReferralRes, error) {
+	if req.GetOrderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "Order id is required")
+	}
+
+	_, err := s.DB.ExecContext(ctx, "UPDATE referrals SET status = ?, revoked_at = ? WHERE order_id = ? AND status IN (?, ?)",
+		ReferralStatusRevoked, time.Now(), req.GetOrderId(), ReferralStatusQualified, ReferralStatusRewarded)
+	if err != nil {
+		slog.Error("Error occurred while revoking referral", "err", err)
+		return nil, err
+	}
+
+	referral, err := scanReferral(s.DB.QueryRowContext(ctx, "SELECT "+referralColumns+" FROM referrals WHERE order_id = ?", req.GetOrderId()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, status.Error(codes.NotFound, "Order did not qualify a referral")
+		}
+		slog.Error("Error occurred while querying referral", "err", err)
+		return nil, err
+	}
+
+	if referral.GetStatus() != ReferralStatusRevoked {
+		return nil, status.Errorf(codes.FailedPrecondition, "Referral is %s", referral.GetStatus())
+	}
+
+	return &upb.ReferralRes{Referral: referral}, nil
+}
+
+func (s *GrpcServer) publishReferralRewarded(ctx context.Context, userId int, amount int, role string) {
+	if amount == 0 {
+		return
+	}
+
+	user, err := s.getUser(ctx, strconv.Itoa(userId), "id")
+	if err != nil {
+		slog.Error("Error occurred while getting rewarded user", "user-id", userId, "err", err)
+		return
+	}
+
+	_ = publishUserEvent(ctx, s.Producer, ReferralRewarded, &ReferralRewardedMessage{
+		UserId: userId,
+		Name:   user.GetName(),
+		Email:  user.GetEmail(),
+		Role:   role,
+		Amount: amount,
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"slices"
+)
+
+type Role struct {
+	Id          int      `json:"id"`
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// getRolesAndPermissions returns the role names of a user and the union of
+// the permissions granted by those roles.
+func getRolesAndPermissions(ctx context.Context, db querier, userId int) ([]string, []string, error) {
+	query := `SELECT r.name, p.name FROM user_roles ur
+				JOIN roles r ON r.id = ur.role_id
+				LEFT JOIN role_permissions rp ON rp.role_id = r.id
+				LEFT JOIN permissions p ON p.id = rp.permission_id
+			WHERE ur.user_id = ? ORDER BY r.name, p.name`
+
+	rows, err := db.QueryContext(ctx, query, userId)
+	if err != nil {
+		slog.Error("Error occurred while querying user roles", "err", err)
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	permissions := []string{}
+	for rows.Next() {
+		var role string
+		var permission sql.NullString
+		if err := rows.Scan(&role, &permission); err != nil {
+			slog.Error("Error occurred while scanning user role", "err", err)
+			return nil, nil, err
+		}
+
+		if !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+		if permission.Valid && !slices.Contains(permissions, permission.String) {
+			permissions = append(permissions, permission.String)
+		}
+	}
+
+	return roles, permissions, nil
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log/slog"
 	"os"
 
+	"github.com/akmmp241/topupstore-microservice/shared"
 	"github.com/go-playground/validator/v10"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gofiber/fiber/v2"
@@ -15,15 +17,24 @@ type AppServer struct {
 	db     *sql.DB
 }
 
-func NewAppServer(db *sql.DB) *AppServer {
-	server := fiber.New()
+func NewAppServer(db *sql.DB, producer *KafkaProducer, hasher *PasswordHasher) *AppServer {
+	server := fiber.New(fiber.Config{
+		ErrorHandler:            shared.ErrorHandler,
+		ProxyHeader:             shared.ClientIPHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          shared.TrustedProxies(),
+	})
 	validate := validator.New()
 
 	api := server.Group("/api")
 
-	userService := NewUserService(validate, db)
+	userService := NewUserService(validate, db, producer, shared.NewRedis(), hasher)
 	userService.RegisterRoutes(api)
 
+	go userService.RunDeletions(context.Background())
+	go userService.RunPointExpiry(context.Background())
+	go StartOrderConsumer(userService.HandleOrder)
+
 	return &AppServer{
 		server: server,
 	}
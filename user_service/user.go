@@ -6,9 +6,10 @@ type User struct {
 	Id                     int       `json:"id"`
 	Name                   string    `json:"name"`
 	Email                  string    `json:"email"`
-	Password               string    `json:"password"`
+	Password               string    `json:"-"`
 	PhoneNumber            string    `json:"phone_number"`
-	EmailVerificationToken string    `json:"email_verification_token"`
+	Tier                   string    `json:"tier"`
+	EmailVerificationToken string    `json:"-"`
 	EmailVerifiedAt        time.Time `json:"email_verified_at"`
 	CreatedAt              time.Time `json:"created_at"`
 	UpdatedAt              time.Time `json:"updated_at"`
@@ -8,93 +8,67 @@ import (
 	"github.com/akmmp241/topupstore-microservice/shared"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/go-playground/validator/v10"
-	"github.com/go-sql-driver/mysql"
 	"github.com/gofiber/fiber/v2"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/redis/go-redis/v9"
 	"log/slog"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type UserService struct {
-	Validator *validator.Validate
-	DB        *sql.DB
-	Ctx       context.Context
+	Validator           *validator.Validate
+	DB                  *sql.DB
+	Ctx                 context.Context
+	Producer            *KafkaProducer
+	RedisClient         *redis.Client
+	Hasher              *PasswordHasher
+	DeletionGracePeriod time.Duration
 }
 
-func NewUserService(validator *validator.Validate, db *sql.DB) *UserService {
-	return &UserService{Validator: validator, DB: db, Ctx: context.Background()}
+func NewUserService(validator *validator.Validate, db *sql.DB, producer *KafkaProducer, redisClient *redis.Client, hasher *PasswordHasher) *UserService {
+	return &UserService{
+		Validator:           validator,
+		DB:                  db,
+		Ctx:                 context.Background(),
+		Producer:            producer,
+		RedisClient:         redisClient,
+		Hasher:              hasher,
+		DeletionGracePeriod: getDeletionGracePeriod(),
+	}
 }
 
 func (s *UserService) RegisterRoutes(router fiber.Router) {
+	// registered before the internal group so its service middleware never
+	// sees requests for /users/me
+	meAPI := router.Group("/users/me", shared.JWTUserMiddleware)
+	meAPI.Get("/", s.handleGetMe)
+	meAPI.Patch("/", s.handleUpdateMe)
+	meAPI.Post("/password", s.handleChangePassword)
+	meAPI.Get("/export", s.handleExport)
+	meAPI.Post("/deletion", s.handleRequestDeletion)
+	meAPI.Delete("/deletion", s.handleCancelDeletion)
+	meAPI.Get("/destinations", s.handleGetDestinations)
+	meAPI.Post("/destinations", s.handleCreateDestination)
+	meAPI.Put("/destinations/:id", s.handleUpdateDestination)
+	meAPI.Delete("/destinations/:id", s.handleDeleteDestination)
+	meAPI.Get("/referrals", s.handleGetReferrals)
+	meAPI.Get("/points", s.handleGetPoints)
+
+	// opened from the confirmation email, the token is the credential
+	router.Get("/users/deletion/:token", s.handleConfirmDeletion)
+
 	internalAPI := router.Group("/users")
 	internalAPI.Use(shared.JWTServiceMiddleware)
-	internalAPI.Post("/", s.handleCreateUser)
 	internalAPI.Get("/", s.handleGetUser)
 	internalAPI.Put("/", s.handleUpdateUser)
 	internalAPI.Delete("/:id", s.handleDeleteUser)
-	internalAPI.Patch("/verify/:token", s.handleVerifyEmail)
-
-	router.Get("/me", s.handleGetUser)
-}
-
-func (s *UserService) handleCreateUser(c *fiber.Ctx) error {
-	registerRequest := &RegisterRequest{}
-	err := c.BodyParser(registerRequest)
-	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
-	}
-
-	err = s.Validator.Struct(registerRequest)
-	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
-		return shared.NewFailedValidationError(*registerRequest, err.(validator.ValidationErrors))
-	}
-
-	// Hash the password
-	password, err := bcrypt.GenerateFromPassword([]byte(registerRequest.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return err
-	}
-	registerRequest.Password = string(password)
-
-	tx, err := s.DB.Begin()
-	if err != nil {
-		return err
-	}
-	defer shared.CommitOrRollback(tx, err)
-
-	result, err := tx.ExecContext(s.Ctx, "INSERT INTO users (id, name, email, password,phone_number, email_verification_token) VALUES (NULL, ?, ?, ?, ?, ?)",
-		registerRequest.Name, registerRequest.Email, registerRequest.Password, registerRequest.PhoneNumber, registerRequest.EmailVerificationToken)
-	if err != nil {
-		if mysqlErr, ok := err.(*mysql.MySQLError); ok {
-			slog.Error("MySQL error occurred", "code", mysqlErr.Number, "message", mysqlErr.Message)
-			// Check for duplicate entry error
-			if mysqlErr.Number == 1062 {
-				errMsg := ""
-				// Parse the error message to find which constraint was violated
-				if strings.Contains(mysqlErr.Message, "email") {
-					errMsg = "email already exists"
-				} else if strings.Contains(mysqlErr.Message, "phone_number") {
-					errMsg = "phone number already exists"
-				}
-				return fiber.NewError(fiber.StatusConflict, "Duplicate entry. "+errMsg)
-			}
-		}
-		slog.Info("Error occurred while inserting user", "err", err)
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil || rowsAffected == 0 {
-		slog.Info("No rows affected while inserting user", "err", err)
-		return fiber.NewError(fiber.StatusInternalServerError, "Failed to create user")
-	}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message": "User created successfully",
-		"data":    nil,
-		"errors":  nil,
-	})
+	adminAPI := router.Group("/admin")
+	adminAPI.Get("/roles", shared.RequirePermission(shared.PermUsersManageRoles), s.handleGetRoles)
+	adminAPI.Put("/users/:id/roles", shared.RequirePermission(shared.PermUsersManageRoles), s.handleUpdateUserRoles)
+	adminAPI.Put("/users/:id/tier", shared.RequirePermission(shared.PermUsersWrite), s.handleUpdateUserTier)
 }
 
 func (s *UserService) handleGetUser(c *fiber.Ctx) error {
@@ -158,27 +132,21 @@ func (s *UserService) handleUpdateUser(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	if updateRequest.Email != "" {
-		user.Email = updateRequest.Email
-	}
 	if updateRequest.Name != "" {
 		user.Name = updateRequest.Name
 	}
-	if updateRequest.PhoneNumber != "" {
-		user.PhoneNumber = updateRequest.PhoneNumber
-	}
 
-	// Encrypt pass
-	password, err := bcrypt.GenerateFromPassword([]byte(updateRequest.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return err
-	}
 	if updateRequest.Password != "" {
 		// Validate password length
 		if len(updateRequest.Password) < 8 || len(updateRequest.Password) > 255 {
 			return fiber.NewError(fiber.StatusBadRequest, "Password must be between 8 and 255 characters")
 		}
-		user.Password = string(password)
+
+		// Encrypt pass
+		user.Password, err = s.Hasher.Hash(updateRequest.Password)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Transaction stuffs
@@ -189,9 +157,9 @@ func (s *UserService) handleUpdateUser(c *fiber.Ctx) error {
 	defer shared.CommitOrRollback(tx, err)
 
 	// Build n exec query
-	query := spew.Sprintf("UPDATE users SET email = ?, name = ?, phone_number = ?, password = ?, updated_at = CURRENT_TIMESTAMP WHERE %s = ?", column)
+	query := spew.Sprintf("UPDATE users SET name = ?, password = ?, updated_at = CURRENT_TIMESTAMP WHERE %s = ?", column)
 
-	result, err := tx.ExecContext(s.Ctx, query, user.Email, user.Name, user.PhoneNumber, user.Password, target)
+	result, err := tx.ExecContext(s.Ctx, query, user.Name, user.Password, target)
 
 	if err != nil {
 		slog.Info("Internal server error", "err", err)
@@ -205,6 +173,12 @@ func (s *UserService) handleUpdateUser(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "Failed to update user")
 	}
 
+	changes := []string{"name"}
+	if updateRequest.Password != "" {
+		changes = append(changes, "password")
+	}
+	publishUserUpdated(s.Ctx, s.Producer, user.Id, changes...)
+
 	return c.JSON(fiber.Map{
 		"message": "User updated successfully",
 		"data":    nil,
@@ -212,60 +186,45 @@ func (s *UserService) handleUpdateUser(c *fiber.Ctx) error {
 	})
 }
 
+// handleDeleteUser is the internal, already confirmed variant of the self
+// service deletion: it skips the email and the grace period.
 func (s *UserService) handleDeleteUser(c *fiber.Ctx) error {
-	// Handle deleting user
-	return c.SendString("User deleted successfully")
-}
-
-func (s *UserService) handleVerifyEmail(c *fiber.Ctx) error {
-	token := c.Params("token")
-	if token == "" {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid token")
-	}
-
-	query := "SELECT id, name, email, password, phone_number, email_verification_token, email_verified_at, created_at, updated_at FROM users WHERE email_verification_token = ? AND email_verified_at IS NULL"
-
-	rows, err := s.DB.QueryContext(s.Ctx, query, token)
+	userId, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		slog.Error("Error occurred while querying user", "err", err)
-		return err
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
 	}
-	defer rows.Close()
 
-	var user User
-	if !rows.Next() {
-		return fiber.NewError(fiber.StatusNotFound, "User not found")
+	if _, err := s.getUser(c.Context(), strconv.Itoa(userId), "id"); err != nil {
+		return err
 	}
 
-	var emailVerifiedAt sql.NullTime
-	err = rows.Scan(&user.Id, &user.Name, &user.Email, &user.Password, &user.PhoneNumber, &user.EmailVerificationToken, &emailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
+	now := time.Now()
+	result, err := s.DB.ExecContext(c.Context(), "INSERT INTO account_deletions (user_id, token_hash, expires_at, confirmed_at, scheduled_for) VALUES (?, '', ?, ?, ?)",
+		userId, now, now, now)
 	if err != nil {
-		slog.Error("Error occurred while scanning user", "err", err)
+		slog.Error("Error occurred while inserting account deletion", "err", err)
 		return err
 	}
 
-	if emailVerifiedAt.Valid {
-		user.EmailVerifiedAt = emailVerifiedAt.Time
-	}
-
-	// Update the email verification token and set email verified at
-	user.EmailVerifiedAt = time.Now()
-
-	_, err = s.DB.ExecContext(s.Ctx, "UPDATE users SET email_verification_token = ?, email_verified_at = ? WHERE id = ?", nil, user.EmailVerifiedAt, user.Id)
+	deletionId, err := result.LastInsertId()
 	if err != nil {
-		slog.Error("Error occurred while updating user", "err", err)
 		return err
 	}
 
+	if err := s.executeDeletion(c.Context(), int(deletionId), userId); err != nil {
+		slog.Error("Error occurred while deleting account", "user-id", userId, "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Failed to delete user")
+	}
+
 	return c.JSON(fiber.Map{
-		"message": "Email verified",
+		"message": "User deleted successfully",
 		"data":    nil,
 		"errors":  nil,
 	})
 }
 
 func (s *UserService) getUser(ctx context.Context, target string, column string) (*User, error) {
-	query := fmt.Sprintf("SELECT id, name, email, password, phone_number, email_verification_token, email_verified_at, created_at, updated_at FROM users WHERE %s = ?", column)
+	query := fmt.Sprintf("SELECT id, name, email, password, phone_number, tier, email_verification_token, email_verified_at, created_at, updated_at FROM users WHERE %s = ?", column)
 
 	rows, err := s.DB.QueryContext(ctx, query, target)
 	if err != nil {
@@ -281,7 +240,7 @@ func (s *UserService) getUser(ctx context.Context, target string, column string)
 
 	var emailVerificationToken sql.NullString
 	var emailVerifiedAt sql.NullTime
-	err = rows.Scan(&user.Id, &user.Name, &user.Email, &user.Password, &user.PhoneNumber, &emailVerificationToken, &emailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
+	err = rows.Scan(&user.Id, &user.Name, &user.Email, &user.Password, &user.PhoneNumber, &user.Tier, &emailVerificationToken, &emailVerifiedAt, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		slog.Debug("Error occurred while scanning user", "err", err)
 		return nil, err
@@ -296,3 +255,162 @@ func (s *UserService) getUser(ctx context.Context, target string, column string)
 
 	return &user, nil
 }
+
+func (s *UserService) handleGetRoles(c *fiber.Ctx) error {
+	query := `SELECT r.id, r.name, p.name FROM roles r
+				LEFT JOIN role_permissions rp ON rp.role_id = r.id
+				LEFT JOIN permissions p ON p.id = rp.permission_id
+			ORDER BY r.id, p.name`
+
+	rows, err := s.DB.QueryContext(s.Ctx, query)
+	if err != nil {
+		slog.Error("Error occurred while querying roles", "err", err)
+		return err
+	}
+	defer rows.Close()
+
+	var roles []*Role
+	for rows.Next() {
+		var id int
+		var name string
+		var permission sql.NullString
+		if err := rows.Scan(&id, &name, &permission); err != nil {
+			slog.Error("Error occurred while scanning role", "err", err)
+			return err
+		}
+
+		if len(roles) == 0 || roles[len(roles)-1].Id != id {
+			roles = append(roles, &Role{Id: id, Name: name, Permissions: []string{}})
+		}
+		if permission.Valid {
+			roles[len(roles)-1].Permissions = append(roles[len(roles)-1].Permissions, permission.String)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Roles retrieved successfully",
+		"data":    roles,
+		"errors":  nil,
+	})
+}
+
+func (s *UserService) handleUpdateUserRoles(c *fiber.Ctx) error {
+	userId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	updateRolesRequest := &UpdateUserRolesRequest{}
+	if err := c.BodyParser(updateRolesRequest); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err = s.Validator.Struct(updateRolesRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*updateRolesRequest, err.(validator.ValidationErrors))
+	}
+
+	if _, err := s.getUser(s.Ctx, strconv.Itoa(userId), "id"); err != nil {
+		return err
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(updateRolesRequest.Roles)), ",")
+	args := make([]any, 0, len(updateRolesRequest.Roles))
+	for _, role := range updateRolesRequest.Roles {
+		args = append(args, role)
+	}
+
+	rows, err := s.DB.QueryContext(s.Ctx, "SELECT id FROM roles WHERE name IN ("+placeholders+")", args...)
+	if err != nil {
+		slog.Error("Error occurred while querying roles", "err", err)
+		return err
+	}
+	defer rows.Close()
+
+	var roleIds []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			slog.Error("Error occurred while scanning role", "err", err)
+			return err
+		}
+		roleIds = append(roleIds, id)
+	}
+
+	if len(roleIds) != len(slices.Compact(slices.Sorted(slices.Values(updateRolesRequest.Roles)))) {
+		return fiber.NewError(fiber.StatusBadRequest, "One or more roles do not exist")
+	}
+
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { shared.CommitOrRollback(tx, err) }()
+
+	_, err = tx.ExecContext(s.Ctx, "DELETE FROM user_roles WHERE user_id = ?", userId)
+	if err != nil {
+		slog.Error("Error occurred while deleting user roles", "err", err)
+		return err
+	}
+
+	for _, roleId := range roleIds {
+		_, err = tx.ExecContext(s.Ctx, "INSERT INTO user_roles (user_id, role_id) VALUES (?, ?)", userId, roleId)
+		if err != nil {
+			slog.Error("Error occurred while inserting user role", "err", err)
+			return err
+		}
+	}
+
+	slog.Info("User roles updated", "user-id", userId, "roles", updateRolesRequest.Roles, "by", shared.GetUserClaims(c).Subject)
+
+	// roles are embedded in access tokens, the user logs in again to get the new ones
+	if err := shared.RevokeUserTokens(c.Context(), userId); err != nil {
+		slog.Error("Error occurred while revoking user tokens", "user-id", userId, "err", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Roles were updated but existing sessions could not be ended")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "User roles updated successfully",
+		"data":    fiber.Map{"roles": updateRolesRequest.Roles},
+		"errors":  nil,
+	})
+}
+
+func (s *UserService) handleUpdateUserTier(c *fiber.Ctx) error {
+	userId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	updateTierRequest := &UpdateUserTierRequest{}
+	if err := c.BodyParser(updateTierRequest); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	err = s.Validator.Struct(updateTierRequest)
+	if err != nil && errors.As(err, &validator.ValidationErrors{}) {
+		return shared.NewFailedValidationError(*updateTierRequest, err.(validator.ValidationErrors))
+	}
+
+	result, err := s.DB.ExecContext(c.Context(), "UPDATE users SET tier = ? WHERE id = ? AND deleted_at IS NULL", updateTierRequest.Tier, userId)
+	if err != nil {
+		slog.Error("Error occurred while updating user tier", "err", err)
+		return err
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		// also when the tier did not change, tell that apart from a missing user
+		if _, err := s.getUser(c.Context(), strconv.Itoa(userId), "id"); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("User tier updated", "user-id", userId, "tier", updateTierRequest.Tier, "by", shared.GetUserClaims(c).Subject)
+	publishUserUpdated(c.Context(), s.Producer, userId, "tier")
+
+	return c.JSON(fiber.Map{
+		"message": "User tier updated successfully",
+		"data":    fiber.Map{"tier": updateTierRequest.Tier},
+		"errors":  nil,
+	})
+}